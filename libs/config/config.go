@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgstrings "code.vegaprotocol.io/shared/libs/strings"
+	"code.vegaprotocol.io/shared/paths"
+)
+
+// Layer identifies where a configuration value comes from. The layers are
+// applied in the order they are declared, the last one winning.
+type Layer string
+
+const (
+	DefaultsLayer    Layer = "defaults"
+	FileLayer        Layer = "file"
+	EnvironmentLayer Layer = "environment"
+	OverridesLayer   Layer = "overrides"
+)
+
+// Origins maps the path of every configuration field, like "API.Port", to the
+// layer that supplied its final value.
+type Origins map[string]Layer
+
+type Origin struct {
+	Path  string `json:"path"`
+	Layer Layer  `json:"layer"`
+}
+
+// Sorted returns the origins sorted by field path.
+func (o Origins) Sorted() []Origin {
+	origins := make([]Origin, 0, len(o))
+	for path, layer := range o {
+		origins = append(origins, Origin{
+			Path:  path,
+			Layer: layer,
+		})
+	}
+
+	sort.Slice(origins, func(i, j int) bool {
+		return origins[i].Path < origins[j].Path
+	})
+
+	return origins
+}
+
+type LoadOptions struct {
+	// FilePath is the path of the configuration file. The file layer is
+	// skipped if it's empty, or if the file doesn't exist.
+	FilePath string
+
+	// EnvPrefix is the prefix of the environment variables. The variable
+	// name of a field is derived from its path, so with the prefix "VEGA",
+	// the field "API.MaxRetries" is read from "VEGA_API_MAX_RETRIES". The
+	// environment layer is skipped if it's empty.
+	EnvPrefix string
+
+	// Overrides are the explicit values, usually coming from command-line
+	// flags, indexed by field path, like "API.Port".
+	Overrides map[string]string
+
+	// LookupEnv looks up the environment variables. It defaults to
+	// os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+}
+
+// Load builds a configuration by merging, in order, the defaults, the file,
+// the environment variables and the overrides. It returns the configuration
+// and the origin of every field.
+func Load[T any](defaults T, options LoadOptions) (*T, Origins, error) {
+	if reflect.TypeOf(defaults) == nil || reflect.TypeOf(defaults).Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("the configuration must be a struct, got %T", defaults)
+	}
+
+	cfg := new(T)
+	reflect.ValueOf(cfg).Elem().Set(deepCopy(reflect.ValueOf(defaults)))
+
+	fields := listFields(reflect.TypeOf(defaults))
+	origins := Origins{}
+	for _, f := range fields {
+		origins[f.path] = DefaultsLayer
+	}
+
+	if err := applyFileLayer(cfg, fields, origins, options.FilePath); err != nil {
+		return nil, nil, err
+	}
+
+	if err := applyEnvironmentLayer(cfg, fields, origins, options); err != nil {
+		return nil, nil, err
+	}
+
+	if err := applyOverridesLayer(cfg, fields, origins, options.Overrides); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, origins, nil
+}
+
+// LoadFromConfigPath is a shortcut for Load, reading the file at the given
+// ConfigPath.
+func LoadFromConfigPath[T any](vegaPaths paths.Paths, configPath paths.ConfigPath, defaults T, options LoadOptions) (*T, Origins, error) {
+	options.FilePath = vegaPaths.ConfigPathFor(configPath)
+	return Load(defaults, options)
+}
+
+// EnvNameFor returns the name of the environment variable for the field at the
+// given path.
+func EnvNameFor(prefix string, fieldPath string) string {
+	segments := []string{}
+	if prefix != "" {
+		segments = append(segments, strings.TrimSuffix(prefix, "_"))
+	}
+	for _, name := range strings.Split(fieldPath, ".") {
+		segments = append(segments, vgstrings.ToScreamingSnakeCase(name))
+	}
+	return strings.Join(segments, "_")
+}
+
+func applyFileLayer[T any](cfg *T, fields []field, origins Origins, filePath string) error {
+	if filePath == "" {
+		return nil
+	}
+
+	exists, err := vgfs.FileExists(filePath)
+	if err != nil {
+		return fmt.Errorf("couldn't verify the configuration file presence: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	// The file is decoded on top of the defaults, so the fields it doesn't
+	// define keep their value.
+	if err := paths.ReadStructuredFile(filePath, cfg); err != nil {
+		return fmt.Errorf("couldn't read the configuration file: %w", err)
+	}
+
+	// The file is decoded a second time, as a raw document, to find out which
+	// fields it defines.
+	doc := map[string]interface{}{}
+	if err := paths.ReadStructuredFile(filePath, &doc); err != nil {
+		return fmt.Errorf("couldn't read the configuration file: %w", err)
+	}
+
+	tagKey := strings.ToLower(paths.CodecForPath(filePath).Name())
+	for _, f := range fields {
+		if isDefinedIn(doc, f.structFields, tagKey) {
+			origins[f.path] = FileLayer
+		}
+	}
+
+	return nil
+}
+
+func applyEnvironmentLayer[T any](cfg *T, fields []field, origins Origins, options LoadOptions) error {
+	if options.EnvPrefix == "" {
+		return nil
+	}
+
+	lookupEnv := options.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	root := reflect.ValueOf(cfg).Elem()
+	for _, f := range fields {
+		envName := EnvNameFor(options.EnvPrefix, f.path)
+		rawValue, ok := lookupEnv(envName)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(f.valueIn(root), rawValue); err != nil {
+			return fmt.Errorf("invalid value for environment variable %s: %w", envName, err)
+		}
+		origins[f.path] = EnvironmentLayer
+	}
+
+	return nil
+}
+
+func applyOverridesLayer[T any](cfg *T, fields []field, origins Origins, overrides map[string]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	fieldsByPath := make(map[string]field, len(fields))
+	for _, f := range fields {
+		fieldsByPath[strings.ToLower(f.path)] = f
+	}
+
+	root := reflect.ValueOf(cfg).Elem()
+	for path, rawValue := range overrides {
+		f, ok := fieldsByPath[strings.ToLower(path)]
+		if !ok {
+			return fmt.Errorf("couldn't override %q: no such configuration field", path)
+		}
+
+		if err := setValue(f.valueIn(root), rawValue); err != nil {
+			return fmt.Errorf("invalid override for %s: %w", f.path, err)
+		}
+		origins[f.path] = OverridesLayer
+	}
+
+	return nil
+}
+
+// isDefinedIn tells if the raw document defines the field reached through the
+// given struct fields. Keys are matched against the tag of the format first,
+// then, case-insensitively, against the field name, as the decoders do.
+func isDefinedIn(doc map[string]interface{}, structFields []reflect.StructField, tagKey string) bool {
+	current := doc
+	for i, sf := range structFields {
+		if isFlattenedIn(sf, tagKey) {
+			// The fields of an embedded struct are at the level of its
+			// parent in the document.
+			continue
+		}
+
+		value, ok := lookupKey(current, sf, tagKey)
+		if !ok {
+			return false
+		}
+
+		if i == len(structFields)-1 {
+			return true
+		}
+
+		current, ok = value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+	}
+	return false
+}
+
+func lookupKey(doc map[string]interface{}, sf reflect.StructField, tagKey string) (interface{}, bool) {
+	if name := tagName(sf, tagKey); name != "" {
+		value, ok := doc[name]
+		return value, ok
+	}
+
+	for key, value := range doc {
+		if strings.EqualFold(key, sf.Name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func tagName(sf reflect.StructField, tagKey string) string {
+	tag, ok := sf.Tag.Lookup(tagKey)
+	if !ok {
+		return ""
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
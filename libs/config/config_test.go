@@ -0,0 +1,230 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	vgconfig "code.vegaprotocol.io/shared/libs/config"
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dummyConfig struct {
+	Level   string
+	API     dummyAPIConfig
+	Timeout time.Duration
+	Hosts   []string
+}
+
+type dummyAPIConfig struct {
+	Port       int    `toml:"port"`
+	MaxRetries uint32 `toml:"max_retries"`
+	Enabled    bool   `toml:"enabled"`
+}
+
+type DummyLoggingConfig struct {
+	Level  string
+	Format string
+}
+
+type dummyEmbeddingConfig struct {
+	DummyLoggingConfig
+	Level string
+	Port  int
+}
+
+func defaultDummyConfig() dummyConfig {
+	return dummyConfig{
+		Level: "info",
+		API: dummyAPIConfig{
+			Port:       8080,
+			MaxRetries: 3,
+			Enabled:    true,
+		},
+		Timeout: 5 * time.Second,
+		Hosts:   []string{"localhost"},
+	}
+}
+
+func TestLoadingConfig(t *testing.T) {
+	t.Run("Loading defaults only succeeds", testLoadingDefaultsOnlySucceeds)
+	t.Run("Loading with file overrides defaults", testLoadingWithFileOverridesDefaults)
+	t.Run("Loading with environment overrides file", testLoadingWithEnvironmentOverridesFile)
+	t.Run("Loading with explicit overrides wins", testLoadingWithExplicitOverridesWins)
+	t.Run("Loading with unknown override fails", testLoadingWithUnknownOverrideFails)
+	t.Run("Loading with invalid environment value fails", testLoadingWithInvalidEnvironmentValueFails)
+	t.Run("Loading from config path succeeds", testLoadingFromConfigPathSucceeds)
+	t.Run("Loading with embedded structs flattens their fields", testLoadingWithEmbeddedStructsFlattensTheirFields)
+	t.Run("Deriving environment variable names succeeds", testDerivingEnvironmentVariableNamesSucceeds)
+}
+
+func testLoadingDefaultsOnlySucceeds(t *testing.T) {
+	defaults := defaultDummyConfig()
+
+	cfg, origins, err := vgconfig.Load(defaults, vgconfig.LoadOptions{
+		FilePath: vgtest.RandomPath(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, defaults, *cfg)
+	assert.Equal(t, vgconfig.Origins{
+		"Level":          vgconfig.DefaultsLayer,
+		"API.Port":       vgconfig.DefaultsLayer,
+		"API.MaxRetries": vgconfig.DefaultsLayer,
+		"API.Enabled":    vgconfig.DefaultsLayer,
+		"Timeout":        vgconfig.DefaultsLayer,
+		"Hosts":          vgconfig.DefaultsLayer,
+	}, origins)
+}
+
+func testLoadingWithFileOverridesDefaults(t *testing.T) {
+	path := writeDummyConfigFile(t, "Level = \"debug\"\n[API]\nport = 9090\n")
+	defaults := defaultDummyConfig()
+
+	cfg, origins, err := vgconfig.Load(defaults, vgconfig.LoadOptions{
+		FilePath: path,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.Level)
+	assert.Equal(t, 9090, cfg.API.Port)
+	assert.Equal(t, uint32(3), cfg.API.MaxRetries)
+	assert.Equal(t, vgconfig.FileLayer, origins["Level"])
+	assert.Equal(t, vgconfig.FileLayer, origins["API.Port"])
+	assert.Equal(t, vgconfig.DefaultsLayer, origins["API.MaxRetries"])
+
+	// The defaults must not be altered.
+	assert.Equal(t, defaultDummyConfig(), defaults)
+}
+
+func testLoadingWithEnvironmentOverridesFile(t *testing.T) {
+	path := writeDummyConfigFile(t, "[API]\nport = 9090\n")
+	env := map[string]string{
+		"VEGA_API_PORT":        "7070",
+		"VEGA_API_MAX_RETRIES": "10",
+		"VEGA_TIMEOUT":         "1m",
+		"VEGA_HOSTS":           "a.vega.xyz, b.vega.xyz",
+	}
+
+	cfg, origins, err := vgconfig.Load(defaultDummyConfig(), vgconfig.LoadOptions{
+		FilePath:  path,
+		EnvPrefix: "VEGA",
+		LookupEnv: lookupIn(env),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7070, cfg.API.Port)
+	assert.Equal(t, uint32(10), cfg.API.MaxRetries)
+	assert.Equal(t, time.Minute, cfg.Timeout)
+	assert.Equal(t, []string{"a.vega.xyz", "b.vega.xyz"}, cfg.Hosts)
+	assert.Equal(t, vgconfig.EnvironmentLayer, origins["API.Port"])
+	assert.Equal(t, vgconfig.EnvironmentLayer, origins["Hosts"])
+	assert.Equal(t, vgconfig.DefaultsLayer, origins["Level"])
+}
+
+func testLoadingWithExplicitOverridesWins(t *testing.T) {
+	cfg, origins, err := vgconfig.Load(defaultDummyConfig(), vgconfig.LoadOptions{
+		EnvPrefix: "VEGA",
+		LookupEnv: lookupIn(map[string]string{"VEGA_API_ENABLED": "false"}),
+		Overrides: map[string]string{"api.enabled": "true"},
+	})
+	require.NoError(t, err)
+	assert.True(t, cfg.API.Enabled)
+	assert.Equal(t, vgconfig.OverridesLayer, origins["API.Enabled"])
+	assert.Equal(t, []vgconfig.Origin{
+		{Path: "API.Enabled", Layer: vgconfig.OverridesLayer},
+		{Path: "API.MaxRetries", Layer: vgconfig.DefaultsLayer},
+		{Path: "API.Port", Layer: vgconfig.DefaultsLayer},
+		{Path: "Hosts", Layer: vgconfig.DefaultsLayer},
+		{Path: "Level", Layer: vgconfig.DefaultsLayer},
+		{Path: "Timeout", Layer: vgconfig.DefaultsLayer},
+	}, origins.Sorted())
+}
+
+func testLoadingWithUnknownOverrideFails(t *testing.T) {
+	cfg, _, err := vgconfig.Load(defaultDummyConfig(), vgconfig.LoadOptions{
+		Overrides: map[string]string{"API.Unknown": "true"},
+	})
+	require.Error(t, err)
+	assert.Nil(t, cfg)
+}
+
+func testLoadingWithInvalidEnvironmentValueFails(t *testing.T) {
+	cfg, _, err := vgconfig.Load(defaultDummyConfig(), vgconfig.LoadOptions{
+		EnvPrefix: "VEGA",
+		LookupEnv: lookupIn(map[string]string{"VEGA_API_PORT": "not-a-number"}),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VEGA_API_PORT")
+	assert.Nil(t, cfg)
+}
+
+func testLoadingFromConfigPathSucceeds(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+
+	path, err := vegaPaths.CreateConfigPathFor(paths.WalletCLIDefaultConfigFile)
+	require.NoError(t, err)
+	require.NoError(t, vgfs.WriteFile(path, []byte("Level = \"warn\"\n")))
+
+	cfg, origins, err := vgconfig.LoadFromConfigPath(vegaPaths, paths.WalletCLIDefaultConfigFile, defaultDummyConfig(), vgconfig.LoadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.Level)
+	assert.Equal(t, vgconfig.FileLayer, origins["Level"])
+}
+
+func testDerivingEnvironmentVariableNamesSucceeds(t *testing.T) {
+	assert.Equal(t, "VEGA_API_MAX_RETRIES", vgconfig.EnvNameFor("VEGA", "API.MaxRetries"))
+	assert.Equal(t, "VEGA_HTTP_SERVER_PORT", vgconfig.EnvNameFor("VEGA_", "HTTPServer.Port"))
+	assert.Equal(t, "LEVEL", vgconfig.EnvNameFor("", "Level"))
+}
+
+func testLoadingWithEmbeddedStructsFlattensTheirFields(t *testing.T) {
+	path := writeDummyConfigFile(t, "Format = \"json\"\nLevel = \"debug\"\n")
+	defaults := dummyEmbeddingConfig{
+		DummyLoggingConfig: DummyLoggingConfig{Level: "info", Format: "text"},
+		Level:              "info",
+		Port:               8080,
+	}
+
+	cfg, origins, err := vgconfig.Load(defaults, vgconfig.LoadOptions{
+		FilePath:  path,
+		EnvPrefix: "VEGA",
+		LookupEnv: lookupIn(map[string]string{"VEGA_PORT": "9090"}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "json", cfg.Format)
+	assert.Equal(t, "debug", cfg.Level)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, vgconfig.Origins{
+		"Level":  vgconfig.FileLayer,
+		"Format": vgconfig.FileLayer,
+		"Port":   vgconfig.EnvironmentLayer,
+	}, origins)
+}
+
+func writeDummyConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	home := vgtest.RandomPath()
+	t.Cleanup(func() {
+		_ = os.RemoveAll(home)
+	})
+	require.NoError(t, vgfs.EnsureDir(home))
+
+	path := filepath.Join(home, "config.toml")
+	require.NoError(t, vgfs.WriteFile(path, []byte(content)))
+
+	return path
+}
+
+func lookupIn(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// field is a leaf of the configuration struct. Nested structs are walked
+// through, and their fields are identified by a dotted path, like "API.Port".
+type field struct {
+	path         string
+	structFields []reflect.StructField
+	index        []int
+}
+
+func (f field) valueIn(root reflect.Value) reflect.Value {
+	return root.FieldByIndex(f.index)
+}
+
+func listFields(t reflect.Type) []field {
+	return listFieldsUnder(t, "", nil, nil)
+}
+
+func listFieldsUnder(t reflect.Type, prefix string, parents []reflect.StructField, parentIndex []int) []field {
+	fields := []field{}
+	embedded := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !isEmbedded(sf) {
+			continue
+		}
+
+		structFields := append(append([]reflect.StructField{}, parents...), sf)
+		index := append(append([]int{}, parentIndex...), i)
+
+		// The fields of an embedded struct are flattened into its parent, as
+		// the decoders do.
+		if isEmbedded(sf) {
+			embedded = append(embedded, listFieldsUnder(sf.Type, prefix, structFields, index)...)
+			continue
+		}
+
+		path := sf.Name
+		if prefix != "" {
+			path = prefix + "." + sf.Name
+		}
+
+		if isNestedStruct(sf.Type) {
+			fields = append(fields, listFieldsUnder(sf.Type, path, structFields, index)...)
+			continue
+		}
+
+		fields = append(fields, field{
+			path:         path,
+			structFields: structFields,
+			index:        index,
+		})
+	}
+
+	// As in Go, a field of the parent shadows the embedded field with the
+	// same name.
+	paths := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		paths[f.path] = true
+	}
+	for _, f := range embedded {
+		if !paths[f.path] {
+			paths[f.path] = true
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// isEmbedded tells if the field is an embedded struct without explicit name,
+// whose fields are flattened into its parent.
+func isEmbedded(sf reflect.StructField) bool {
+	return sf.Anonymous && isNestedStruct(sf.Type) && tagName(sf, "toml") == "" && tagName(sf, "json") == ""
+}
+
+// isFlattenedIn tells if the fields of the embedded struct are at the level of
+// its parent in a document of the given format. Unlike TOML and JSON, YAML
+// only flattens the embedded structs tagged `inline`.
+func isFlattenedIn(sf reflect.StructField, tagKey string) bool {
+	if !sf.Anonymous || !isNestedStruct(sf.Type) {
+		return false
+	}
+	if tagKey == "yaml" {
+		tag := sf.Tag.Get("yaml")
+		return strings.Contains(tag, ",inline")
+	}
+	return tagName(sf, tagKey) == ""
+}
+
+// isNestedStruct tells if the type is a struct to walk through, as opposed to
+// a struct handled as a single value, like time.Time.
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// setValue parses the raw value according to the type of the target.
+func setValue(target reflect.Value, rawValue string) error {
+	if target.CanAddr() && target.Addr().Type().Implements(textUnmarshalerType) {
+		return target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(rawValue))
+	}
+
+	if target.Type() == durationType {
+		d, err := time.ParseDuration(rawValue)
+		if err != nil {
+			return err
+		}
+		target.SetInt(int64(d))
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(rawValue)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(rawValue)
+		if err != nil {
+			return err
+		}
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(rawValue, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(rawValue, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(rawValue, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetFloat(f)
+	case reflect.Slice:
+		items := []string{}
+		if rawValue != "" {
+			items = strings.Split(rawValue, ",")
+		}
+		slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	case reflect.Ptr:
+		value := reflect.New(target.Type().Elem())
+		if err := setValue(value.Elem(), rawValue); err != nil {
+			return err
+		}
+		target.Set(value)
+	default:
+		return fmt.Errorf("unsupported type %s", target.Type())
+	}
+
+	return nil
+}
+
+// deepCopy copies the value, so decoding on top of it doesn't alter the
+// original maps, slices and pointers.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -2,12 +2,10 @@ package ethereum
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -19,8 +17,10 @@ type BaseTokenSession struct {
 	generated.BaseTokenSession
 	syncTimeout time.Duration
 	address     common.Address
-	privateKey  *ecdsa.PrivateKey
+	signer      Signer
 	client      *Client
+	// confirmations is the number of blocks the *Sync helpers wait for.
+	confirmations uint64
 }
 
 func (ts *BaseTokenSession) Address() common.Address {
@@ -28,76 +28,82 @@ func (ts *BaseTokenSession) Address() common.Address {
 }
 
 func (ts *BaseTokenSession) ApproveSync(spender common.Address, value *big.Int) (*types.Transaction, error) {
-	sink := make(chan *generated.BaseTokenApproval)
+	sync := ts.approvalSync(ts.CallOpts.From, spender)
 
-	sub, err := ts.Contract.WatchApproval(&bind.WatchOpts{}, sink, []common.Address{ts.CallOpts.From}, []common.Address{spender})
-	if err != nil {
-		return nil, fmt.Errorf("failed to watch for approval: %w", err)
-	}
-	defer sub.Unsubscribe()
-
-	tx, err := ts.Approve(spender, value)
-	if err != nil {
-		return nil, err
-	}
-
-	return wait(sink, sub, tx, ts.syncTimeout)
+	return sync.run(ts.CallOpts.Context, ts.client.backend, ts.confirmations, ts.syncTimeout, func() (*types.Transaction, error) {
+		return ts.Approve(spender, value)
+	})
 }
 
 func (ts *BaseTokenSession) TransferSync(recipient common.Address, value *big.Int) (*types.Transaction, error) {
-	sink := make(chan *generated.BaseTokenTransfer)
+	sync := ts.transferSync(ts.CallOpts.From, recipient)
 
-	sub, err := ts.Contract.WatchTransfer(&bind.WatchOpts{}, sink, []common.Address{ts.CallOpts.From}, []common.Address{recipient})
-	if err != nil {
-		return nil, fmt.Errorf("failed to watch for transfer: %w", err)
-	}
-	defer sub.Unsubscribe()
+	return sync.run(ts.CallOpts.Context, ts.client.backend, ts.confirmations, ts.syncTimeout, func() (*types.Transaction, error) {
+		return ts.Transfer(recipient, value)
+	})
+}
 
-	tx, err := ts.Transfer(recipient, value)
-	if err != nil {
-		return nil, err
-	}
+func (ts *BaseTokenSession) TransferFromSync(sender common.Address, recipient common.Address, value *big.Int) (*types.Transaction, error) {
+	sync := ts.transferSync(sender, recipient)
 
-	return wait(sink, sub, tx, ts.syncTimeout)
+	return sync.run(ts.CallOpts.Context, ts.client.backend, ts.confirmations, ts.syncTimeout, func() (*types.Transaction, error) {
+		return ts.TransferFrom(sender, recipient, value)
+	})
 }
 
-func (ts *BaseTokenSession) TransferFromSync(sender common.Address, recipient common.Address, value *big.Int) (*types.Transaction, error) {
-	sink := make(chan *generated.BaseTokenTransfer)
+func (ts *BaseTokenSession) MintSync(to common.Address, amount *big.Int) (*types.Transaction, error) {
+	sync := ts.transferSync(common.BigToAddress(common.Big0), to)
 
-	sub, err := ts.Contract.WatchTransfer(&bind.WatchOpts{}, sink, []common.Address{sender}, []common.Address{recipient})
-	if err != nil {
-		return nil, fmt.Errorf("failed to watch for transfer: %w", err)
+	var mintErr error
+	tx, err := sync.run(ts.CallOpts.Context, ts.client.backend, ts.confirmations, ts.syncTimeout, func() (*types.Transaction, error) {
+		tx, err := ts.Mint(to, amount)
+		if err != nil {
+			mintErr = fmt.Errorf("failed to mint %s: %w", to, err)
+			return nil, mintErr
+		}
+		return tx, nil
+	})
+	if mintErr != nil {
+		return nil, mintErr
 	}
-	defer sub.Unsubscribe()
-
-	tx, err := ts.TransferFrom(sender, recipient, value)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to wait for mint: %w", err)
 	}
 
-	return wait(sink, sub, tx, ts.syncTimeout)
+	return tx, nil
 }
 
-func (ts *BaseTokenSession) MintSync(to common.Address, amount *big.Int) (*types.Transaction, error) {
-	sink := make(chan *generated.BaseTokenTransfer)
-
-	sub, err := ts.Contract.WatchTransfer(&bind.WatchOpts{}, sink, []common.Address{common.BigToAddress(common.Big0)}, []common.Address{to})
-	if err != nil {
-		return nil, fmt.Errorf("failed to watch for transfer: %w", err)
+func (ts *BaseTokenSession) approvalSync(owner common.Address, spender common.Address) eventSync[*generated.BaseTokenApproval] {
+	return eventSync[*generated.BaseTokenApproval]{
+		name:     "approval",
+		contract: ts.address,
+		parse:    ts.Contract.ParseApproval,
+		matches: func(approval *generated.BaseTokenApproval) bool {
+			return approval.Owner == owner && approval.Spender == spender
+		},
 	}
-	defer sub.Unsubscribe()
+}
 
-	tx, err := ts.Mint(to, amount)
-	if err != nil {
-		return nil, fmt.Errorf("failed to mint %s: %w", to, err)
+func (ts *BaseTokenSession) transferSync(from common.Address, to common.Address) eventSync[*generated.BaseTokenTransfer] {
+	return eventSync[*generated.BaseTokenTransfer]{
+		name:     "transfer",
+		contract: ts.address,
+		parse:    ts.Contract.ParseTransfer,
+		matches: func(transfer *generated.BaseTokenTransfer) bool {
+			return transfer.From == from && transfer.To == to
+		},
 	}
+}
 
-	tx, err = wait(sink, sub, tx, ts.syncTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to wait for mint: %w", err)
+func (ts *BaseTokenSession) anyTransferSync() eventSync[*generated.BaseTokenTransfer] {
+	return eventSync[*generated.BaseTokenTransfer]{
+		name:     "transfer",
+		contract: ts.address,
+		parse:    ts.Contract.ParseTransfer,
+		matches: func(*generated.BaseTokenTransfer) bool {
+			return true
+		},
 	}
-
-	return tx, nil
 }
 
 // MintRawSync is an experimental way of minting new tokens. It attempts to execute an on-chain transaction that
@@ -153,41 +159,39 @@ func (ts *BaseTokenSession) mintRaw(to common.Address, targetBalance *big.Int) (
 		return nil, fmt.Errorf("failed to create signed mint tx: %w", err)
 	}
 
-	if err = ts.client.SendTransaction(ts.CallOpts.Context, signedTx); err != nil {
+	if err = ts.client.backend.SendTransaction(ts.CallOpts.Context, signedTx); err != nil {
 		return nil, fmt.Errorf("failed to send transaction: %w", err)
 	}
 
 	return signedTx, nil
 }
 
+// GetLastTransferValueSync waits for the transaction to be mined and
+// confirmed, and returns the value of the last transfer of the token it
+// emitted. It returns a TransactionRevertedError if the transaction has been
+// reverted. As the raw mint is slow, it doesn't apply the sync timeout of the
+// session: it waits as long as the context allows.
 func (ts *BaseTokenSession) GetLastTransferValueSync(ctx context.Context, signedTx *types.Transaction) (*big.Int, error) {
-	receipt, err := bind.WaitMined(ctx, ts.client, signedTx)
+	receipt, err := waitForReceipt(ctx, ts.client.backend, signedTx, ts.confirmations, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to wait for transaction to be mined: %w", err)
 	}
 
-	if len(receipt.Logs) == 0 {
-		return nil, fmt.Errorf("no logs in transaction")
-	}
-
-	// last transfer event
-	log := receipt.Logs[len(receipt.Logs)-1]
-
-	transfer, err := ts.Contract.ParseTransfer(*log)
+	transfer, err := ts.anyTransferSync().lastEventIn(receipt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse transfer: %w", err)
+		return nil, err
 	}
 
 	return transfer.Value, nil
 }
 
 func (ts *BaseTokenSession) createMintSignedTx(to common.Address, targetBalance *big.Int) (*types.Transaction, error) {
-	nonce, err := ts.client.PendingNonceAt(ts.CallOpts.Context, to)
+	nonce, err := ts.client.backend.PendingNonceAt(ts.CallOpts.Context, ts.signer.Address())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get nonce: %w", err)
 	}
 
-	gasPrice, err := ts.client.SuggestGasPrice(ts.CallOpts.Context)
+	gasPrice, err := ts.client.backend.SuggestGasPrice(ts.CallOpts.Context)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gas price: %w", err)
 	}
@@ -202,7 +206,7 @@ func (ts *BaseTokenSession) createMintSignedTx(to common.Address, targetBalance
 
 	tx := types.NewContractCreation(nonce, big.NewInt(0), gasLimit.Uint64(), gasPrice, data)
 
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, ts.privateKey)
+	signedTx, err := ts.signer.SignTx(ts.CallOpts.Context, tx, ts.client.chainID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign transaction: %w", err)
 	}
@@ -1,30 +1,73 @@
 package ethereum
 
 import (
-	"fmt"
+	"context"
 	"math/big"
-	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
-func TestBaseTokenSession_prepareRawMintData(t *testing.T) {
-	want := "6000603460be8239805160601c9060145160183384609f565b908181116043575b82806044818088602f3082609f565b5063a9059cbb60e01b600052336004525af1005b63de5f72fd60e01b600052600492918280858180895af15060633086609f565b63de5f72fd60e01b60005291030491815b8381105a61ea6010161560925760019083808481808a5af150016074565b5090915060449050816020565b6024600081926044946370a0823160e01b83526004525afa5060245190563773a5c7aff77e014cbf067dd31801b4c6dc4136000000000000000000000000000000000000000000000000000006faf9a60e00"
+// mintBackend serves the nonce and the gas price of the raw mint. Any other
+// call panics.
+type mintBackend struct {
+	Backend
+	nonce    uint64
+	nonceFor common.Address
+}
 
-	ts := &BaseTokenSession{
-		address: common.HexToAddress("0x3773A5c7aFF77e014cBF067dd31801b4C6dc4136"),
-	}
+func (b *mintBackend) PendingNonceAt(_ context.Context, account common.Address) (uint64, error) {
+	b.nonceFor = account
+	return b.nonce, nil
+}
+
+func (b *mintBackend) SuggestGasPrice(_ context.Context) (*big.Int, error) {
+	return big.NewInt(1000000000), nil
+}
+
+func TestRawMint(t *testing.T) {
+	t.Run("Raw mint is sent by the signer with a replay protection", testRawMintIsSentByTheSignerWithReplayProtection)
+}
 
-	targetBalance := big.NewInt(7675000000000)
+func testRawMintIsSentByTheSignerWithReplayProtection(t *testing.T) {
+	signer, err := GenerateLocalSigner()
+	if err != nil {
+		t.Fatalf("Failed to create local signer: %s", err)
+	}
+	backend := &mintBackend{nonce: 7}
+	chainID := big.NewInt(1337)
+	session := &BaseTokenSession{
+		address: common.HexToAddress("0x1b8a1B6CBE5c93609b46D1829Cc7f3Cb8eeE23a0"),
+		signer:  signer,
+		client:  &Client{chainID: chainID, backend: backend},
+	}
+	recipient := common.HexToAddress("0x02")
 
-	got, err := ts.prepareMintBytecode(targetBalance)
+	tx, err := session.createMintSignedTx(recipient, big.NewInt(100))
 	if err != nil {
-		t.Errorf("prepareMintBytecode() error = %s", err)
-		return
+		t.Fatalf("createMintSignedTx() error = %s", err)
+	}
+
+	// The nonce is the one of the account sending the transaction, not of the
+	// recipient of the tokens.
+	if backend.nonceFor != signer.Address() {
+		t.Errorf("createMintSignedTx() took the nonce of %s, want %s", backend.nonceFor, signer.Address())
+	}
+	if tx.Nonce() != 7 {
+		t.Errorf("createMintSignedTx() nonce = %d, want 7", tx.Nonce())
 	}
 
-	if !reflect.DeepEqual(fmt.Sprintf("%x", got), want) {
-		t.Errorf("prepareMintBytecode() got = %x, want %s", got, want)
+	// The transaction is signed with EIP-155, so it can't be replayed on
+	// another chain.
+	if !tx.Protected() || tx.ChainId().Cmp(chainID) != 0 {
+		t.Errorf("createMintSignedTx() should be replay protected on chain %s, got chain %s", chainID, tx.ChainId())
+	}
+	sender, err := types.Sender(types.NewEIP155Signer(chainID), tx)
+	if err != nil {
+		t.Fatalf("Failed to recover the sender: %s", err)
+	}
+	if sender != signer.Address() {
+		t.Errorf("createMintSignedTx() signed by %s, want %s", sender, signer.Address())
 	}
 }
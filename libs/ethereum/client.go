@@ -6,68 +6,192 @@ import (
 	"fmt"
 	"math/big"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/event"
 
 	"code.vegaprotocol.io/shared/libs/ethereum/generated"
 )
 
 var defaultSyncDuration = time.Second * 5
 
+// Backend is the connection to Ethereum the sessions rely on, to call the
+// contracts and follow the transactions.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	BlockNumber(ctx context.Context) (uint64, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	SupportsSubscriptions() bool
+}
+
+// EthereumClient is the set of Ethereum calls of the go-ethereum client.
+type EthereumClient interface {
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ChainSyncReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	ethereum.LogFilterer
+	ethereum.PendingContractCaller
+	ethereum.PendingStateReader
+	ethereum.TransactionReader
+	ethereum.TransactionSender
+	BlockNumber(ctx context.Context) (uint64, error)
+	CallContractAtHash(ctx context.Context, msg ethereum.CallMsg, blockHash common.Hash) ([]byte, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	NetworkID(ctx context.Context) (*big.Int, error)
+	PeerCount(ctx context.Context) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error)
+}
+
 type Client struct {
-	*ethclient.Client
+	// EthereumClient is the connection to the endpoint. When the client has
+	// been created with several endpoints, it is the Failover, so the calls
+	// fail over to another endpoint when the current one is unreachable.
+	EthereumClient
 	chainID *big.Int
+	// backend is the connection used by the sessions.
+	backend Backend
+	// failover is set when the client has been created with several
+	// endpoints.
+	failover      *Failover
+	confirmations uint64
+}
+
+// endpointBackend is the backend of a client dialing a single endpoint.
+type endpointBackend struct {
+	*ethclient.Client
+	supportsSubscriptions bool
 }
 
+func (b *endpointBackend) SupportsSubscriptions() bool {
+	return b.supportsSubscriptions
+}
+
+// NewClient dials the Ethereum node at the given address. The address is
+// either a 'ws', 'wss', 'http' or 'https' URL, or the path to the IPC endpoint
+// of a local node.
+//
+// The HTTP endpoints don't support the event subscriptions, so the *Sync
+// helpers of the sessions created from such a client poll the transaction
+// receipts instead.
 func NewClient(ctx context.Context, ethereumAddress string) (*Client, error) {
-	addr, err := url.Parse(ethereumAddress)
+	supportsSubscriptions, err := supportsSubscriptionsAt(ethereumAddress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Ethereum address: %w", err)
-	}
-
-	if addr.Scheme != "ws" && addr.Scheme != "wss" {
-		return nil, fmt.Errorf("address scheme needs to be 'ws' or 'wss': %q", addr.Scheme)
+		return nil, err
 	}
 
-	client, err := ethclient.DialContext(ctx, addr.String())
+	client, err := ethclient.DialContext(ctx, ethereumAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial Ethereum client: %s", err)
 	}
 
 	chainID, err := client.ChainID(ctx)
 	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to get chain ID: %w", err)
 	}
 
 	return &Client{
-		chainID: chainID,
-		Client:  client,
+		EthereumClient: client,
+		chainID:        chainID,
+		backend: &endpointBackend{
+			Client:                client,
+			supportsSubscriptions: supportsSubscriptions,
+		},
 	}, nil
 }
 
+// SupportsSubscriptions tells if the endpoint supports the event
+// subscriptions. Only the HTTP endpoints don't. For a client created with
+// several endpoints, it is about the endpoint currently in use.
+func (ec *Client) SupportsSubscriptions() bool {
+	return ec.backend.SupportsSubscriptions()
+}
+
+// Backend returns the connection used by the sessions. For a client created
+// with several endpoints, it fails over to another endpoint when the current
+// one is unreachable.
+func (ec *Client) Backend() Backend {
+	return ec.backend
+}
+
+// EthClient returns the go-ethereum client of a client created with a single
+// endpoint, for the code expecting one. It returns nil for a client created
+// with several endpoints, as a go-ethereum client is bound to a single one of
+// them.
+func (ec *Client) EthClient() *ethclient.Client {
+	client, _ := ec.EthereumClient.(*ethclient.Client)
+	return client
+}
+
+// Failover returns the endpoints of a client created with several endpoints,
+// and nil otherwise.
+func (ec *Client) Failover() *Failover {
+	return ec.failover
+}
+
+// Close closes the connections to the endpoints.
+func (ec *Client) Close() {
+	if ec.failover != nil {
+		ec.failover.Close()
+		return
+	}
+	if client := ec.EthClient(); client != nil {
+		client.Close()
+	}
+}
+
+// SetConfirmations sets the number of blocks the *Sync helpers wait for after
+// a transaction is mined, the block including it being the first one. It
+// applies to the sessions created afterwards. The default, 0, returns as soon
+// as the transaction is mined. The sync timeout of the sessions must leave
+// enough time for the confirmations.
+func (ec *Client) SetConfirmations(confirmations uint64) {
+	ec.confirmations = confirmations
+}
+
+func supportsSubscriptionsAt(ethereumAddress string) (bool, error) {
+	// An address without scheme is the path to an IPC endpoint.
+	if !strings.Contains(ethereumAddress, "://") {
+		if ethereumAddress == "" {
+			return false, fmt.Errorf("the Ethereum address is empty")
+		}
+		return true, nil
+	}
+
+	addr, err := url.Parse(ethereumAddress)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse Ethereum address: %w", err)
+	}
+
+	switch addr.Scheme {
+	case "ws", "wss":
+		return true, nil
+	case "http", "https":
+		return false, nil
+	default:
+		return false, fmt.Errorf("address scheme needs to be 'ws', 'wss', 'http' or 'https', or the address needs to be an IPC path: %q", addr.Scheme)
+	}
+}
+
 func (ec *Client) NewERC20BridgeSession(
 	ctx context.Context,
-	contractOwnerPrivateKey string,
+	signer Signer,
 	bridgeAddress common.Address,
 	syncTimeout *time.Duration,
 ) (*ERC20BridgeSession, error) {
-	privateKey, err := crypto.HexToECDSA(contractOwnerPrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert erc20 bridge contract owner private key hash into ECDSA: %w", err)
-	}
+	auth := transactOptsFor(ctx, signer, ec.chainID)
 
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, ec.chainID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create erc20 bridge contract authentication: %w", err)
-	}
-
-	bridge, err := generated.NewERC20Bridge(bridgeAddress, ec.Client)
+	bridge, err := generated.NewERC20Bridge(bridgeAddress, ec.backend)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating erc20 bridge contract for address %q: %w", bridgeAddress, err)
 	}
@@ -85,28 +209,22 @@ func (ec *Client) NewERC20BridgeSession(
 			},
 			TransactOpts: *auth,
 		},
-		syncTimeout: *syncTimeout,
-		address:     bridgeAddress,
+		syncTimeout:   *syncTimeout,
+		address:       bridgeAddress,
+		client:        ec,
+		confirmations: ec.confirmations,
 	}, nil
 }
 
 func (ec *Client) NewStakingBridgeSession(
 	ctx context.Context,
-	contractOwnerPrivateKey string,
+	signer Signer,
 	bridgeAddress common.Address,
 	syncTimeout *time.Duration,
 ) (*StakingBridgeSession, error) {
-	privateKey, err := crypto.HexToECDSA(contractOwnerPrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert staking bridge contract owner private key hash into ECDSA: %w", err)
-	}
-
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, ec.chainID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create staking bridge contract authentication: %w", err)
-	}
+	auth := transactOptsFor(ctx, signer, ec.chainID)
 
-	bridge, err := generated.NewStakingBridge(bridgeAddress, ec.Client)
+	bridge, err := generated.NewStakingBridge(bridgeAddress, ec.backend)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating staking bridge contract for address %q: %w", bridgeAddress, err)
 	}
@@ -131,21 +249,13 @@ func (ec *Client) NewStakingBridgeSession(
 
 func (ec *Client) NewBaseTokenSession(
 	ctx context.Context,
-	contractOwnerPrivateKey string,
+	signer Signer,
 	tokenAddress common.Address,
 	syncTimeout *time.Duration,
 ) (*BaseTokenSession, error) {
-	privateKey, err := crypto.HexToECDSA(contractOwnerPrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert base token contract owner private key hash into ECDSA: %w", err)
-	}
+	auth := transactOptsFor(ctx, signer, ec.chainID)
 
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, ec.chainID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create base token contract authentication: %w", err)
-	}
-
-	token, err := generated.NewBaseToken(tokenAddress, ec.Client)
+	token, err := generated.NewBaseToken(tokenAddress, ec.backend)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating base token contract for address %q: %w", tokenAddress, err)
 	}
@@ -163,24 +273,14 @@ func (ec *Client) NewBaseTokenSession(
 			},
 			TransactOpts: *auth,
 		},
-		syncTimeout: *syncTimeout,
-		address:     tokenAddress,
-		privateKey:  privateKey,
-		client:      ec,
+		syncTimeout:   *syncTimeout,
+		address:       tokenAddress,
+		signer:        signer,
+		client:        ec,
+		confirmations: ec.confirmations,
 	}, nil
 }
 
-func wait[T any](sink chan T, sub event.Subscription, tx *types.Transaction, timeout time.Duration) (*types.Transaction, error) {
-	select {
-	case <-sink:
-		return tx, nil
-	case err := <-sub.Err():
-		return nil, err
-	case <-time.After(timeout):
-		return nil, fmt.Errorf("transaction time has timed out")
-	}
-}
-
 func HexStringToByte32Array(str string) ([32]byte, error) {
 	value := [32]byte{}
 
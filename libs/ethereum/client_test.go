@@ -120,22 +120,27 @@ func TestClient(t *testing.T) {
 		t.Fatalf("Failed to create Ethereum client: %s", err)
 	}
 
-	stakingBridge, err := client.NewStakingBridgeSession(ctx, contractOwnerPrivateKey, stakingBridgeAddress, nil)
+	contractOwner, err := vgethereum.NewLocalSigner(contractOwnerPrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to create contract owner signer: %s", err)
+	}
+
+	stakingBridge, err := client.NewStakingBridgeSession(ctx, contractOwner, stakingBridgeAddress, nil)
 	if err != nil {
 		t.Fatalf("Failed to create staking bridge: %s", err)
 	}
 
-	erc20bridge, err := client.NewERC20BridgeSession(ctx, contractOwnerPrivateKey, erc20BridgeAddress, nil)
+	erc20bridge, err := client.NewERC20BridgeSession(ctx, contractOwner, erc20BridgeAddress, nil)
 	if err != nil {
 		t.Fatalf("Failed to create staking bridge: %s", err)
 	}
 
-	tUSDCToken, err := client.NewBaseTokenSession(ctx, contractOwnerPrivateKey, tUSDCTokenAddress, nil)
+	tUSDCToken, err := client.NewBaseTokenSession(ctx, contractOwner, tUSDCTokenAddress, nil)
 	if err != nil {
 		t.Fatalf("Failed to create tUSDC token: %s", err)
 	}
 
-	vegaToken, err := client.NewBaseTokenSession(ctx, contractOwnerPrivateKey, vegaTokenAddress, nil)
+	vegaToken, err := client.NewBaseTokenSession(ctx, contractOwner, vegaTokenAddress, nil)
 	if err != nil {
 		t.Fatalf("Failed to create vega token: %s", err)
 	}
@@ -0,0 +1,112 @@
+package ethereum_test
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	vgethereum "code.vegaprotocol.io/shared/libs/ethereum"
+	vgrand "code.vegaprotocol.io/shared/libs/rand"
+)
+
+type ethService struct{}
+
+func (ethService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(hexutil.MustDecodeBig("0x539"))
+}
+
+func newRPCServer(t *testing.T) *rpc.Server {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", ethService{}); err != nil {
+		t.Fatalf("Failed to register the eth service: %s", err)
+	}
+	return server
+}
+
+func TestNewClientEndpoints(t *testing.T) {
+	t.Run("Dialing an HTTP endpoint succeeds without subscriptions", testDialingHTTPEndpointSucceedsWithoutSubscriptions)
+	t.Run("Dialing a websocket endpoint succeeds with subscriptions", testDialingWebsocketEndpointSucceedsWithSubscriptions)
+	t.Run("Dialing an IPC endpoint succeeds with subscriptions", testDialingIPCEndpointSucceedsWithSubscriptions)
+	t.Run("Dialing an unsupported scheme fails", testDialingUnsupportedSchemeFails)
+}
+
+func testDialingHTTPEndpointSucceedsWithoutSubscriptions(t *testing.T) {
+	server := newRPCServer(t)
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := vgethereum.NewClient(context.Background(), httpServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	if client.SupportsSubscriptions() {
+		t.Errorf("HTTP client should not support subscriptions")
+	}
+}
+
+func testDialingWebsocketEndpointSucceedsWithSubscriptions(t *testing.T) {
+	server := newRPCServer(t)
+	defer server.Stop()
+	httpServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer httpServer.Close()
+
+	client, err := vgethereum.NewClient(context.Background(), "ws"+httpServer.URL[len("http"):])
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	if !client.SupportsSubscriptions() {
+		t.Errorf("websocket client should support subscriptions")
+	}
+}
+
+func testDialingIPCEndpointSucceedsWithSubscriptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("IPC endpoints are named pipes on Windows")
+	}
+
+	ipcPath := filepath.Join(os.TempDir(), "vega-"+vgrand.RandomStr(8)+".ipc")
+	listener, err := net.Listen("unix", ipcPath)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %s", ipcPath, err)
+	}
+	defer os.Remove(ipcPath)
+
+	server := newRPCServer(t)
+	defer server.Stop()
+	go server.ServeListener(listener)
+	defer listener.Close()
+
+	client, err := vgethereum.NewClient(context.Background(), ipcPath)
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	if !client.SupportsSubscriptions() {
+		t.Errorf("IPC client should support subscriptions")
+	}
+}
+
+func testDialingUnsupportedSchemeFails(t *testing.T) {
+	if _, err := vgethereum.NewClient(context.Background(), "ftp://localhost:8545"); err == nil {
+		t.Errorf("expected an error for the 'ftp' scheme")
+	}
+
+	if _, err := vgethereum.NewClient(context.Background(), ""); err == nil {
+		t.Errorf("expected an error for an empty address")
+	}
+}
@@ -1,11 +1,9 @@
 package ethereum
 
 import (
-	"fmt"
 	"math/big"
 	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 
@@ -16,6 +14,9 @@ type ERC20BridgeSession struct {
 	generated.ERC20BridgeSession
 	syncTimeout time.Duration
 	address     common.Address
+	client      *Client
+	// confirmations is the number of blocks the *Sync helpers wait for.
+	confirmations uint64
 }
 
 func (bs ERC20BridgeSession) Address() common.Address {
@@ -23,19 +24,16 @@ func (bs ERC20BridgeSession) Address() common.Address {
 }
 
 func (bs ERC20BridgeSession) DepositAssetSync(asset_source common.Address, amount *big.Int, vega_public_key [32]byte) (*types.Transaction, error) {
-	sink := make(chan *generated.ERC20BridgeAssetDeposited)
-
-	sub, err := bs.Contract.WatchAssetDeposited(&bind.WatchOpts{}, sink, []common.Address{}, []common.Address{asset_source})
-	if err != nil {
-		return nil, fmt.Errorf("failed to watch for deposit: %w", err)
-	}
-	defer sub.Unsubscribe()
-
-	tx, err := bs.DepositAsset(asset_source, amount, vega_public_key)
-	if err != nil {
-		return nil, err
+	sync := eventSync[*generated.ERC20BridgeAssetDeposited]{
+		name:     "deposit",
+		contract: bs.address,
+		parse:    bs.Contract.ParseAssetDeposited,
+		matches: func(deposit *generated.ERC20BridgeAssetDeposited) bool {
+			return deposit.UserAddress == bs.CallOpts.From && deposit.AssetSource == asset_source && deposit.VegaPublicKey == vega_public_key
+		},
 	}
 
-	return wait(sink, sub, tx, bs.syncTimeout)
-
+	return sync.run(bs.CallOpts.Context, bs.client.backend, bs.confirmations, bs.syncTimeout, func() (*types.Transaction, error) {
+		return bs.DepositAsset(asset_source, amount, vega_public_key)
+	})
 }
@@ -0,0 +1,690 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	defaultHealthCheckInterval = 10 * time.Second
+
+	defaultMaxHeadLag uint64 = 5
+
+	// resubscribeBackoffMax is the maximum delay between two attempts to
+	// re-establish a lost subscription.
+	resubscribeBackoffMax = 5 * time.Second
+)
+
+var ErrNoHealthyEndpoint = errors.New("no healthy Ethereum endpoint")
+
+type FailoverOptions struct {
+	// ChainID is the chain ID the endpoints must be on. If not set, it is the
+	// chain ID of the first endpoint answering, in the order of the list.
+	ChainID *big.Int
+	// HealthCheckInterval is the interval between two health checks of the
+	// endpoints. It defaults to 10 seconds.
+	HealthCheckInterval time.Duration
+	// MaxHeadLag is the number of blocks an endpoint can lag behind the most
+	// advanced one before being considered unhealthy. It defaults to 5.
+	MaxHeadLag uint64
+}
+
+// EndpointHealth is the outcome of the last health check of an endpoint.
+type EndpointHealth struct {
+	Address string `json:"address"`
+	Healthy bool   `json:"healthy"`
+	// HeadBlock is the number of the latest block known by the endpoint.
+	HeadBlock uint64    `json:"headBlock"`
+	LastCheck time.Time `json:"lastCheck"`
+	// Error is the reason the endpoint is unhealthy.
+	Error string `json:"error,omitempty"`
+}
+
+type failoverEndpoint struct {
+	address               string
+	supportsSubscriptions bool
+	// client is nil until the endpoint has been dialed successfully. The
+	// websocket and IPC clients reconnect on their own, so it is never
+	// replaced.
+	client *ethclient.Client
+	health EndpointHealth
+}
+
+// Failover spreads the calls over several endpoints. All the calls go to the
+// current endpoint, and when it is unreachable, the next healthy endpoint, in
+// the order of the list, becomes the current one. The endpoints are checked in
+// the background, on their chain ID and their head block.
+//
+// The subscriptions are made on the current endpoint, and are re-established
+// on the next one when they are lost.
+type Failover struct {
+	mu         sync.RWMutex
+	endpoints  []*failoverEndpoint
+	current    int
+	chainID    *big.Int
+	maxHeadLag uint64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewFailoverClient creates a client over several endpoints, that fails over
+// from one to another. Each address is either a 'ws', 'wss', 'http' or 'https'
+// URL, or the path to the IPC endpoint of a local node. The first healthy
+// endpoint, in the order of the list, is used first. It fails if none of the
+// endpoints is healthy.
+func NewFailoverClient(ctx context.Context, ethereumAddresses []string, options FailoverOptions) (*Client, error) {
+	if len(ethereumAddresses) == 0 {
+		return nil, fmt.Errorf("at least one Ethereum address is required")
+	}
+
+	f := &Failover{
+		endpoints:  make([]*failoverEndpoint, 0, len(ethereumAddresses)),
+		maxHeadLag: defaultMaxHeadLag,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if options.ChainID != nil {
+		f.chainID = new(big.Int).Set(options.ChainID)
+	}
+	if options.MaxHeadLag > 0 {
+		f.maxHeadLag = options.MaxHeadLag
+	}
+
+	for _, address := range ethereumAddresses {
+		supportsSubscriptions, err := supportsSubscriptionsAt(address)
+		if err != nil {
+			return nil, err
+		}
+		f.endpoints = append(f.endpoints, &failoverEndpoint{
+			address:               address,
+			supportsSubscriptions: supportsSubscriptions,
+			health:                EndpointHealth{Address: address},
+		})
+	}
+
+	f.CheckHealth(ctx)
+
+	f.mu.RLock()
+	current := f.endpoints[f.current]
+	chainID := f.chainID
+	f.mu.RUnlock()
+
+	if !current.health.Healthy {
+		f.closeEndpoints()
+		return nil, fmt.Errorf("failed to connect to Ethereum: %w", ErrNoHealthyEndpoint)
+	}
+
+	interval := options.HealthCheckInterval
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	go f.checkHealthEvery(interval)
+
+	return &Client{
+		EthereumClient: f,
+		chainID:        chainID,
+		backend:        f,
+		failover:       f,
+	}, nil
+}
+
+// CurrentEndpoint returns the health of the endpoint in use.
+func (f *Failover) CurrentEndpoint() EndpointHealth {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.endpoints[f.current].health
+}
+
+// Health returns the health of all the endpoints, in the order of the list.
+func (f *Failover) Health() []EndpointHealth {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.healthOfEndpoints()
+}
+
+// CheckHealth checks all the endpoints right away, and returns their health.
+// If the current endpoint is no longer healthy, the next healthy one becomes
+// the current one.
+func (f *Failover) CheckHealth(ctx context.Context) []EndpointHealth {
+	f.mu.RLock()
+	probes := make([]endpointProbe, len(f.endpoints))
+	for i, e := range f.endpoints {
+		probes[i].address = e.address
+		probes[i].client = e.client
+	}
+	f.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+		go func(p *endpointProbe) {
+			defer wg.Done()
+			p.run(ctx)
+		}(&probes[i])
+	}
+	wg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, p := range probes {
+		e := f.endpoints[i]
+		if e.client == nil {
+			e.client = p.client
+		} else if p.client != nil && p.client != e.client {
+			// Another check dialed the endpoint in the meantime.
+			p.client.Close()
+		}
+		if p.err == nil && f.chainID == nil {
+			f.chainID = p.chainID
+		}
+	}
+
+	var highestHead uint64
+	for _, p := range probes {
+		if p.err == nil && p.chainID.Cmp(f.chainID) == 0 && p.head > highestHead {
+			highestHead = p.head
+		}
+	}
+
+	now := time.Now()
+	for i, p := range probes {
+		health := EndpointHealth{
+			Address:   p.address,
+			HeadBlock: p.head,
+			LastCheck: now,
+		}
+		switch {
+		case p.err != nil:
+			health.Error = p.err.Error()
+		case p.chainID.Cmp(f.chainID) != 0:
+			health.Error = fmt.Sprintf("chain ID %s doesn't match the expected chain ID %s", p.chainID, f.chainID)
+		case highestHead-p.head > f.maxHeadLag:
+			health.Error = fmt.Sprintf("head block %d lags behind the highest head block %d", p.head, highestHead)
+		default:
+			health.Healthy = true
+		}
+		f.endpoints[i].health = health
+	}
+
+	f.selectEndpoint()
+
+	return f.healthOfEndpoints()
+}
+
+// Close stops the health checks and closes the connections to the endpoints.
+func (f *Failover) Close() {
+	f.closeOnce.Do(func() {
+		close(f.stop)
+		<-f.done
+		f.closeEndpoints()
+	})
+}
+
+func (f *Failover) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]byte, error) {
+		return client.CodeAt(ctx, contract, blockNumber)
+	})
+}
+
+func (f *Failover) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]byte, error) {
+		return client.CallContract(ctx, call, blockNumber)
+	})
+}
+
+func (f *Failover) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*types.Header, error) {
+		return client.HeaderByNumber(ctx, number)
+	})
+}
+
+func (f *Failover) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]byte, error) {
+		return client.PendingCodeAt(ctx, account)
+	})
+}
+
+func (f *Failover) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (uint64, error) {
+		return client.PendingNonceAt(ctx, account)
+	})
+}
+
+func (f *Failover) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*big.Int, error) {
+		return client.SuggestGasPrice(ctx)
+	})
+}
+
+func (f *Failover) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*big.Int, error) {
+		return client.SuggestGasTipCap(ctx)
+	})
+}
+
+func (f *Failover) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (uint64, error) {
+		return client.EstimateGas(ctx, call)
+	})
+}
+
+// SendTransaction sends the transaction to the current endpoint. When the
+// endpoint is unreachable, the transaction may still have been broadcast, so
+// the next endpoint may reject it as known: this is then a success.
+func (f *Failover) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	retrying := false
+	_, err := callWithFailover(ctx, f, func(client *ethclient.Client) (struct{}, error) {
+		err := client.SendTransaction(ctx, tx)
+		if err != nil && retrying && isAlreadyBroadcast(ctx, client, tx, err) {
+			return struct{}{}, nil
+		}
+		retrying = true
+		return struct{}{}, err
+	})
+	return err
+}
+
+func (f *Failover) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]types.Log, error) {
+		return client.FilterLogs(ctx, query)
+	})
+}
+
+func (f *Failover) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*types.Receipt, error) {
+		return client.TransactionReceipt(ctx, txHash)
+	})
+}
+
+func (f *Failover) BlockNumber(ctx context.Context) (uint64, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (uint64, error) {
+		return client.BlockNumber(ctx)
+	})
+}
+
+func (f *Failover) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*big.Int, error) {
+		return client.BalanceAt(ctx, account, blockNumber)
+	})
+}
+
+func (f *Failover) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*types.Block, error) {
+		return client.BlockByHash(ctx, hash)
+	})
+}
+
+func (f *Failover) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*types.Block, error) {
+		return client.BlockByNumber(ctx, number)
+	})
+}
+
+func (f *Failover) CallContractAtHash(ctx context.Context, call ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]byte, error) {
+		return client.CallContractAtHash(ctx, call, blockHash)
+	})
+}
+
+func (f *Failover) ChainID(ctx context.Context) (*big.Int, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*big.Int, error) {
+		return client.ChainID(ctx)
+	})
+}
+
+func (f *Failover) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*types.Header, error) {
+		return client.HeaderByHash(ctx, hash)
+	})
+}
+
+func (f *Failover) NetworkID(ctx context.Context) (*big.Int, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*big.Int, error) {
+		return client.NetworkID(ctx)
+	})
+}
+
+func (f *Failover) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (uint64, error) {
+		return client.NonceAt(ctx, account, blockNumber)
+	})
+}
+
+func (f *Failover) PeerCount(ctx context.Context) (uint64, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (uint64, error) {
+		return client.PeerCount(ctx)
+	})
+}
+
+func (f *Failover) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*big.Int, error) {
+		return client.PendingBalanceAt(ctx, account)
+	})
+}
+
+func (f *Failover) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]byte, error) {
+		return client.PendingCallContract(ctx, call)
+	})
+}
+
+func (f *Failover) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]byte, error) {
+		return client.PendingStorageAt(ctx, account, key)
+	})
+}
+
+func (f *Failover) PendingTransactionCount(ctx context.Context) (uint, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (uint, error) {
+		return client.PendingTransactionCount(ctx)
+	})
+}
+
+func (f *Failover) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) ([]byte, error) {
+		return client.StorageAt(ctx, account, key, blockNumber)
+	})
+}
+
+func (f *Failover) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*ethereum.SyncProgress, error) {
+		return client.SyncProgress(ctx)
+	})
+}
+
+func (f *Failover) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (uint, error) {
+		return client.TransactionCount(ctx, blockHash)
+	})
+}
+
+func (f *Failover) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (*types.Transaction, error) {
+		return client.TransactionInBlock(ctx, blockHash, index)
+	})
+}
+
+func (f *Failover) TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error) {
+	return callWithFailover(ctx, f, func(client *ethclient.Client) (common.Address, error) {
+		return client.TransactionSender(ctx, tx, block, index)
+	})
+}
+
+func (f *Failover) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	type lookup struct {
+		tx        *types.Transaction
+		isPending bool
+	}
+	result, err := callWithFailover(ctx, f, func(client *ethclient.Client) (lookup, error) {
+		tx, isPending, err := client.TransactionByHash(ctx, hash)
+		return lookup{tx: tx, isPending: isPending}, err
+	})
+	return result.tx, result.isPending, err
+}
+
+// SubscribeFilterLogs subscribes to the logs on the current endpoint. The
+// subscription is re-established on the next healthy endpoint when it is lost.
+func (f *Failover) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return f.subscribe(ctx, func(ctx context.Context, client *ethclient.Client) (ethereum.Subscription, error) {
+		return client.SubscribeFilterLogs(ctx, query, ch)
+	})
+}
+
+// SubscribeNewHead subscribes to the new blocks on the current endpoint. The
+// subscription is re-established on the next healthy endpoint when it is lost.
+func (f *Failover) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return f.subscribe(ctx, func(ctx context.Context, client *ethclient.Client) (ethereum.Subscription, error) {
+		return client.SubscribeNewHead(ctx, ch)
+	})
+}
+
+// SupportsSubscriptions tells if the current endpoint supports the event
+// subscriptions.
+func (f *Failover) SupportsSubscriptions() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.endpoints[f.current].supportsSubscriptions
+}
+
+// callWithFailover calls the current endpoint, and retries on the next healthy
+// one as long as the endpoints are unreachable. The errors returned by a
+// reachable endpoint are returned as is.
+func callWithFailover[T any](ctx context.Context, f *Failover, call func(client *ethclient.Client) (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+	for range f.endpoints {
+		index, client := f.currentClient()
+
+		result, err = call(client)
+		if err == nil || !isConnectionError(ctx, err) {
+			return result, err
+		}
+
+		if next := f.markUnhealthy(index, err); next == index {
+			break
+		}
+	}
+	return result, err
+}
+
+func (f *Failover) subscribe(ctx context.Context, subscribe func(context.Context, *ethclient.Client) (ethereum.Subscription, error)) (ethereum.Subscription, error) {
+	// The first subscription is made right away, so the caller knows whether
+	// it succeeded.
+	index, sub, err := f.subscribeOnCurrent(ctx, subscribe)
+	if err != nil {
+		return nil, err
+	}
+
+	firstSub, active := sub, true
+	return event.ResubscribeErr(resubscribeBackoffMax, func(ctx context.Context, lastErr error) (event.Subscription, error) {
+		if firstSub != nil {
+			sub := firstSub
+			firstSub = nil
+			return sub, nil
+		}
+
+		if active && lastErr != nil {
+			f.markUnhealthy(index, fmt.Errorf("subscription lost: %w", lastErr))
+		}
+		active = false
+
+		var (
+			sub ethereum.Subscription
+			err error
+		)
+		index, sub, err = f.subscribeOnCurrent(ctx, subscribe)
+		if err != nil {
+			return nil, err
+		}
+		active = true
+		return sub, nil
+	}), nil
+}
+
+func (f *Failover) subscribeOnCurrent(ctx context.Context, subscribe func(context.Context, *ethclient.Client) (ethereum.Subscription, error)) (int, ethereum.Subscription, error) {
+	f.mu.RLock()
+	index := f.current
+	endpoint := f.endpoints[index]
+	f.mu.RUnlock()
+
+	if !endpoint.supportsSubscriptions {
+		return index, nil, rpc.ErrNotificationsUnsupported
+	}
+
+	sub, err := subscribe(ctx, endpoint.client)
+	if err != nil {
+		if isConnectionError(ctx, err) {
+			f.markUnhealthy(index, err)
+		}
+		return index, nil, err
+	}
+	return index, sub, nil
+}
+
+func (f *Failover) currentClient() (int, *ethclient.Client) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.current, f.endpoints[f.current].client
+}
+
+// markUnhealthy marks the endpoint as unhealthy until the next health check,
+// and returns the index of the current endpoint, which changes if the
+// unhealthy endpoint was the current one and another one is healthy.
+func (f *Failover) markUnhealthy(index int, err error) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	health := &f.endpoints[index].health
+	health.Healthy = false
+	health.Error = err.Error()
+	health.LastCheck = time.Now()
+
+	f.selectEndpoint()
+
+	return f.current
+}
+
+// selectEndpoint keeps the current endpoint as long as it is healthy, and
+// switches to the first healthy one otherwise. If none is, the current one is
+// kept, as the best effort.
+func (f *Failover) selectEndpoint() {
+	if f.endpoints[f.current].health.Healthy {
+		return
+	}
+
+	for i, e := range f.endpoints {
+		if e.health.Healthy && e.client != nil {
+			f.current = i
+			return
+		}
+	}
+}
+
+func (f *Failover) healthOfEndpoints() []EndpointHealth {
+	health := make([]EndpointHealth, 0, len(f.endpoints))
+	for _, e := range f.endpoints {
+		health = append(health, e.health)
+	}
+	return health
+}
+
+func (f *Failover) checkHealthEvery(interval time.Duration) {
+	defer close(f.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			f.CheckHealth(ctx)
+			cancel()
+		}
+	}
+}
+
+func (f *Failover) closeEndpoints() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, e := range f.endpoints {
+		if e.client != nil {
+			e.client.Close()
+		}
+	}
+}
+
+// endpointProbe checks the health of an endpoint, dialing it first if needed.
+type endpointProbe struct {
+	address string
+	client  *ethclient.Client
+	chainID *big.Int
+	head    uint64
+	err     error
+}
+
+func (p *endpointProbe) run(ctx context.Context) {
+	if p.client == nil {
+		client, err := ethclient.DialContext(ctx, p.address)
+		if err != nil {
+			p.err = fmt.Errorf("failed to dial Ethereum client: %w", err)
+			return
+		}
+		p.client = client
+	}
+
+	chainID, err := p.client.ChainID(ctx)
+	if err != nil {
+		p.err = fmt.Errorf("failed to get chain ID: %w", err)
+		return
+	}
+	p.chainID = chainID
+
+	head, err := p.client.BlockNumber(ctx)
+	if err != nil {
+		p.err = fmt.Errorf("failed to get the block number: %w", err)
+		return
+	}
+	p.head = head
+}
+
+// isAlreadyBroadcast tells if the endpoint rejected the transaction because it
+// already has it. A "nonce too low" rejection only counts if the endpoint
+// knows the transaction with that hash, as it may be about another
+// transaction using the same nonce.
+func isAlreadyBroadcast(ctx context.Context, client *ethclient.Client, tx *types.Transaction, err error) bool {
+	message := strings.ToLower(err.Error())
+	if strings.Contains(message, "already known") || strings.Contains(message, "known transaction") {
+		return true
+	}
+	if !strings.Contains(message, "nonce too low") {
+		return false
+	}
+
+	_, _, lookupErr := client.TransactionByHash(ctx, tx.Hash())
+	return lookupErr == nil
+}
+
+// isConnectionError tells if the error is due to the endpoint being
+// unreachable, rather than an error returned by the endpoint.
+func isConnectionError(ctx context.Context, err error) bool {
+	if ctx != nil && ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return true
+	}
+
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
@@ -0,0 +1,404 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// chainService serves the chain ID and the head block of a fake node, and
+// notifies a new head every few milliseconds.
+type chainService struct {
+	chainID int64
+	head    uint64
+	// sendErr is the error returned when sending a transaction, if any.
+	sendErr string
+	sent    int32
+}
+
+func (s *chainService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(s.chainID))
+}
+
+func (s *chainService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(atomic.LoadUint64(&s.head))
+}
+
+func (s *chainService) SendRawTransaction(_ hexutil.Bytes) (common.Hash, error) {
+	atomic.AddInt32(&s.sent, 1)
+	if s.sendErr != "" {
+		return common.Hash{}, errors.New(s.sendErr)
+	}
+	return common.Hash{}, nil
+}
+
+// GetTransactionByHash doesn't know any transaction.
+func (s *chainService) GetTransactionByHash(_ common.Hash) (*types.Transaction, error) {
+	return nil, nil
+}
+
+func (s *chainService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+
+	sub := notifier.CreateSubscription()
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sub.Err():
+				return
+			case <-ticker.C:
+				_ = notifier.Notify(sub.ID, &types.Header{
+					Number:     new(big.Int).SetUint64(atomic.LoadUint64(&s.head)),
+					Difficulty: big.NewInt(0),
+				})
+			}
+		}
+	}()
+	return sub, nil
+}
+
+type fakeNode struct {
+	service    *chainService
+	server     *rpc.Server
+	httpServer *httptest.Server
+	address    string
+}
+
+func newFakeNode(t *testing.T, chainID int64, head uint64, websocket bool) *fakeNode {
+	t.Helper()
+
+	service := &chainService{chainID: chainID, head: head}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatalf("Failed to register the eth service: %s", err)
+	}
+
+	node := &fakeNode{service: service, server: server}
+	if websocket {
+		node.httpServer = httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+		node.address = "ws" + node.httpServer.URL[len("http"):]
+	} else {
+		node.httpServer = httptest.NewServer(server)
+		node.address = node.httpServer.URL
+	}
+	return node
+}
+
+func (n *fakeNode) stop() {
+	n.server.Stop()
+	n.httpServer.CloseClientConnections()
+	n.httpServer.Close()
+}
+
+func TestFailover(t *testing.T) {
+	resubscribeBackoffMax = 100 * time.Millisecond
+	defer func() {
+		resubscribeBackoffMax = 5 * time.Second
+	}()
+
+	t.Run("Creating a client selects the first healthy endpoint", testCreatingClientSelectsTheFirstHealthyEndpoint)
+	t.Run("Creating a client without healthy endpoint fails", testCreatingClientWithoutHealthyEndpointFails)
+	t.Run("Endpoints on another chain are unhealthy", testEndpointsOnAnotherChainAreUnhealthy)
+	t.Run("Lagging endpoints are unhealthy", testLaggingEndpointsAreUnhealthy)
+	t.Run("Calls fail over when the endpoint goes down", testCallsFailOverWhenTheEndpointGoesDown)
+	t.Run("Client calls fail over when the endpoint goes down", testClientCallsFailOverWhenTheEndpointGoesDown)
+	t.Run("Only single endpoint clients expose the go-ethereum client", testOnlySingleEndpointClientsExposeTheGoEthereumClient)
+	t.Run("Subscriptions are re-established after failing over", testSubscriptionsAreReestablishedAfterFailingOver)
+	t.Run("Resending a known transaction after failing over succeeds", testResendingKnownTransactionAfterFailingOverSucceeds)
+	t.Run("Sending a rejected transaction fails", testSendingRejectedTransactionFails)
+}
+
+func testCreatingClientSelectsTheFirstHealthyEndpoint(t *testing.T) {
+	down := newFakeNode(t, 1337, 10, false)
+	down.stop()
+	first := newFakeNode(t, 1337, 10, false)
+	defer first.stop()
+	second := newFakeNode(t, 1337, 10, false)
+	defer second.stop()
+
+	client, err := NewFailoverClient(context.Background(), []string{down.address, first.address, second.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	if current := client.Failover().CurrentEndpoint(); current.Address != first.address || !current.Healthy {
+		t.Errorf("expected the current endpoint to be the healthy %s, got %+v", first.address, current)
+	}
+	if client.chainID.Int64() != 1337 {
+		t.Errorf("expected chain ID 1337, got %s", client.chainID)
+	}
+
+	health := client.Failover().Health()
+	if len(health) != 3 {
+		t.Fatalf("expected the health of 3 endpoints, got %d", len(health))
+	}
+	if health[0].Healthy || health[0].Error == "" {
+		t.Errorf("expected the stopped endpoint to be unhealthy, got %+v", health[0])
+	}
+	if !health[1].Healthy || !health[2].Healthy {
+		t.Errorf("expected the running endpoints to be healthy, got %+v", health[1:])
+	}
+}
+
+func testCreatingClientWithoutHealthyEndpointFails(t *testing.T) {
+	down := newFakeNode(t, 1337, 10, false)
+	down.stop()
+
+	if _, err := NewFailoverClient(context.Background(), []string{down.address}, FailoverOptions{}); err == nil {
+		t.Errorf("expected an error without healthy endpoint")
+	}
+
+	if _, err := NewFailoverClient(context.Background(), nil, FailoverOptions{}); err == nil {
+		t.Errorf("expected an error without endpoint")
+	}
+}
+
+func testEndpointsOnAnotherChainAreUnhealthy(t *testing.T) {
+	otherChain := newFakeNode(t, 1, 10, false)
+	defer otherChain.stop()
+	expectedChain := newFakeNode(t, 1337, 10, false)
+	defer expectedChain.stop()
+
+	client, err := NewFailoverClient(context.Background(), []string{otherChain.address, expectedChain.address}, FailoverOptions{
+		ChainID: big.NewInt(1337),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	health := client.Failover().Health()
+	if health[0].Healthy {
+		t.Errorf("expected the endpoint on another chain to be unhealthy")
+	}
+	if current := client.Failover().CurrentEndpoint(); current.Address != expectedChain.address {
+		t.Errorf("expected the current endpoint to be %s, got %s", expectedChain.address, current.Address)
+	}
+}
+
+func testLaggingEndpointsAreUnhealthy(t *testing.T) {
+	lagging := newFakeNode(t, 1337, 10, false)
+	defer lagging.stop()
+	upToDate := newFakeNode(t, 1337, 100, false)
+	defer upToDate.stop()
+
+	client, err := NewFailoverClient(context.Background(), []string{lagging.address, upToDate.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	health := client.Failover().Health()
+	if health[0].Healthy || health[0].HeadBlock != 10 {
+		t.Errorf("expected the lagging endpoint to be unhealthy at block 10, got %+v", health[0])
+	}
+	if current := client.Failover().CurrentEndpoint(); current.Address != upToDate.address || current.HeadBlock != 100 {
+		t.Errorf("expected the current endpoint to be %s at block 100, got %+v", upToDate.address, current)
+	}
+}
+
+func testCallsFailOverWhenTheEndpointGoesDown(t *testing.T) {
+	first := newFakeNode(t, 1337, 10, false)
+	second := newFakeNode(t, 1337, 12, false)
+	defer second.stop()
+
+	client, err := NewFailoverClient(context.Background(), []string{first.address, second.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	head, err := client.Backend().BlockNumber(context.Background())
+	if err != nil || head != 10 {
+		t.Fatalf("expected block 10 from the first endpoint, got %d (%v)", head, err)
+	}
+
+	first.stop()
+
+	head, err = client.Backend().BlockNumber(context.Background())
+	if err != nil || head != 12 {
+		t.Fatalf("expected block 12 from the second endpoint, got %d (%v)", head, err)
+	}
+	if current := client.Failover().CurrentEndpoint(); current.Address != second.address {
+		t.Errorf("expected the current endpoint to be %s, got %s", second.address, current.Address)
+	}
+	if health := client.Failover().Health(); health[0].Healthy {
+		t.Errorf("expected the stopped endpoint to be unhealthy")
+	}
+}
+
+func testClientCallsFailOverWhenTheEndpointGoesDown(t *testing.T) {
+	first := newFakeNode(t, 1337, 10, false)
+	second := newFakeNode(t, 1337, 12, false)
+	defer second.stop()
+
+	client, err := NewFailoverClient(context.Background(), []string{first.address, second.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	first.stop()
+
+	head, err := client.BlockNumber(context.Background())
+	if err != nil || head != 12 {
+		t.Fatalf("expected block 12 from the second endpoint, got %d (%v)", head, err)
+	}
+	chainID, err := client.ChainID(context.Background())
+	if err != nil || chainID.Int64() != 1337 {
+		t.Fatalf("expected chain ID 1337 from the second endpoint, got %v (%v)", chainID, err)
+	}
+	if current := client.Failover().CurrentEndpoint(); current.Address != second.address {
+		t.Errorf("expected the current endpoint to be %s, got %s", second.address, current.Address)
+	}
+}
+
+func testOnlySingleEndpointClientsExposeTheGoEthereumClient(t *testing.T) {
+	node := newFakeNode(t, 1337, 10, false)
+	defer node.stop()
+
+	client, err := NewClient(context.Background(), node.address)
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	if client.EthClient() == nil {
+		t.Fatalf("expected the go-ethereum client of a single endpoint client")
+	}
+	if chainID, err := client.EthClient().ChainID(context.Background()); err != nil || chainID.Int64() != 1337 {
+		t.Errorf("expected chain ID 1337 from the go-ethereum client, got %v (%v)", chainID, err)
+	}
+
+	failoverClient, err := NewFailoverClient(context.Background(), []string{node.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer failoverClient.Close()
+
+	if failoverClient.EthClient() != nil {
+		t.Errorf("expected no go-ethereum client for a failover client")
+	}
+}
+
+func testSubscriptionsAreReestablishedAfterFailingOver(t *testing.T) {
+	first := newFakeNode(t, 1337, 10, true)
+	second := newFakeNode(t, 1337, 12, true)
+	defer second.stop()
+
+	client, err := NewFailoverClient(context.Background(), []string{first.address, second.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	if !client.SupportsSubscriptions() {
+		t.Fatalf("websocket endpoints should support subscriptions")
+	}
+
+	heads := make(chan *types.Header)
+	sub, err := client.Backend().SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to new heads: %s", err)
+	}
+	defer sub.Unsubscribe()
+
+	waitForHead(t, heads, 10)
+
+	first.stop()
+
+	waitForHead(t, heads, 12)
+	if current := client.Failover().CurrentEndpoint(); current.Address != second.address {
+		t.Errorf("expected the current endpoint to be %s, got %s", second.address, current.Address)
+	}
+}
+
+func testResendingKnownTransactionAfterFailingOverSucceeds(t *testing.T) {
+	first := newFakeNode(t, 1337, 10, false)
+	second := newFakeNode(t, 1337, 10, false)
+	defer second.stop()
+	second.service.sendErr = "already known"
+
+	client, err := NewFailoverClient(context.Background(), []string{first.address, second.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	first.stop()
+
+	if err := client.SendTransaction(context.Background(), newFailoverTx()); err != nil {
+		t.Errorf("expected the known transaction to be a success, got %s", err)
+	}
+	if sent := atomic.LoadInt32(&second.service.sent); sent != 1 {
+		t.Errorf("expected the transaction to be sent to the second endpoint once, got %d", sent)
+	}
+}
+
+func testSendingRejectedTransactionFails(t *testing.T) {
+	first := newFakeNode(t, 1337, 10, false)
+	defer first.stop()
+	first.service.sendErr = "already known"
+	second := newFakeNode(t, 1337, 10, false)
+	second.service.sendErr = "nonce too low"
+
+	client, err := NewFailoverClient(context.Background(), []string{first.address, second.address}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	// The transaction isn't resent, so it is not known from a previous
+	// attempt.
+	if err := client.SendTransaction(context.Background(), newFailoverTx()); err == nil {
+		t.Errorf("expected an error for a transaction known on the first attempt")
+	}
+	if sent := atomic.LoadInt32(&second.service.sent); sent != 0 {
+		t.Errorf("expected the transaction not to be sent to the second endpoint, got %d", sent)
+	}
+
+	first.stop()
+	defer second.stop()
+
+	// The endpoint doesn't know the transaction, so the nonce has been used
+	// by another one.
+	if err := client.SendTransaction(context.Background(), newFailoverTx()); err == nil || !strings.Contains(err.Error(), "nonce too low") {
+		t.Errorf("expected a nonce error for an unknown transaction, got %v", err)
+	}
+}
+
+func newFailoverTx() *types.Transaction {
+	return types.NewTransaction(1, common.HexToAddress("0x1b8a1B6CBE5c93609b46D1829Cc7f3Cb8eeE23a0"), big.NewInt(1), 21000, big.NewInt(1), nil)
+}
+
+func waitForHead(t *testing.T, heads <-chan *types.Header, number uint64) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case head := <-heads:
+			if head.Number.Uint64() == number {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for head %d", number)
+		}
+	}
+}
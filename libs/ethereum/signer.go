@@ -0,0 +1,205 @@
+package ethereum
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Signer signs the transactions sent by the sessions, on behalf of a single
+// account.
+type Signer interface {
+	// Address is the address of the account signing the transactions.
+	Address() common.Address
+	// SignTx signs the transaction for the given chain.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// LocalSigner signs with a private key held in memory. It suits the tests,
+// as a stand-in for the other signers.
+type LocalSigner struct {
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+// NewLocalSigner creates a signer from a hex-encoded private key.
+func NewLocalSigner(hexPrivateKey string) (*LocalSigner, error) {
+	privateKey, err := crypto.HexToECDSA(hexPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert private key hash into ECDSA: %w", err)
+	}
+	return NewLocalSignerFromECDSA(privateKey), nil
+}
+
+func NewLocalSignerFromECDSA(privateKey *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+}
+
+// GenerateLocalSigner creates a signer from a newly generated private key.
+func GenerateLocalSigner() (*LocalSigner, error) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+	return NewLocalSignerFromECDSA(privateKey), nil
+}
+
+// NewLocalSignerFromKeystore creates a signer from a go-ethereum keystore
+// JSON key, decrypted with the passphrase.
+func NewLocalSignerFromKeystore(keyJSON []byte, passphrase string) (*LocalSigner, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore key: %w", err)
+	}
+	return NewLocalSignerFromECDSA(key.PrivateKey), nil
+}
+
+// NewLocalSignerFromKeystoreFile creates a signer from a go-ethereum keystore
+// file, decrypted with the passphrase.
+func NewLocalSignerFromKeystoreFile(keyFilePath string, passphrase string) (*LocalSigner, error) {
+	keyJSON, err := os.ReadFile(keyFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file %s: %w", keyFilePath, err)
+	}
+	return NewLocalSignerFromKeystore(keyJSON, passphrase)
+}
+
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *LocalSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), s.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return signedTx, nil
+}
+
+// RemoteSigner delegates the signing to a remote signer, such as Clef, through
+// the standard `account_signTransaction` JSON-RPC method.
+type RemoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// NewRemoteSigner dials the remote signer at the given endpoint, that signs on
+// behalf of the given account.
+func NewRemoteSigner(ctx context.Context, endpoint string, address common.Address) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial remote signer: %w", err)
+	}
+
+	return &RemoteSigner{
+		client:  client,
+		address: address,
+	}, nil
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx sends the transaction to the remote signer, and checks it has been
+// signed by the expected account.
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args, err := signTxArgsFor(s.address, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Raw hexutil.Bytes      `json:"raw"`
+		Tx  *types.Transaction `json:"tx"`
+	}
+	if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("failed to sign transaction with remote signer: %w", err)
+	}
+	if result.Tx == nil {
+		return nil, fmt.Errorf("the remote signer didn't return the signed transaction")
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), result.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the signature of the remote signer: %w", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("the remote signer signed with %s instead of %s", sender, s.address)
+	}
+
+	return result.Tx, nil
+}
+
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+func signTxArgsFor(from common.Address, tx *types.Transaction, chainID *big.Int) (*apitypes.SendTxArgs, error) {
+	data := hexutil.Bytes(tx.Data())
+	args := &apitypes.SendTxArgs{
+		From:  common.NewMixedcaseAddress(from),
+		Gas:   hexutil.Uint64(tx.Gas()),
+		Value: hexutil.Big(*tx.Value()),
+		Nonce: hexutil.Uint64(tx.Nonce()),
+		Data:  &data,
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+
+	if chainID != nil && chainID.Sign() != 0 {
+		args.ChainID = (*hexutil.Big)(chainID)
+	}
+	if tx.Type() != types.LegacyTxType {
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+
+	return args, nil
+}
+
+// transactOptsFor creates the options of the sessions to sign their
+// transactions with the signer.
+func transactOptsFor(ctx context.Context, signer Signer, chainID *big.Int) *bind.TransactOpts {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	from := signer.Address()
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, chainID)
+		},
+		Context: context.Background(),
+	}
+}
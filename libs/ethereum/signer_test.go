@@ -0,0 +1,229 @@
+package ethereum_test
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	vgethereum "code.vegaprotocol.io/shared/libs/ethereum"
+	vgrand "code.vegaprotocol.io/shared/libs/rand"
+)
+
+var signerChainID = big.NewInt(1337)
+
+// accountService is a stand-in for a remote signer, such as Clef, signing the
+// transactions with a local signer.
+type accountService struct {
+	signer vgethereum.Signer
+}
+
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+func (s *accountService) SignTransaction(ctx context.Context, args apitypes.SendTxArgs) (*signTransactionResult, error) {
+	tx, err := s.signer.SignTx(ctx, args.ToTransaction(), (*big.Int)(args.ChainID))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTransactionResult{Raw: raw, Tx: tx}, nil
+}
+
+func newRemoteSignerServer(t *testing.T, signer vgethereum.Signer) (*rpc.Server, *httptest.Server) {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &accountService{signer: signer}); err != nil {
+		t.Fatalf("Failed to register the account service: %s", err)
+	}
+	return server, httptest.NewServer(server)
+}
+
+func newUnsignedTx() *types.Transaction {
+	to := common.HexToAddress("0x1b8a1B6CBE5c93609b46D1829Cc7f3Cb8eeE23a0")
+	return types.NewTransaction(1, to, big.NewInt(42), 21000, big.NewInt(1000000000), []byte("data"))
+}
+
+func assertSignedBy(t *testing.T, tx *types.Transaction, expected common.Address) {
+	t.Helper()
+
+	sender, err := types.Sender(types.LatestSignerForChainID(signerChainID), tx)
+	if err != nil {
+		t.Fatalf("Failed to recover the sender: %s", err)
+	}
+	if sender != expected {
+		t.Errorf("expected the transaction to be signed by %s, got %s", expected, sender)
+	}
+}
+
+func TestSigners(t *testing.T) {
+	t.Run("Local signer signs with its key", testLocalSignerSignsWithItsKey)
+	t.Run("Local signer with an invalid key fails", testLocalSignerWithInvalidKeyFails)
+	t.Run("Keystore signer decrypts the key", testKeystoreSignerDecryptsTheKey)
+	t.Run("Remote signer signs through JSON-RPC", testRemoteSignerSignsThroughJSONRPC)
+	t.Run("Remote signer signing with another account fails", testRemoteSignerSigningWithAnotherAccountFails)
+	t.Run("Sessions sign with the signer", testSessionsSignWithTheSigner)
+}
+
+func testLocalSignerSignsWithItsKey(t *testing.T) {
+	signer, err := vgethereum.NewLocalSigner(contractOwnerPrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to create local signer: %s", err)
+	}
+
+	if signer.Address() != contractOwnerAddress {
+		t.Errorf("expected address %s, got %s", contractOwnerAddress, signer.Address())
+	}
+
+	tx, err := signer.SignTx(context.Background(), newUnsignedTx(), signerChainID)
+	if err != nil {
+		t.Fatalf("Failed to sign transaction: %s", err)
+	}
+	assertSignedBy(t, tx, contractOwnerAddress)
+}
+
+func testLocalSignerWithInvalidKeyFails(t *testing.T) {
+	if _, err := vgethereum.NewLocalSigner("not-a-key"); err == nil {
+		t.Errorf("expected an error for an invalid private key")
+	}
+}
+
+func testKeystoreSignerDecryptsTheKey(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate private key: %s", err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Address:    address,
+		PrivateKey: privateKey,
+	}, "passphrase", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("Failed to encrypt key: %s", err)
+	}
+
+	keyFilePath := filepath.Join(os.TempDir(), "vega-"+vgrand.RandomStr(8)+".json")
+	if err := os.WriteFile(keyFilePath, keyJSON, 0o600); err != nil {
+		t.Fatalf("Failed to write keystore file: %s", err)
+	}
+	defer os.Remove(keyFilePath)
+
+	signer, err := vgethereum.NewLocalSignerFromKeystoreFile(keyFilePath, "passphrase")
+	if err != nil {
+		t.Fatalf("Failed to create keystore signer: %s", err)
+	}
+	if signer.Address() != address {
+		t.Errorf("expected address %s, got %s", address, signer.Address())
+	}
+
+	tx, err := signer.SignTx(context.Background(), newUnsignedTx(), signerChainID)
+	if err != nil {
+		t.Fatalf("Failed to sign transaction: %s", err)
+	}
+	assertSignedBy(t, tx, address)
+
+	if _, err := vgethereum.NewLocalSignerFromKeystore(keyJSON, "wrong passphrase"); err == nil {
+		t.Errorf("expected an error for a wrong passphrase")
+	}
+}
+
+func testRemoteSignerSignsThroughJSONRPC(t *testing.T) {
+	localSigner, err := vgethereum.GenerateLocalSigner()
+	if err != nil {
+		t.Fatalf("Failed to create local signer: %s", err)
+	}
+	server, httpServer := newRemoteSignerServer(t, localSigner)
+	defer server.Stop()
+	defer httpServer.Close()
+
+	signer, err := vgethereum.NewRemoteSigner(context.Background(), httpServer.URL, localSigner.Address())
+	if err != nil {
+		t.Fatalf("Failed to create remote signer: %s", err)
+	}
+	defer signer.Close()
+
+	unsignedTx := newUnsignedTx()
+	tx, err := signer.SignTx(context.Background(), unsignedTx, signerChainID)
+	if err != nil {
+		t.Fatalf("Failed to sign transaction: %s", err)
+	}
+	assertSignedBy(t, tx, localSigner.Address())
+	if tx.Nonce() != unsignedTx.Nonce() || tx.Value().Cmp(unsignedTx.Value()) != 0 || string(tx.Data()) != string(unsignedTx.Data()) {
+		t.Errorf("expected the signed transaction to match the unsigned one")
+	}
+}
+
+func testRemoteSignerSigningWithAnotherAccountFails(t *testing.T) {
+	localSigner, err := vgethereum.GenerateLocalSigner()
+	if err != nil {
+		t.Fatalf("Failed to create local signer: %s", err)
+	}
+	server, httpServer := newRemoteSignerServer(t, localSigner)
+	defer server.Stop()
+	defer httpServer.Close()
+
+	signer, err := vgethereum.NewRemoteSigner(context.Background(), httpServer.URL, contractOwnerAddress)
+	if err != nil {
+		t.Fatalf("Failed to create remote signer: %s", err)
+	}
+	defer signer.Close()
+
+	if _, err := signer.SignTx(context.Background(), newUnsignedTx(), signerChainID); err == nil {
+		t.Errorf("expected an error when the remote signer signs with another account")
+	}
+}
+
+func testSessionsSignWithTheSigner(t *testing.T) {
+	server := newRPCServer(t)
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := vgethereum.NewClient(context.Background(), httpServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to create Ethereum client: %s", err)
+	}
+	defer client.Close()
+
+	signer, err := vgethereum.GenerateLocalSigner()
+	if err != nil {
+		t.Fatalf("Failed to create local signer: %s", err)
+	}
+
+	session, err := client.NewBaseTokenSession(context.Background(), signer, tUSDCTokenAddress, nil)
+	if err != nil {
+		t.Fatalf("Failed to create base token session: %s", err)
+	}
+
+	if session.TransactOpts.From != signer.Address() {
+		t.Errorf("expected the session to send from %s, got %s", signer.Address(), session.TransactOpts.From)
+	}
+
+	tx, err := session.TransactOpts.Signer(signer.Address(), newUnsignedTx())
+	if err != nil {
+		t.Fatalf("Failed to sign transaction: %s", err)
+	}
+	assertSignedBy(t, tx, signer.Address())
+
+	if _, err := session.TransactOpts.Signer(contractOwnerAddress, newUnsignedTx()); err == nil {
+		t.Errorf("expected an error when signing for another account")
+	}
+}
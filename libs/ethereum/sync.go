@@ -0,0 +1,189 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// receiptPollInterval is the interval at which the receipt of a transaction is
+// looked up while waiting for it.
+var receiptPollInterval = time.Second
+
+// TransactionRevertedError is returned by the *Sync helpers when the
+// transaction has been mined, but reverted.
+type TransactionRevertedError struct {
+	TxHash common.Hash
+}
+
+func (e TransactionRevertedError) Error() string {
+	return fmt.Sprintf("transaction %s has been reverted", e.TxHash)
+}
+
+// syncBackend is the part of the client the *Sync helpers rely on to follow a
+// transaction.
+type syncBackend interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	SupportsSubscriptions() bool
+}
+
+// eventSync waits for the receipt of a transaction, and decodes the event
+// confirming it from the logs of the receipt.
+type eventSync[T any] struct {
+	// name is the name of the event, as displayed in error messages.
+	name string
+	// contract is the address of the contract emitting the event.
+	contract common.Address
+	// parse decodes the event from a log of the contract.
+	parse func(log types.Log) (T, error)
+	// matches tells if the decoded event is the expected one.
+	matches func(event T) bool
+}
+
+func (s eventSync[T]) run(ctx context.Context, backend syncBackend, confirmations uint64, timeout time.Duration, send func() (*types.Transaction, error)) (*types.Transaction, error) {
+	tx, err := send()
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := waitForReceipt(ctx, backend, tx, confirmations, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.eventIn(receipt); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// eventIn returns the first event of the receipt matching the expected one.
+func (s eventSync[T]) eventIn(receipt *types.Receipt) (T, error) {
+	events := s.eventsIn(receipt)
+	if len(events) == 0 {
+		var none T
+		return none, fmt.Errorf("transaction %s has been mined without the expected %s event", receipt.TxHash, s.name)
+	}
+	return events[0], nil
+}
+
+// lastEventIn returns the last event of the receipt matching the expected one.
+func (s eventSync[T]) lastEventIn(receipt *types.Receipt) (T, error) {
+	events := s.eventsIn(receipt)
+	if len(events) == 0 {
+		var none T
+		return none, fmt.Errorf("transaction %s has been mined without the expected %s event", receipt.TxHash, s.name)
+	}
+	return events[len(events)-1], nil
+}
+
+// eventsIn returns the events of the receipt matching the expected one, in
+// the order they have been emitted.
+func (s eventSync[T]) eventsIn(receipt *types.Receipt) []T {
+	events := []T{}
+	for _, log := range receipt.Logs {
+		if log.Address != s.contract || len(log.Topics) == 0 {
+			continue
+		}
+
+		event, err := s.parse(*log)
+		if err != nil {
+			continue
+		}
+
+		if s.matches(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// waitForReceipt waits for the transaction to be mined, and then for the given
+// number of confirmations, the block including the transaction being the
+// first one. It returns a TransactionRevertedError if the transaction has been
+// reverted.
+//
+// The receipt is polled, and, when the backend supports subscriptions, also
+// looked up on every new block. A timeout of 0 waits as long as the context
+// allows.
+func waitForReceipt(ctx context.Context, backend syncBackend, tx *types.Transaction, confirmations uint64, timeout time.Duration) (*types.Receipt, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var (
+		heads   chan *types.Header
+		headErr <-chan error
+	)
+	if backend.SupportsSubscriptions() {
+		heads = make(chan *types.Header, 1)
+		sub, err := backend.SubscribeNewHead(ctx, heads)
+		if err == nil {
+			defer sub.Unsubscribe()
+			headErr = sub.Err()
+		} else {
+			// Polling still works without the subscription.
+			heads = nil
+		}
+	}
+
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		receipt, err := backend.TransactionReceipt(ctx, tx.Hash())
+		if err == nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return nil, TransactionRevertedError{TxHash: tx.Hash()}
+			}
+
+			confirmed, err := isConfirmed(ctx, backend, receipt, confirmations)
+			if err == nil && confirmed {
+				return receipt, nil
+			}
+			lastErr = err
+		} else if !errors.Is(err, ethereum.NotFound) {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("transaction time has timed out: %w", lastErr)
+			}
+			return nil, fmt.Errorf("transaction time has timed out")
+		case <-heads:
+		case <-headErr:
+			// The subscription is lost, so it falls back to polling.
+			heads, headErr = nil, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func isConfirmed(ctx context.Context, backend syncBackend, receipt *types.Receipt, confirmations uint64) (bool, error) {
+	if confirmations <= 1 {
+		return true, nil
+	}
+
+	head, err := backend.BlockNumber(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the block number: %w", err)
+	}
+
+	return head+1 >= receipt.BlockNumber.Uint64()+confirmations, nil
+}
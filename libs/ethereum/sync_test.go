@@ -0,0 +1,344 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+
+	"code.vegaprotocol.io/shared/libs/ethereum/generated"
+)
+
+var (
+	syncedContract = common.HexToAddress("0x1b8a1B6CBE5c93609b46D1829Cc7f3Cb8eeE23a0")
+	syncedTopic    = common.HexToHash("0x01")
+)
+
+// fakeSyncBackend returns the receipt after the given number of lookups, and
+// mines a new block on every block number lookup.
+type fakeSyncBackend struct {
+	mu                    sync.Mutex
+	receipt               *types.Receipt
+	lookupsToMined        int
+	lookups               int
+	head                  uint64
+	supportsSubscriptions bool
+	heads                 chan<- *types.Header
+}
+
+func (b *fakeSyncBackend) TransactionReceipt(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lookups++
+	if b.receipt == nil || b.lookups <= b.lookupsToMined {
+		return nil, ethereum.NotFound
+	}
+	return b.receipt, nil
+}
+
+func (b *fakeSyncBackend) BlockNumber(_ context.Context) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.head++
+	return b.head, nil
+}
+
+func (b *fakeSyncBackend) SubscribeNewHead(_ context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.heads = ch
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	}), nil
+}
+
+func (b *fakeSyncBackend) SupportsSubscriptions() bool {
+	return b.supportsSubscriptions
+}
+
+func TestSync(t *testing.T) {
+	receiptPollInterval = 10 * time.Millisecond
+	defer func() {
+		receiptPollInterval = time.Second
+	}()
+
+	t.Run("Waiting for a mined transaction with the event succeeds", testWaitingForMinedTransactionWithTheEventSucceeds)
+	t.Run("Waiting for a mined transaction without the event fails", testWaitingForMinedTransactionWithoutTheEventFails)
+	t.Run("Waiting for a reverted transaction fails", testWaitingForRevertedTransactionFails)
+	t.Run("Waiting for confirmations succeeds", testWaitingForConfirmationsSucceeds)
+	t.Run("Waiting with subscriptions succeeds", testWaitingWithSubscriptionsSucceeds)
+	t.Run("Waiting for a pending transaction times out", testWaitingForPendingTransactionTimesOut)
+	t.Run("Failing to send a transaction does not wait", testFailingToSendTransactionDoesNotWait)
+	t.Run("Last transfer value is the one of the token contract", testLastTransferValueIsTheOneOfTheTokenContract)
+	t.Run("Last transfer value of a reverted transaction fails", testLastTransferValueOfRevertedTransactionFails)
+	t.Run("Last transfer value waits beyond the sync timeout", testLastTransferValueWaitsBeyondTheSyncTimeout)
+	t.Run("Last transfer value stops at the deadline of the context", testLastTransferValueStopsAtTheDeadlineOfTheContext)
+}
+
+func testWaitingForMinedTransactionWithTheEventSucceeds(t *testing.T) {
+	tx := newSyncedTx()
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusSuccessful,
+			&types.Log{Address: common.HexToAddress("0x02"), Topics: []common.Hash{syncedTopic}},
+			&types.Log{Address: syncedContract, Topics: []common.Hash{syncedTopic}},
+		),
+		lookupsToMined: 2,
+	}
+
+	got, err := newSyncedEventSync().run(context.Background(), backend, 0, 5*time.Second, sendTx(tx))
+	if err != nil {
+		t.Fatalf("run() error = %s", err)
+	}
+	if got.Hash() != tx.Hash() {
+		t.Errorf("run() got = %s, want %s", got.Hash(), tx.Hash())
+	}
+}
+
+func testWaitingForMinedTransactionWithoutTheEventFails(t *testing.T) {
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusSuccessful,
+			&types.Log{Address: common.HexToAddress("0x02"), Topics: []common.Hash{syncedTopic}},
+			&types.Log{Address: syncedContract},
+			&types.Log{Address: syncedContract, Topics: []common.Hash{common.HexToHash("0x03")}},
+		),
+	}
+
+	_, err := newSyncedEventSync().run(context.Background(), backend, 0, 5*time.Second, sendTx(newSyncedTx()))
+	if err == nil || !strings.Contains(err.Error(), "without the expected synced event") {
+		t.Errorf("run() error = %v, want an error about the missing event", err)
+	}
+}
+
+func testWaitingForRevertedTransactionFails(t *testing.T) {
+	tx := newSyncedTx()
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusFailed),
+	}
+
+	_, err := newSyncedEventSync().run(context.Background(), backend, 0, 5*time.Second, sendTx(tx))
+
+	var revertedErr TransactionRevertedError
+	if !errors.As(err, &revertedErr) {
+		t.Fatalf("run() error = %v, want a TransactionRevertedError", err)
+	}
+	if revertedErr.TxHash != tx.Hash() {
+		t.Errorf("run() reverted = %s, want %s", revertedErr.TxHash, tx.Hash())
+	}
+}
+
+func testWaitingForConfirmationsSucceeds(t *testing.T) {
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusSuccessful,
+			&types.Log{Address: syncedContract, Topics: []common.Hash{syncedTopic}},
+		),
+	}
+
+	receipt, err := waitForReceipt(context.Background(), backend, newSyncedTx(), 3, 5*time.Second)
+	if err != nil {
+		t.Fatalf("waitForReceipt() error = %s", err)
+	}
+	// The receipt is in block 10, so the third confirmation is block 12.
+	if backend.head != 12 {
+		t.Errorf("waitForReceipt() returned %v at block %d, want block 12", receipt.TxHash, backend.head)
+	}
+}
+
+func testWaitingWithSubscriptionsSucceeds(t *testing.T) {
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusSuccessful,
+			&types.Log{Address: syncedContract, Topics: []common.Hash{syncedTopic}},
+		),
+		supportsSubscriptions: true,
+	}
+
+	if _, err := waitForReceipt(context.Background(), backend, newSyncedTx(), 0, 5*time.Second); err != nil {
+		t.Fatalf("waitForReceipt() error = %s", err)
+	}
+	if backend.heads == nil {
+		t.Errorf("waitForReceipt() should subscribe to the new heads")
+	}
+}
+
+func testWaitingForPendingTransactionTimesOut(t *testing.T) {
+	_, err := waitForReceipt(context.Background(), &fakeSyncBackend{}, newSyncedTx(), 0, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("waitForReceipt() error = %v, want a time out", err)
+	}
+}
+
+func testFailingToSendTransactionDoesNotWait(t *testing.T) {
+	backend := &fakeSyncBackend{}
+	sendErr := errors.New("insufficient funds")
+
+	_, err := newSyncedEventSync().run(context.Background(), backend, 0, 5*time.Second, func() (*types.Transaction, error) {
+		return nil, sendErr
+	})
+
+	if !errors.Is(err, sendErr) {
+		t.Errorf("run() error = %v, want %v", err, sendErr)
+	}
+	if backend.lookups != 0 {
+		t.Errorf("run() looked up the receipt %d times, want 0", backend.lookups)
+	}
+}
+
+func testLastTransferValueIsTheOneOfTheTokenContract(t *testing.T) {
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusSuccessful,
+			newTransferLog(syncedContract, 1),
+			newTransferLog(syncedContract, 2),
+			newTransferLog(common.HexToAddress("0x02"), 3),
+			&types.Log{Address: syncedContract, Topics: []common.Hash{syncedTopic}},
+		),
+		lookupsToMined: 1,
+	}
+
+	value, err := newSyncedTokenSession(t, backend).GetLastTransferValueSync(context.Background(), newSyncedTx())
+	if err != nil {
+		t.Fatalf("GetLastTransferValueSync() error = %s", err)
+	}
+	if value.Int64() != 2 {
+		t.Errorf("GetLastTransferValueSync() got = %s, want 2", value)
+	}
+}
+
+func testLastTransferValueOfRevertedTransactionFails(t *testing.T) {
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusFailed, newTransferLog(syncedContract, 1)),
+	}
+
+	_, err := newSyncedTokenSession(t, backend).GetLastTransferValueSync(context.Background(), newSyncedTx())
+
+	var revertedErr TransactionRevertedError
+	if !errors.As(err, &revertedErr) {
+		t.Errorf("GetLastTransferValueSync() error = %v, want a TransactionRevertedError", err)
+	}
+}
+
+func testLastTransferValueWaitsBeyondTheSyncTimeout(t *testing.T) {
+	backend := &fakeSyncBackend{
+		receipt: newSyncedReceipt(types.ReceiptStatusSuccessful, newTransferLog(syncedContract, 1)),
+		// At one lookup every 10ms, it is mined after the sync timeout.
+		lookupsToMined: 10,
+	}
+	session := newSyncedTokenSession(t, backend)
+	session.syncTimeout = 20 * time.Millisecond
+
+	value, err := session.GetLastTransferValueSync(context.Background(), newSyncedTx())
+	if err != nil {
+		t.Fatalf("GetLastTransferValueSync() error = %s", err)
+	}
+	if value.Int64() != 1 {
+		t.Errorf("GetLastTransferValueSync() got = %s, want 1", value)
+	}
+}
+
+func testLastTransferValueStopsAtTheDeadlineOfTheContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := newSyncedTokenSession(t, &fakeSyncBackend{}).GetLastTransferValueSync(ctx, newSyncedTx())
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("GetLastTransferValueSync() error = %v, want a time out", err)
+	}
+}
+
+// syncOnlyBackend serves the calls of the *Sync helpers from a fake backend.
+// Any other call panics.
+type syncOnlyBackend struct {
+	Backend
+	sync *fakeSyncBackend
+}
+
+func (b syncOnlyBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return b.sync.TransactionReceipt(ctx, txHash)
+}
+
+func (b syncOnlyBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return b.sync.BlockNumber(ctx)
+}
+
+func (b syncOnlyBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return b.sync.SubscribeNewHead(ctx, ch)
+}
+
+func (b syncOnlyBackend) SupportsSubscriptions() bool {
+	return b.sync.SupportsSubscriptions()
+}
+
+func newSyncedTokenSession(t *testing.T, sync *fakeSyncBackend) *BaseTokenSession {
+	t.Helper()
+
+	backend := syncOnlyBackend{sync: sync}
+	token, err := generated.NewBaseToken(syncedContract, backend)
+	if err != nil {
+		t.Fatalf("Failed to bind the token: %s", err)
+	}
+
+	return &BaseTokenSession{
+		BaseTokenSession: generated.BaseTokenSession{Contract: token},
+		syncTimeout:      5 * time.Second,
+		address:          syncedContract,
+		client:           &Client{backend: backend},
+	}
+}
+
+func newTransferLog(contract common.Address, value int64) *types.Log {
+	return &types.Log{
+		Address: contract,
+		Topics: []common.Hash{
+			crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")),
+			common.BytesToHash(common.HexToAddress("0x03").Bytes()),
+			common.BytesToHash(common.HexToAddress("0x04").Bytes()),
+		},
+		Data: common.LeftPadBytes(big.NewInt(value).Bytes(), 32),
+	}
+}
+
+func newSyncedEventSync() eventSync[*types.Log] {
+	return eventSync[*types.Log]{
+		name:     "synced",
+		contract: syncedContract,
+		parse: func(log types.Log) (*types.Log, error) {
+			if log.Topics[0] != syncedTopic {
+				return nil, errors.New("event signature mismatch")
+			}
+			return &log, nil
+		},
+		matches: func(log *types.Log) bool {
+			return true
+		},
+	}
+}
+
+func newSyncedTx() *types.Transaction {
+	return types.NewTransaction(1, syncedContract, big.NewInt(0), 21000, big.NewInt(1), nil)
+}
+
+func newSyncedReceipt(status uint64, logs ...*types.Log) *types.Receipt {
+	return &types.Receipt{
+		Status:      status,
+		TxHash:      newSyncedTx().Hash(),
+		BlockNumber: big.NewInt(10),
+		Logs:        logs,
+	}
+}
+
+func sendTx(tx *types.Transaction) func() (*types.Transaction, error) {
+	return func() (*types.Transaction, error) {
+		return tx, nil
+	}
+}
@@ -0,0 +1,152 @@
+package fs
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Operation identifies a FileSystem method, so faults can be injected on it.
+type Operation string
+
+const (
+	OpStat      Operation = "stat"
+	OpReadDir   Operation = "readdir"
+	OpMkdirAll  Operation = "mkdirall"
+	OpReadFile  Operation = "readfile"
+	OpWriteFile Operation = "writefile"
+	OpRename    Operation = "rename"
+	OpRemove    Operation = "remove"
+	OpRemoveAll Operation = "removeall"
+	OpChmod     Operation = "chmod"
+)
+
+// FaultyFileSystem wraps a FileSystem and makes the operations fail on demand.
+// It is meant to simulate errors, like permission denied or disk full, in
+// tests.
+type FaultyFileSystem struct {
+	FileSystem
+
+	mu     sync.RWMutex
+	faults []fault
+}
+
+type fault struct {
+	op   Operation
+	path string
+	err  error
+}
+
+func NewFaultyFileSystem(fsys FileSystem) *FaultyFileSystem {
+	return &FaultyFileSystem{
+		FileSystem: fsys,
+	}
+}
+
+// InjectFault makes the given operation fail with err on the given path, and
+// everything under it. An empty path matches all the paths.
+func (f *FaultyFileSystem) InjectFault(op Operation, path string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.faults = append(f.faults, fault{
+		op:   op,
+		path: path,
+		err:  err,
+	})
+}
+
+// ClearFaults removes all the injected faults.
+func (f *FaultyFileSystem) ClearFaults() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.faults = nil
+}
+
+func (f *FaultyFileSystem) Stat(name string) (fs.FileInfo, error) {
+	if err := f.faultFor(OpStat, name); err != nil {
+		return nil, err
+	}
+	return f.FileSystem.Stat(name)
+}
+
+func (f *FaultyFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.faultFor(OpReadDir, name); err != nil {
+		return nil, err
+	}
+	return f.FileSystem.ReadDir(name)
+}
+
+func (f *FaultyFileSystem) MkdirAll(path string, perm fs.FileMode) error {
+	if err := f.faultFor(OpMkdirAll, path); err != nil {
+		return err
+	}
+	return f.FileSystem.MkdirAll(path, perm)
+}
+
+func (f *FaultyFileSystem) ReadFile(name string) ([]byte, error) {
+	if err := f.faultFor(OpReadFile, name); err != nil {
+		return nil, err
+	}
+	return f.FileSystem.ReadFile(name)
+}
+
+func (f *FaultyFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := f.faultFor(OpWriteFile, name); err != nil {
+		return err
+	}
+	return f.FileSystem.WriteFile(name, data, perm)
+}
+
+func (f *FaultyFileSystem) Rename(oldPath, newPath string) error {
+	if err := f.faultFor(OpRename, oldPath); err != nil {
+		return err
+	}
+	if err := f.faultFor(OpRename, newPath); err != nil {
+		return err
+	}
+	return f.FileSystem.Rename(oldPath, newPath)
+}
+
+func (f *FaultyFileSystem) Remove(name string) error {
+	if err := f.faultFor(OpRemove, name); err != nil {
+		return err
+	}
+	return f.FileSystem.Remove(name)
+}
+
+func (f *FaultyFileSystem) RemoveAll(path string) error {
+	if err := f.faultFor(OpRemoveAll, path); err != nil {
+		return err
+	}
+	return f.FileSystem.RemoveAll(path)
+}
+
+func (f *FaultyFileSystem) Chmod(name string, mode fs.FileMode) error {
+	if err := f.faultFor(OpChmod, name); err != nil {
+		return err
+	}
+	return f.FileSystem.Chmod(name, mode)
+}
+
+func (f *FaultyFileSystem) faultFor(op Operation, name string) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	path := filepath.Clean(name)
+	for _, ft := range f.faults {
+		if ft.op != op {
+			continue
+		}
+		if ft.path == "" || isSameOrChildPath(path, filepath.Clean(ft.path)) {
+			return &fs.PathError{Op: string(op), Path: name, Err: ft.err}
+		}
+	}
+	return nil
+}
+
+func isSameOrChildPath(path, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+string(filepath.Separator))
+}
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"io/fs"
+	"os"
+)
+
+// FileSystem abstracts the file system operations the Vega file structure
+// relies on. It allows the helpers of this package, and the paths built on
+// top of them, to run against something else than the OS file system, like
+// an in-memory file system for tests.
+type FileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Rename(oldPath, newPath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode fs.FileMode) error
+}
+
+// OSFileSystem is the FileSystem implementation backed by the os package.
+type OSFileSystem struct{}
+
+func (OSFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OSFileSystem) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OSFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (OSFileSystem) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (OSFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFileSystem) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (OSFileSystem) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// OrDefault returns the given file system, or the OS file system if nil.
+func OrDefault(fsys FileSystem) FileSystem {
+	if fsys == nil {
+		return OSFileSystem{}
+	}
+	return fsys
+}
@@ -6,25 +6,100 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+
+	vgrand "code.vegaprotocol.io/shared/libs/rand"
 )
 
 var ErrIsADirectory = errors.New("is a directory")
 
+const (
+	// DefaultDirMode is the mode the directories are created with, unless
+	// specified otherwise.
+	DefaultDirMode fs.FileMode = 0700
+
+	// DefaultFileMode is the mode the files are written with, unless
+	// specified otherwise.
+	DefaultFileMode fs.FileMode = 0600
+)
+
 // EnsureDir will make sure a directory exists or is created at the given path.
 func EnsureDir(path string) error {
-	_, err := os.Stat(path)
+	return EnsureDirIn(OSFileSystem{}, path)
+}
+
+// EnsureDirIn behaves like EnsureDir on the given file system.
+func EnsureDirIn(fsys FileSystem, path string) error {
+	_, err := fsys.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return os.MkdirAll(path, os.ModeDir|0700)
+			return fsys.MkdirAll(path, os.ModeDir|DefaultDirMode)
 		}
 		return err
 	}
 	return nil
 }
 
+// EnsureDirWithMode behaves like EnsureDir, but creates the directory with the
+// given mode. The mode of an existing directory is left untouched.
+func EnsureDirWithMode(path string, mode fs.FileMode) error {
+	return EnsureDirWithModeIn(OSFileSystem{}, path, mode)
+}
+
+// EnsureDirWithModeIn behaves like EnsureDirWithMode on the given file system.
+// Every missing parent is created with the given mode too, while the existing
+// ones are left untouched.
+func EnsureDirWithModeIn(fsys FileSystem, path string, mode fs.FileMode) error {
+	missingDirs, err := missingDirsOf(fsys, path)
+	if err != nil {
+		return err
+	}
+	if len(missingDirs) == 0 {
+		return nil
+	}
+
+	if err := fsys.MkdirAll(path, os.ModeDir|mode.Perm()); err != nil {
+		return err
+	}
+
+	// The mode is set explicitly, as the one given at creation is altered by
+	// the umask.
+	for _, dir := range missingDirs {
+		if err := fsys.Chmod(dir, mode.Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missingDirsOf returns the path, and its parents, that don't exist yet.
+func missingDirsOf(fsys FileSystem, path string) ([]string, error) {
+	missingDirs := []string{}
+	for {
+		_, err := fsys.Stat(path)
+		if err == nil {
+			return missingDirs, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		missingDirs = append(missingDirs, path)
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return missingDirs, nil
+		}
+		path = parent
+	}
+}
+
 // PathExists returns whether a link exists at the given path.
 func PathExists(path string) (bool, error) {
-	_, err := os.Stat(path)
+	return PathExistsIn(OSFileSystem{}, path)
+}
+
+// PathExistsIn behaves like PathExists on the given file system.
+func PathExistsIn(fsys FileSystem, path string) (bool, error) {
+	_, err := fsys.Stat(path)
 	if err == nil {
 		return true, nil
 	}
@@ -37,7 +112,12 @@ func PathExists(path string) (bool, error) {
 // FileExists similar to PathExists, but ensures the path is to a file, not a
 // directory.
 func FileExists(path string) (bool, error) {
-	fileInfo, err := os.Stat(path)
+	return FileExistsIn(OSFileSystem{}, path)
+}
+
+// FileExistsIn behaves like FileExists on the given file system.
+func FileExistsIn(fsys FileSystem, path string) (bool, error) {
+	fileInfo, err := fsys.Stat(path)
 	if err == nil {
 		if fileInfo.IsDir() {
 			return false, ErrIsADirectory
@@ -51,12 +131,12 @@ func FileExists(path string) (bool, error) {
 }
 
 func ReadFile(path string) ([]byte, error) {
-	dir, fileName := filepath.Split(path)
-	if len(dir) == 0 {
-		dir = "."
-	}
+	return ReadFileIn(OSFileSystem{}, path)
+}
 
-	buf, err := fs.ReadFile(os.DirFS(dir), fileName)
+// ReadFileIn behaves like ReadFile on the given file system.
+func ReadFileIn(fsys FileSystem, path string) ([]byte, error) {
+	buf, err := fsys.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't read file: %w", err)
 	}
@@ -65,16 +145,57 @@ func ReadFile(path string) ([]byte, error) {
 }
 
 func WriteFile(path string, content []byte) error {
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("couldn't create file: %w", err)
+	return WriteFileIn(OSFileSystem{}, path, content)
+}
+
+// WriteFileIn behaves like WriteFile on the given file system.
+func WriteFileIn(fsys FileSystem, path string, content []byte) error {
+	if err := fsys.WriteFile(path, content, DefaultFileMode); err != nil {
+		return fmt.Errorf("couldn't write file: %w", err)
 	}
-	defer f.Close()
 
-	_, err = f.Write(content)
-	if err != nil {
+	return nil
+}
+
+// WriteFileAtomically behaves like WriteFile, but writes the content to a
+// temporary file next to the target, and renames it into place. A reader, or a
+// crash in the middle of the write, never sees a partially written file.
+func WriteFileAtomically(path string, content []byte) error {
+	return WriteFileAtomicallyIn(OSFileSystem{}, path, content)
+}
+
+// WriteFileAtomicallyIn behaves like WriteFileAtomically on the given file
+// system.
+func WriteFileAtomicallyIn(fsys FileSystem, path string, content []byte) error {
+	tmpPath := path + "." + vgrand.RandomStr(8) + ".tmp"
+
+	if err := fsys.WriteFile(tmpPath, content, DefaultFileMode); err != nil {
+		return fmt.Errorf("couldn't write file: %w", err)
+	}
+
+	if err := fsys.Rename(tmpPath, path); err != nil {
+		_ = fsys.Remove(tmpPath)
+		return fmt.Errorf("couldn't move the file into place: %w", err)
+	}
+
+	return nil
+}
+
+// WriteFileWithMode behaves like WriteFile, but sets the given mode on the
+// file, whether it already exists or not.
+func WriteFileWithMode(path string, content []byte, mode fs.FileMode) error {
+	return WriteFileWithModeIn(OSFileSystem{}, path, content, mode)
+}
+
+// WriteFileWithModeIn behaves like WriteFileWithMode on the given file system.
+func WriteFileWithModeIn(fsys FileSystem, path string, content []byte, mode fs.FileMode) error {
+	if err := fsys.WriteFile(path, content, mode.Perm()); err != nil {
 		return fmt.Errorf("couldn't write file: %w", err)
 	}
 
+	if err := fsys.Chmod(path, mode.Perm()); err != nil {
+		return fmt.Errorf("couldn't set the file mode: %w", err)
+	}
+
 	return nil
 }
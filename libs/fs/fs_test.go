@@ -1,7 +1,9 @@
 package fs_test
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
 	vgfs "code.vegaprotocol.io/shared/libs/fs"
@@ -23,6 +25,12 @@ func TestFileSystemHelpers(t *testing.T) {
 	t.Run("Rewriting file succeeds", testRewritingFileSucceeds)
 	t.Run("Reading existing file succeeds", testReadingExistingFileSucceeds)
 	t.Run("Reading non-existing file fails", testReadingNonExistingFileFails)
+	t.Run("Ensuring presence of directories with a mode succeeds", testEnsuringPresenceOfDirectoriesWithModeSucceeds)
+	t.Run("Ensuring presence of existing directories with a mode keeps their mode", testEnsuringPresenceOfExistingDirectoriesWithModeKeepsTheirMode)
+	t.Run("Ensuring presence of nested directories with a mode sets the mode of the parents", testEnsuringPresenceOfNestedDirectoriesWithModeSetsTheModeOfTheParents)
+	t.Run("Writing file with a mode succeeds", testWritingFileWithModeSucceeds)
+	t.Run("Writing file atomically succeeds", testWritingFileAtomicallySucceeds)
+	t.Run("Writing file atomically keeps the previous content on failure", testWritingFileAtomicallyKeepsThePreviousContentOnFailure)
 }
 
 func testEnsuringPresenceOfNonExistingDirectoriesSucceeds(t *testing.T) {
@@ -158,3 +166,83 @@ func testReadingNonExistingFileFails(t *testing.T) {
 	require.Error(t, err)
 	assert.Empty(t, readData)
 }
+
+func testEnsuringPresenceOfDirectoriesWithModeSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	err := vgfs.EnsureDirWithMode(path, 0750)
+	require.NoError(t, err)
+	vgtest.AssertDirAccessWithMode(t, path, 0750)
+}
+
+func testEnsuringPresenceOfExistingDirectoriesWithModeKeepsTheirMode(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	err := vgfs.EnsureDir(path)
+	require.NoError(t, err)
+
+	err = vgfs.EnsureDirWithMode(path, 0750)
+	require.NoError(t, err)
+	vgtest.AssertDirAccess(t, path)
+}
+
+func testEnsuringPresenceOfNestedDirectoriesWithModeSetsTheModeOfTheParents(t *testing.T) {
+	root := vgtest.RandomPath()
+	defer os.RemoveAll(root)
+
+	err := vgfs.EnsureDir(root)
+	require.NoError(t, err)
+
+	parent := filepath.Join(root, "parent")
+	path := filepath.Join(parent, "child")
+	err = vgfs.EnsureDirWithMode(path, 0750)
+	require.NoError(t, err)
+	vgtest.AssertDirAccess(t, root)
+	vgtest.AssertDirAccessWithMode(t, parent, 0750)
+	vgtest.AssertDirAccessWithMode(t, path, 0750)
+}
+
+func testWritingFileWithModeSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	err := vgfs.WriteFile(path, []byte("Hello, World!"))
+	require.NoError(t, err)
+	vgtest.AssertFileAccess(t, path)
+
+	err = vgfs.WriteFileWithMode(path, []byte("Hello, World!"), 0644)
+	require.NoError(t, err)
+	vgtest.AssertFileAccessWithMode(t, path, 0644)
+}
+
+func testWritingFileAtomicallySucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	require.NoError(t, vgfs.WriteFileAtomically(path, []byte("Hello, World!")))
+	vgtest.AssertFileAccess(t, path)
+	require.NoError(t, vgfs.WriteFileAtomically(path, []byte("Bonjour, le Monde!")))
+
+	readData, err := vgfs.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("Bonjour, le Monde!"), readData)
+}
+
+func testWritingFileAtomicallyKeepsThePreviousContentOnFailure(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/vega"))
+	require.NoError(t, vgfs.WriteFileAtomicallyIn(fsys, "/vega/file.json", []byte("previous")))
+	fsys.InjectFault(vgfs.OpRename, "", errors.New("disk full"))
+
+	err := vgfs.WriteFileAtomicallyIn(fsys, "/vega/file.json", []byte("next"))
+
+	require.Error(t, err)
+	readData, err := vgfs.ReadFileIn(fsys, "/vega/file.json")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("previous"), readData)
+	entries, err := fsys.ReadDir("/vega")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
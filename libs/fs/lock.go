@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrLockTimeout = errors.New("timed out waiting for the lock")
+
+	// errLockHeld is returned by the platform specific implementations when
+	// the lock is currently held by someone else.
+	errLockHeld = errors.New("lock is held")
+)
+
+// lockRetryInterval is the time to wait between two attempts at acquiring a
+// lock.
+const lockRetryInterval = 10 * time.Millisecond
+
+// Lock is an advisory lock held on a lock file. It only coordinates the
+// processes that use this API. It doesn't prevent anyone from writing to the
+// protected files.
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// LockShared acquires a shared lock on the file at the given path, waiting up
+// to the timeout for any exclusive lock to be released. Multiple processes
+// can hold a shared lock at the same time. The lock file and its parent
+// directories are created if needed.
+func LockShared(path string, timeout time.Duration) (*Lock, error) {
+	return acquireLock(path, false, timeout)
+}
+
+// LockExclusive acquires an exclusive lock on the file at the given path,
+// waiting up to the timeout for any other lock to be released. The lock file
+// and its parent directories are created if needed.
+func LockExclusive(path string, timeout time.Duration) (*Lock, error) {
+	return acquireLock(path, true, timeout)
+}
+
+// Path returns the path of the lock file.
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// Unlock releases the lock. The lock file is left in place, as removing it
+// would race with the processes waiting on it.
+func (l *Lock) Unlock() error {
+	if l.file == nil {
+		return nil
+	}
+
+	unlockErr := unlockFile(l.file)
+	closeErr := l.file.Close()
+	l.file = nil
+
+	if unlockErr != nil {
+		return fmt.Errorf("couldn't unlock file: %w", unlockErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("couldn't close lock file: %w", closeErr)
+	}
+	return nil
+}
+
+func acquireLock(path string, exclusive bool, timeout time.Duration) (*Lock, error) {
+	if err := EnsureDir(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("couldn't create directories for lock file: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLockFile(f, exclusive)
+		if err == nil {
+			return &Lock{
+				path: path,
+				file: f,
+			}, nil
+		}
+
+		if !errors.Is(err, errLockHeld) {
+			_ = f.Close()
+			return nil, fmt.Errorf("couldn't lock file: %w", err)
+		}
+
+		if !time.Now().Before(deadline) {
+			_ = f.Close()
+			return nil, ErrLockTimeout
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,109 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLocks(t *testing.T) {
+	t.Run("Acquiring a lock creates the lock file", testAcquiringLockCreatesLockFile)
+	t.Run("Acquiring an exclusive lock twice times out", testAcquiringExclusiveLockTwiceTimesOut)
+	t.Run("Acquiring a shared lock while exclusively locked times out", testAcquiringSharedLockWhileExclusivelyLockedTimesOut)
+	t.Run("Acquiring multiple shared locks succeeds", testAcquiringMultipleSharedLocksSucceeds)
+	t.Run("Acquiring an exclusive lock after release succeeds", testAcquiringExclusiveLockAfterReleaseSucceeds)
+	t.Run("Waiting for a lock to be released succeeds", testWaitingForLockToBeReleasedSucceeds)
+}
+
+func testAcquiringLockCreatesLockFile(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	path := filepath.Join(home, "sub", "file.lock")
+
+	lock, err := vgfs.LockExclusive(path, 0)
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	assert.Equal(t, path, lock.Path())
+	vgtest.AssertFileAccess(t, path)
+}
+
+func testAcquiringExclusiveLockTwiceTimesOut(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	lock, err := vgfs.LockExclusive(path, 0)
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	secondLock, err := vgfs.LockExclusive(path, 50*time.Millisecond)
+	require.ErrorIs(t, err, vgfs.ErrLockTimeout)
+	assert.Nil(t, secondLock)
+}
+
+func testAcquiringSharedLockWhileExclusivelyLockedTimesOut(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	lock, err := vgfs.LockExclusive(path, 0)
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	sharedLock, err := vgfs.LockShared(path, 50*time.Millisecond)
+	require.ErrorIs(t, err, vgfs.ErrLockTimeout)
+	assert.Nil(t, sharedLock)
+}
+
+func testAcquiringMultipleSharedLocksSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	lock1, err := vgfs.LockShared(path, 0)
+	require.NoError(t, err)
+	defer lock1.Unlock()
+
+	lock2, err := vgfs.LockShared(path, 0)
+	require.NoError(t, err)
+	defer lock2.Unlock()
+
+	exclusiveLock, err := vgfs.LockExclusive(path, 50*time.Millisecond)
+	require.ErrorIs(t, err, vgfs.ErrLockTimeout)
+	assert.Nil(t, exclusiveLock)
+}
+
+func testAcquiringExclusiveLockAfterReleaseSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	lock, err := vgfs.LockExclusive(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, lock.Unlock())
+
+	secondLock, err := vgfs.LockExclusive(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, secondLock.Unlock())
+}
+
+func testWaitingForLockToBeReleasedSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	lock, err := vgfs.LockExclusive(path, 0)
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = lock.Unlock()
+	}()
+
+	secondLock, err := vgfs.LockExclusive(path, 5*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, secondLock.Unlock())
+}
@@ -0,0 +1,315 @@
+package fs
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	errNotADirectory     = syscall.ENOTDIR
+	errDirectoryNotEmpty = syscall.ENOTEMPTY
+)
+
+// MemoryFileSystem is an in-memory FileSystem. It is meant to be used in tests
+// that should not touch the disk. Relative paths are resolved against the
+// root of the file system.
+type MemoryFileSystem struct {
+	mu    sync.RWMutex
+	nodes map[string]*memoryNode
+}
+
+type memoryNode struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func NewMemoryFileSystem() *MemoryFileSystem {
+	return &MemoryFileSystem{
+		nodes: map[string]*memoryNode{},
+	}
+}
+
+func (m *MemoryFileSystem) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	path := cleanMemoryPath(name)
+	node, err := m.lookup("stat", name, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newMemoryFileInfo(path, node), nil
+}
+
+func (m *MemoryFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	path := cleanMemoryPath(name)
+	node, err := m.lookup("readdir", name, path)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotADirectory}
+	}
+
+	entries := []fs.DirEntry{}
+	for childPath, child := range m.nodes {
+		if childPath != path && filepath.Dir(childPath) == path {
+			entries = append(entries, fs.FileInfoToDirEntry(newMemoryFileInfo(childPath, child)))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+func (m *MemoryFileSystem) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := cleanMemoryPath(name)
+	missing := []string{}
+	for current := path; !isMemoryRoot(current); current = filepath.Dir(current) {
+		node, ok := m.nodes[current]
+		if ok {
+			if !node.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: name, Err: errNotADirectory}
+			}
+			break
+		}
+		missing = append(missing, current)
+	}
+
+	for _, dir := range missing {
+		m.nodes[dir] = &memoryNode{
+			mode:    fs.ModeDir | perm.Perm(),
+			modTime: time.Now(),
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryFileSystem) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	path := cleanMemoryPath(name)
+	node, err := m.lookup("open", name, path)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: ErrIsADirectory}
+	}
+
+	buf := make([]byte, len(node.data))
+	copy(buf, node.data)
+	return buf, nil
+}
+
+func (m *MemoryFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := cleanMemoryPath(name)
+	if err := m.ensureParent("open", name, path); err != nil {
+		return err
+	}
+
+	buf := make([]byte, len(data))
+	copy(buf, data)
+
+	node, ok := m.nodes[path]
+	if ok {
+		if node.mode.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: ErrIsADirectory}
+		}
+		// As with the OS, the permissions of an existing file are kept.
+		node.data = buf
+		node.modTime = time.Now()
+		return nil
+	}
+
+	m.nodes[path] = &memoryNode{
+		data:    buf,
+		mode:    perm.Perm(),
+		modTime: time.Now(),
+	}
+	return nil
+}
+
+func (m *MemoryFileSystem) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldPath := cleanMemoryPath(oldName)
+	newPath := cleanMemoryPath(newName)
+
+	node, err := m.lookup("rename", oldName, oldPath)
+	if err != nil {
+		return err
+	}
+	if err := m.ensureParent("rename", newName, newPath); err != nil {
+		return err
+	}
+	if existing, ok := m.nodes[newPath]; ok && existing.mode.IsDir() && !m.isEmptyDir(newPath) {
+		return &fs.PathError{Op: "rename", Path: newName, Err: errDirectoryNotEmpty}
+	}
+
+	delete(m.nodes, oldPath)
+	m.nodes[newPath] = node
+
+	if node.mode.IsDir() {
+		prefix := oldPath + string(filepath.Separator)
+		moved := map[string]*memoryNode{}
+		for childPath, child := range m.nodes {
+			if strings.HasPrefix(childPath, prefix) {
+				delete(m.nodes, childPath)
+				moved[filepath.Join(newPath, strings.TrimPrefix(childPath, prefix))] = child
+			}
+		}
+		for childPath, child := range moved {
+			m.nodes[childPath] = child
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryFileSystem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := cleanMemoryPath(name)
+	node, err := m.lookup("remove", name, path)
+	if err != nil {
+		return err
+	}
+	if node.mode.IsDir() && !m.isEmptyDir(path) {
+		return &fs.PathError{Op: "remove", Path: name, Err: errDirectoryNotEmpty}
+	}
+
+	delete(m.nodes, path)
+	return nil
+}
+
+func (m *MemoryFileSystem) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := cleanMemoryPath(name)
+	prefix := path + string(filepath.Separator)
+	for childPath := range m.nodes {
+		if childPath == path || strings.HasPrefix(childPath, prefix) {
+			delete(m.nodes, childPath)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryFileSystem) Chmod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := cleanMemoryPath(name)
+	node, err := m.lookup("chmod", name, path)
+	if err != nil {
+		return err
+	}
+
+	node.mode = (node.mode &^ fs.ModePerm) | mode.Perm()
+	return nil
+}
+
+func (m *MemoryFileSystem) lookup(op, name, path string) (*memoryNode, error) {
+	if isMemoryRoot(path) {
+		return &memoryNode{mode: fs.ModeDir | 0700}, nil
+	}
+
+	node, ok := m.nodes[path]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return node, nil
+}
+
+func (m *MemoryFileSystem) ensureParent(op, name, path string) error {
+	parent, err := m.lookup(op, name, filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if !parent.mode.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: errNotADirectory}
+	}
+	return nil
+}
+
+func (m *MemoryFileSystem) isEmptyDir(path string) bool {
+	for childPath := range m.nodes {
+		if childPath != path && filepath.Dir(childPath) == path {
+			return false
+		}
+	}
+	return true
+}
+
+func cleanMemoryPath(name string) string {
+	path := filepath.Clean(name)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(string(filepath.Separator), path)
+	}
+	return path
+}
+
+func isMemoryRoot(path string) bool {
+	return filepath.Dir(path) == path
+}
+
+type memoryFileInfo struct {
+	name string
+	node memoryNode
+}
+
+func newMemoryFileInfo(path string, node *memoryNode) *memoryFileInfo {
+	return &memoryFileInfo{
+		name: filepath.Base(path),
+		node: *node,
+	}
+}
+
+func (i *memoryFileInfo) Name() string {
+	return i.name
+}
+
+func (i *memoryFileInfo) Size() int64 {
+	return int64(len(i.node.data))
+}
+
+func (i *memoryFileInfo) Mode() fs.FileMode {
+	return i.node.mode
+}
+
+func (i *memoryFileInfo) ModTime() time.Time {
+	return i.node.modTime
+}
+
+func (i *memoryFileInfo) IsDir() bool {
+	return i.node.mode.IsDir()
+}
+
+func (i *memoryFileInfo) Sys() interface{} {
+	return nil
+}
@@ -0,0 +1,206 @@
+package fs_test
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryFileSystem(t *testing.T) {
+	t.Run("Ensuring presence of non-existing directories succeeds", testMemoryEnsuringPresenceOfNonExistingDirectoriesSucceeds)
+	t.Run("Writing and reading file succeeds", testMemoryWritingAndReadingFileSucceeds)
+	t.Run("Writing file without parent directory fails", testMemoryWritingFileWithoutParentDirectoryFails)
+	t.Run("Reading non-existing file fails", testMemoryReadingNonExistingFileFails)
+	t.Run("Verify file existence on a directory fails", testMemoryVerifyingFileExistenceOnDirectoryFails)
+	t.Run("Listing directory succeeds", testMemoryListingDirectorySucceeds)
+	t.Run("Renaming directory moves its content", testMemoryRenamingDirectoryMovesItsContent)
+	t.Run("Removing non-empty directory fails", testMemoryRemovingNonEmptyDirectoryFails)
+	t.Run("Removing all succeeds", testMemoryRemovingAllSucceeds)
+	t.Run("Changing permissions succeeds", testMemoryChangingPermissionsSucceeds)
+}
+
+func testMemoryEnsuringPresenceOfNonExistingDirectoriesSucceeds(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+
+	err := vgfs.EnsureDirIn(fsys, "/home/vega/config")
+	require.NoError(t, err)
+
+	stats, err := fsys.Stat("/home/vega")
+	require.NoError(t, err)
+	assert.True(t, stats.IsDir())
+	assert.Equal(t, fs.FileMode(0700), stats.Mode().Perm())
+
+	exists, err := vgfs.PathExistsIn(fsys, "/home/vega/config")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func testMemoryWritingAndReadingFileSucceeds(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	data := []byte("Hello, World!")
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/home"))
+
+	err := vgfs.WriteFileIn(fsys, "/home/file.txt", data)
+	require.NoError(t, err)
+
+	stats, err := fsys.Stat("/home/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), stats.Mode().Perm())
+	assert.Equal(t, int64(len(data)), stats.Size())
+
+	readData, err := vgfs.ReadFileIn(fsys, "/home/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, data, readData)
+
+	exists, err := vgfs.FileExistsIn(fsys, "/home/file.txt")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func testMemoryWritingFileWithoutParentDirectoryFails(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+
+	err := vgfs.WriteFileIn(fsys, "/home/file.txt", []byte("Hello, World!"))
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func testMemoryReadingNonExistingFileFails(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+
+	readData, err := vgfs.ReadFileIn(fsys, "/home/file.txt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Empty(t, readData)
+
+	exists, err := vgfs.FileExistsIn(fsys, "/home/file.txt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testMemoryVerifyingFileExistenceOnDirectoryFails(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/home"))
+
+	exists, err := vgfs.FileExistsIn(fsys, "/home")
+	require.ErrorIs(t, err, vgfs.ErrIsADirectory)
+	assert.False(t, exists)
+}
+
+func testMemoryListingDirectorySucceeds(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/home/sub/deeper"))
+	require.NoError(t, vgfs.WriteFileIn(fsys, "/home/b.txt", []byte("b")))
+	require.NoError(t, vgfs.WriteFileIn(fsys, "/home/a.txt", []byte("a")))
+
+	entries, err := fsys.ReadDir("/home")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "a.txt", entries[0].Name())
+	assert.Equal(t, "b.txt", entries[1].Name())
+	assert.Equal(t, "sub", entries[2].Name())
+	assert.True(t, entries[2].IsDir())
+}
+
+func testMemoryRenamingDirectoryMovesItsContent(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/home/old/sub"))
+	require.NoError(t, vgfs.WriteFileIn(fsys, "/home/old/sub/file.txt", []byte("content")))
+
+	err := fsys.Rename("/home/old", "/home/new")
+	require.NoError(t, err)
+
+	exists, err := vgfs.PathExistsIn(fsys, "/home/old")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	readData, err := vgfs.ReadFileIn(fsys, "/home/new/sub/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("content"), readData)
+}
+
+func testMemoryRemovingNonEmptyDirectoryFails(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/home/sub"))
+
+	err := fsys.Remove("/home")
+	require.ErrorIs(t, err, syscall.ENOTEMPTY)
+
+	require.NoError(t, fsys.Remove("/home/sub"))
+	require.NoError(t, fsys.Remove("/home"))
+}
+
+func testMemoryRemovingAllSucceeds(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/home/sub"))
+	require.NoError(t, vgfs.WriteFileIn(fsys, "/home/sub/file.txt", []byte("content")))
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/homework"))
+
+	err := fsys.RemoveAll("/home")
+	require.NoError(t, err)
+
+	exists, err := vgfs.PathExistsIn(fsys, "/home/sub/file.txt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = vgfs.PathExistsIn(fsys, "/homework")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func testMemoryChangingPermissionsSucceeds(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	require.NoError(t, vgfs.EnsureDirIn(fsys, "/home"))
+
+	err := fsys.Chmod("/home", 0750)
+	require.NoError(t, err)
+
+	stats, err := fsys.Stat("/home")
+	require.NoError(t, err)
+	assert.True(t, stats.IsDir())
+	assert.Equal(t, fs.FileMode(0750), stats.Mode().Perm())
+}
+
+func TestFaultyFileSystem(t *testing.T) {
+	t.Run("Injected fault is returned on matching path", testFaultyInjectedFaultIsReturnedOnMatchingPath)
+	t.Run("Injected fault is not returned on other paths", testFaultyInjectedFaultIsNotReturnedOnOtherPaths)
+	t.Run("Clearing faults restores the operations", testFaultyClearingFaultsRestoresOperations)
+}
+
+func testFaultyInjectedFaultIsReturnedOnMatchingPath(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	fsys.InjectFault(vgfs.OpMkdirAll, "/home", fs.ErrPermission)
+	fsys.InjectFault(vgfs.OpWriteFile, "", syscall.ENOSPC)
+
+	err := vgfs.EnsureDirIn(fsys, "/home/vega")
+	require.ErrorIs(t, err, fs.ErrPermission)
+	assert.True(t, os.IsPermission(err))
+
+	err = vgfs.WriteFileIn(fsys, "/file.txt", []byte("content"))
+	require.ErrorIs(t, err, syscall.ENOSPC)
+}
+
+func testFaultyInjectedFaultIsNotReturnedOnOtherPaths(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	fsys.InjectFault(vgfs.OpMkdirAll, "/home", fs.ErrPermission)
+
+	err := vgfs.EnsureDirIn(fsys, "/homework")
+	require.NoError(t, err)
+}
+
+func testFaultyClearingFaultsRestoresOperations(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	fsys.InjectFault(vgfs.OpMkdirAll, "", fs.ErrPermission)
+
+	err := vgfs.EnsureDirIn(fsys, "/home")
+	require.Error(t, err)
+
+	fsys.ClearFaults()
+
+	err = vgfs.EnsureDirIn(fsys, "/home")
+	require.NoError(t, err)
+}
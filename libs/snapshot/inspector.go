@@ -0,0 +1,295 @@
+package snapshot
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// The node stores its snapshots in an IAVL tree, persisted in a LevelDB
+// database. Every snapshot is a version of the tree. In the database, the root
+// of each version is stored under the key `r<version>`, with the version as a
+// big-endian int64, and points to the hash of the root node. Each node is
+// stored under the key `n<hash>`.
+
+const (
+	rootKeyPrefix = 'r'
+	nodeKeyPrefix = 'n'
+)
+
+var ErrVersionNotFound = errors.New("snapshot version not found")
+
+// HeightDecoder extracts the block height from a snapshot payload. It returns
+// false if the payload doesn't hold the height. The payloads are encoded by
+// the node, so the decoding is left to the caller.
+type HeightDecoder func(key, value []byte) (uint64, bool)
+
+type Options struct {
+	// Prefix is the prefix of the keys of the tree in the database, if the
+	// tree shares the database with other data.
+	Prefix []byte
+	// HeightDecoder extracts the block height of every version. If not set,
+	// the heights are not reported.
+	HeightDecoder HeightDecoder
+}
+
+// Version is a snapshot stored in the database.
+type Version struct {
+	Version int64 `json:"version"`
+	// Hash is the hex-encoded hash of the root of the tree at this version.
+	Hash string `json:"hash"`
+	// Height is the block height of the snapshot. It is 0 when unknown.
+	Height uint64 `json:"height,omitempty"`
+}
+
+// Entry is a raw key/value payload of a snapshot.
+type Entry struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Inspector reads the snapshots stored in a LevelDB database. The database is
+// opened in read-only mode: nothing is ever written to it.
+type Inspector struct {
+	db            *leveldb.DB
+	prefix        []byte
+	heightDecoder HeightDecoder
+}
+
+// Open opens the LevelDB database at the given path, in read-only mode. It
+// fails if the database doesn't exist, or is locked by a running node.
+func Open(dbPath string, options Options) (*Inspector, error) {
+	db, err := leveldb.OpenFile(dbPath, &opt.Options{
+		ReadOnly:       true,
+		ErrorIfMissing: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open the snapshot database %s: %w", dbPath, err)
+	}
+
+	return &Inspector{
+		db:            db,
+		prefix:        options.Prefix,
+		heightDecoder: options.HeightDecoder,
+	}, nil
+}
+
+// OpenFromPaths opens the snapshot database of the node, located at
+// paths.SnapshotDBStateFile.
+func OpenFromPaths(vegaPaths paths.Paths, options Options) (*Inspector, error) {
+	return Open(vegaPaths.StatePathFor(paths.SnapshotDBStateFile), options)
+}
+
+func (i *Inspector) Close() error {
+	return i.db.Close()
+}
+
+// Versions lists the snapshots stored in the database, sorted by version.
+func (i *Inspector) Versions() ([]Version, error) {
+	rootPrefix := i.key(rootKeyPrefix)
+
+	iter := i.db.NewIterator(util.BytesPrefix(rootPrefix), nil)
+	defer iter.Release()
+
+	versions := []Version{}
+	for iter.Next() {
+		rawVersion := iter.Key()[len(rootPrefix):]
+		if len(rawVersion) != 8 {
+			return nil, fmt.Errorf("invalid root key %x", iter.Key())
+		}
+
+		rootHash := copyBytes(iter.Value())
+		version := Version{
+			Version: int64(binary.BigEndian.Uint64(rawVersion)),
+			Hash:    hex.EncodeToString(rootHash),
+		}
+
+		if i.heightDecoder != nil {
+			height, err := i.heightOf(rootHash)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't get the height of version %d: %w", version.Version, err)
+			}
+			version.Height = height
+		}
+
+		versions = append(versions, version)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("couldn't iterate over the snapshot versions: %w", err)
+	}
+
+	sort.Slice(versions, func(a, b int) bool {
+		return versions[a].Version < versions[b].Version
+	})
+
+	return versions, nil
+}
+
+// Export returns the raw key/value payloads of the given version, sorted by
+// key. It returns ErrVersionNotFound if the version is not in the database.
+func (i *Inspector) Export(version int64) ([]Entry, error) {
+	rootHash, err := i.rootHashOf(version)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	if err := i.walk(rootHash, func(entry Entry) bool {
+		entries = append(entries, entry)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("couldn't export version %d: %w", version, err)
+	}
+
+	return entries, nil
+}
+
+func (i *Inspector) rootHashOf(version int64) ([]byte, error) {
+	rawVersion := make([]byte, 8)
+	binary.BigEndian.PutUint64(rawVersion, uint64(version))
+
+	rootHash, err := i.db.Get(append(i.key(rootKeyPrefix), rawVersion...), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, fmt.Errorf("couldn't read the root of version %d: %w", version, err)
+	}
+
+	return rootHash, nil
+}
+
+func (i *Inspector) heightOf(rootHash []byte) (uint64, error) {
+	var (
+		height uint64
+		found  bool
+	)
+	err := i.walk(rootHash, func(entry Entry) bool {
+		height, found = i.heightDecoder(entry.Key, entry.Value)
+		return !found
+	})
+	return height, err
+}
+
+// walk visits the leaves of the tree with the given root hash, in the order of
+// their keys, until visit returns false. An empty root hash is an empty tree.
+func (i *Inspector) walk(rootHash []byte, visit func(Entry) bool) error {
+	if len(rootHash) == 0 {
+		return nil
+	}
+	_, err := i.walkNode(rootHash, visit)
+	return err
+}
+
+func (i *Inspector) walkNode(hash []byte, visit func(Entry) bool) (bool, error) {
+	buf, err := i.db.Get(append(i.key(nodeKeyPrefix), hash...), nil)
+	if err != nil {
+		return false, fmt.Errorf("couldn't read node %x: %w", hash, err)
+	}
+
+	n, err := decodeNode(buf)
+	if err != nil {
+		return false, fmt.Errorf("couldn't decode node %x: %w", hash, err)
+	}
+
+	if n.isLeaf() {
+		return visit(Entry{Key: n.key, Value: n.value}), nil
+	}
+
+	if goOn, err := i.walkNode(n.leftHash, visit); err != nil || !goOn {
+		return goOn, err
+	}
+	return i.walkNode(n.rightHash, visit)
+}
+
+func (i *Inspector) key(kind byte) []byte {
+	key := make([]byte, 0, len(i.prefix)+1)
+	key = append(key, i.prefix...)
+	return append(key, kind)
+}
+
+// node is a node of the IAVL tree, as persisted in the database.
+type node struct {
+	height    int64
+	size      int64
+	version   int64
+	key       []byte
+	value     []byte
+	leftHash  []byte
+	rightHash []byte
+}
+
+func (n *node) isLeaf() bool {
+	return n.height == 0
+}
+
+// decodeNode decodes a node encoded as: the height, the size and the version
+// as signed varints, then the key, and either the value for a leaf, or the
+// hashes of the left and right children otherwise, as length-prefixed bytes.
+func decodeNode(buf []byte) (*node, error) {
+	r := &nodeReader{buf: buf}
+
+	n := &node{
+		height:  r.varint(),
+		size:    r.varint(),
+		version: r.varint(),
+		key:     r.bytes(),
+	}
+	if n.isLeaf() {
+		n.value = r.bytes()
+	} else {
+		n.leftHash = r.bytes()
+		n.rightHash = r.bytes()
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return n, nil
+}
+
+type nodeReader struct {
+	buf []byte
+	err error
+}
+
+func (r *nodeReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = errors.New("invalid varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *nodeReader) bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	size, n := binary.Uvarint(r.buf)
+	if n <= 0 || uint64(len(r.buf)-n) < size {
+		r.err = errors.New("invalid length-prefixed bytes")
+		return nil
+	}
+	v := copyBytes(r.buf[n : n+int(size)])
+	r.buf = r.buf[n+int(size):]
+	return v
+}
+
+// copyBytes copies the bytes, as the ones returned by LevelDB must not be
+// retained.
+func copyBytes(b []byte) []byte {
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,228 @@
+package snapshot_test
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	vgsnapshot "code.vegaprotocol.io/shared/libs/snapshot"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func TestInspector(t *testing.T) {
+	t.Run("Opening a missing database fails", testOpeningMissingDatabaseFails)
+	t.Run("Listing versions succeeds", testListingVersionsSucceeds)
+	t.Run("Listing versions with heights succeeds", testListingVersionsWithHeightsSucceeds)
+	t.Run("Exporting a version succeeds", testExportingVersionSucceeds)
+	t.Run("Exporting an unknown version fails", testExportingUnknownVersionFails)
+	t.Run("Inspecting does not write to the database", testInspectingDoesNotWriteToTheDatabase)
+}
+
+func testOpeningMissingDatabaseFails(t *testing.T) {
+	vegaHome := vgtest.RandomPath()
+	defer os.RemoveAll(vegaHome)
+
+	_, err := vgsnapshot.OpenFromPaths(&paths.CustomPaths{CustomHome: vegaHome}, vgsnapshot.Options{})
+
+	require.Error(t, err)
+	exists, err := os.Stat(filepath.Join(vegaHome, "state"))
+	assert.Nil(t, exists)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func testListingVersionsSucceeds(t *testing.T) {
+	vegaPaths, hashes := newSnapshotDB(t)
+	defer os.RemoveAll(vegaPaths.CustomHome)
+
+	inspector, err := vgsnapshot.OpenFromPaths(vegaPaths, vgsnapshot.Options{})
+	require.NoError(t, err)
+	defer inspector.Close()
+
+	versions, err := inspector.Versions()
+
+	require.NoError(t, err)
+	assert.Equal(t, []vgsnapshot.Version{
+		{Version: 1, Hash: hashes[1]},
+		{Version: 2, Hash: hashes[2]},
+		{Version: 3, Hash: ""},
+	}, versions)
+}
+
+func testListingVersionsWithHeightsSucceeds(t *testing.T) {
+	vegaPaths, _ := newSnapshotDB(t)
+	defer os.RemoveAll(vegaPaths.CustomHome)
+
+	inspector, err := vgsnapshot.OpenFromPaths(vegaPaths, vgsnapshot.Options{
+		HeightDecoder: func(key, value []byte) (uint64, bool) {
+			if string(key) != "app" {
+				return 0, false
+			}
+			height, err := strconv.ParseUint(strings.TrimPrefix(string(value), "height="), 10, 64)
+			return height, err == nil
+		},
+	})
+	require.NoError(t, err)
+	defer inspector.Close()
+
+	versions, err := inspector.Versions()
+
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, uint64(100), versions[0].Height)
+	assert.Equal(t, uint64(200), versions[1].Height)
+	assert.Equal(t, uint64(0), versions[2].Height)
+}
+
+func testExportingVersionSucceeds(t *testing.T) {
+	vegaPaths, _ := newSnapshotDB(t)
+	defer os.RemoveAll(vegaPaths.CustomHome)
+
+	inspector, err := vgsnapshot.OpenFromPaths(vegaPaths, vgsnapshot.Options{})
+	require.NoError(t, err)
+	defer inspector.Close()
+
+	entries, err := inspector.Export(2)
+	require.NoError(t, err)
+	assert.Equal(t, []vgsnapshot.Entry{
+		{Key: []byte("app"), Value: []byte("height=200")},
+		{Key: []byte("markets"), Value: []byte("market-1")},
+	}, entries)
+
+	entries, err = inspector.Export(3)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func testExportingUnknownVersionFails(t *testing.T) {
+	vegaPaths, _ := newSnapshotDB(t)
+	defer os.RemoveAll(vegaPaths.CustomHome)
+
+	inspector, err := vgsnapshot.OpenFromPaths(vegaPaths, vgsnapshot.Options{})
+	require.NoError(t, err)
+	defer inspector.Close()
+
+	_, err = inspector.Export(42)
+
+	assert.ErrorIs(t, err, vgsnapshot.ErrVersionNotFound)
+}
+
+func testInspectingDoesNotWriteToTheDatabase(t *testing.T) {
+	vegaPaths, _ := newSnapshotDB(t)
+	defer os.RemoveAll(vegaPaths.CustomHome)
+	dbPath := vegaPaths.StatePathFor(paths.SnapshotDBStateFile)
+	before := dirState(t, dbPath)
+
+	inspector, err := vgsnapshot.OpenFromPaths(vegaPaths, vgsnapshot.Options{})
+	require.NoError(t, err)
+	_, err = inspector.Versions()
+	require.NoError(t, err)
+	_, err = inspector.Export(2)
+	require.NoError(t, err)
+	require.NoError(t, inspector.Close())
+
+	assert.Equal(t, before, dirState(t, dbPath))
+}
+
+// newSnapshotDB creates a snapshot database with 3 versions: the first one
+// has a single leaf, the second one has two leaves, and the last one is empty.
+func newSnapshotDB(t *testing.T) (*paths.CustomPaths, map[int64]string) {
+	t.Helper()
+
+	vegaPaths := &paths.CustomPaths{CustomHome: vgtest.RandomPath()}
+	dbPath, err := vegaPaths.CreateStateDirFor(paths.SnapshotDBStateFile)
+	require.NoError(t, err)
+
+	db, err := leveldb.OpenFile(dbPath, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	hashes := map[int64]string{}
+
+	leafV1 := putNode(t, db, encodeLeaf(1, "app", "height=100"))
+	putRoot(t, db, 1, leafV1)
+	hashes[1] = hex.EncodeToString(leafV1)
+
+	appLeaf := putNode(t, db, encodeLeaf(2, "app", "height=200"))
+	marketsLeaf := putNode(t, db, encodeLeaf(2, "markets", "market-1"))
+	rootV2 := putNode(t, db, encodeInner(2, "markets", appLeaf, marketsLeaf))
+	putRoot(t, db, 2, rootV2)
+	hashes[2] = hex.EncodeToString(rootV2)
+
+	putRoot(t, db, 3, nil)
+
+	return vegaPaths, hashes
+}
+
+func encodeLeaf(version int64, key, value string) []byte {
+	buf := appendVarint(nil, 0)
+	buf = appendVarint(buf, 1)
+	buf = appendVarint(buf, version)
+	buf = appendBytes(buf, []byte(key))
+	return appendBytes(buf, []byte(value))
+}
+
+func encodeInner(version int64, key string, leftHash, rightHash []byte) []byte {
+	buf := appendVarint(nil, 1)
+	buf = appendVarint(buf, 2)
+	buf = appendVarint(buf, version)
+	buf = appendBytes(buf, []byte(key))
+	buf = appendBytes(buf, leftHash)
+	return appendBytes(buf, rightHash)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	tmp := make([]byte, binary.MaxVarintLen64)
+	return append(buf, tmp[:binary.PutVarint(tmp, v)]...)
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	tmp := make([]byte, binary.MaxVarintLen64)
+	buf = append(buf, tmp[:binary.PutUvarint(tmp, uint64(len(b)))]...)
+	return append(buf, b...)
+}
+
+func putNode(t *testing.T, db *leveldb.DB, encodedNode []byte) []byte {
+	t.Helper()
+	hash := sha256.Sum256(encodedNode)
+	require.NoError(t, db.Put(append([]byte("n"), hash[:]...), encodedNode, nil))
+	return hash[:]
+}
+
+func putRoot(t *testing.T, db *leveldb.DB, version int64, rootHash []byte) {
+	t.Helper()
+	key := make([]byte, 9)
+	key[0] = 'r'
+	binary.BigEndian.PutUint64(key[1:], uint64(version))
+	require.NoError(t, db.Put(key, rootHash, nil))
+}
+
+type fileState struct {
+	Size    int64
+	ModTime time.Time
+}
+
+func dirState(t *testing.T, dirPath string) map[string]fileState {
+	t.Helper()
+
+	entries, err := os.ReadDir(dirPath)
+	require.NoError(t, err)
+
+	state := map[string]fileState{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		require.NoError(t, err)
+		state[entry.Name()] = fileState{Size: info.Size(), ModTime: info.ModTime()}
+	}
+	return state
+}
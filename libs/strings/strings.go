@@ -0,0 +1,39 @@
+package strings
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ToScreamingSnakeCase converts a camel case name to upper case words joined by
+// underscores, as used in environment variable names. A word starts at an
+// upper case letter following a lower case letter or a digit, or at the last
+// letter of an acronym, so "WalletCLIConfigHome" becomes
+// "WALLET_CLI_CONFIG_HOME". Any character other than an ASCII letter or digit
+// is replaced by an underscore.
+func ToScreamingSnakeCase(name string) string {
+	runes := []rune(name)
+
+	var out strings.Builder
+	for i, r := range runes {
+		if !isASCIILetterOrDigit(r) {
+			out.WriteRune('_')
+			continue
+		}
+
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				out.WriteRune('_')
+			}
+		}
+		out.WriteRune(unicode.ToUpper(r))
+	}
+
+	return out.String()
+}
+
+func isASCIILetterOrDigit(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
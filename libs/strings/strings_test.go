@@ -0,0 +1,29 @@
+package strings_test
+
+import (
+	"testing"
+
+	vgstrings "code.vegaprotocol.io/shared/libs/strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScreamingSnakeCase(t *testing.T) {
+	t.Run("Converting camel case names succeeds", testConvertingCamelCaseNamesSucceeds)
+}
+
+func testConvertingCamelCaseNamesSucceeds(t *testing.T) {
+	tcs := map[string]string{
+		"Level":                        "LEVEL",
+		"MaxRetries":                   "MAX_RETRIES",
+		"HTTPServer":                   "HTTP_SERVER",
+		"WalletCLIConfigHome":          "WALLET_CLI_CONFIG_HOME",
+		"WalletServiceRSAKeysDataHome": "WALLET_SERVICE_RSA_KEYS_DATA_HOME",
+		"Node2Config":                  "NODE2_CONFIG",
+		"data-node":                    "DATA_NODE",
+	}
+
+	for name, expected := range tcs {
+		assert.Equal(t, expected, vgstrings.ToScreamingSnakeCase(name), name)
+	}
+}
@@ -6,27 +6,63 @@ import (
 	"runtime"
 	"testing"
 
+	"code.vegaprotocol.io/shared/paths"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func AssertDirAccess(t *testing.T, dirPath string) {
+	AssertDirAccessWithMode(t, dirPath, 0700)
+}
+
+func AssertFileAccess(t *testing.T, filePath string) {
+	AssertFileAccessWithMode(t, filePath, 0600)
+}
+
+// AssertDirAccessWithMode verifies the directory has the given mode. On
+// Windows, the modes don't map to the Unix ones, and a directory is always
+// reported with the mode 0777.
+func AssertDirAccessWithMode(t *testing.T, dirPath string, mode fs.FileMode) {
 	stats, err := os.Stat(dirPath)
 	require.NoError(t, err)
 	assert.True(t, stats.IsDir())
 	if runtime.GOOS == "windows" {
 		assert.Equal(t, fs.FileMode(0777), stats.Mode().Perm())
 	} else {
-		assert.Equal(t, fs.FileMode(0700), stats.Mode().Perm())
+		assert.Equal(t, mode.Perm(), stats.Mode().Perm())
 	}
 }
 
-func AssertFileAccess(t *testing.T, filePath string) {
+// AssertFileAccessWithMode verifies the file has the given mode. On Windows,
+// the modes don't map to the Unix ones, and a writable file is always
+// reported with the mode 0666.
+func AssertFileAccessWithMode(t *testing.T, filePath string, mode fs.FileMode) {
 	stats, err := os.Stat(filePath)
 	assert.NoError(t, err)
 	if runtime.GOOS == "windows" {
 		assert.Equal(t, fs.FileMode(0666), stats.Mode().Perm())
 	} else {
-		assert.Equal(t, fs.FileMode(0600), stats.Mode().Perm())
+		assert.Equal(t, mode.Perm(), stats.Mode().Perm())
 	}
 }
+
+// AssertDirAccessFor verifies the directory at the path relative to the root
+// of the given category has the mode the permission policy of the Paths
+// grants it.
+func AssertDirAccessFor(t *testing.T, vegaPaths paths.Paths, category paths.PathCategory, relDirPath string) {
+	t.Helper()
+
+	permissions := paths.PermissionsFor(vegaPaths, category, relDirPath)
+	AssertDirAccessWithMode(t, paths.PathFor(vegaPaths, category, relDirPath), permissions.DirMode)
+}
+
+// AssertFileAccessFor verifies the file at the path relative to the root of
+// the given category has the mode the permission policy of the Paths grants
+// it.
+func AssertFileAccessFor(t *testing.T, vegaPaths paths.Paths, category paths.PathCategory, relFilePath string) {
+	t.Helper()
+
+	permissions := paths.PermissionsFor(vegaPaths, category, relFilePath)
+	AssertFileAccessWithMode(t, paths.PathFor(vegaPaths, category, relFilePath), permissions.FileMode)
+}
@@ -0,0 +1,482 @@
+package paths
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	vgcrypto "code.vegaprotocol.io/shared/libs/crypto"
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+const (
+	// backupManifestName is the name of the manifest in the archive.
+	backupManifestName = "manifest.json"
+
+	// backupFilesDir is the folder holding the files in the archive.
+	backupFilesDir = "files"
+
+	// backupFormatVersion is the version of the archive format.
+	backupFormatVersion = 1
+)
+
+// ErrBackupMayBeEncrypted is returned when an archive can't be read without
+// passphrase, which happens when it is encrypted.
+var ErrBackupMayBeEncrypted = errors.New("the archive is invalid, or encrypted and requires a passphrase")
+
+// BackupManifest describes the content of a backup archive.
+type BackupManifest struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Files     []BackupFile `json:"files"`
+}
+
+// BackupFile is a file stored in a backup archive.
+type BackupFile struct {
+	Category PathCategory `json:"category"`
+	// Path is the path of the file relative to the root of its category,
+	// with forward slashes.
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// DefaultBackupCategories are the categories backed up when none is given.
+// The state and the cache can grow large, and are built in memory like the
+// rest of the archive, so they have to be requested explicitly.
+var DefaultBackupCategories = []PathCategory{ConfigPathCategory, DataPathCategory}
+
+type BackupOptions struct {
+	// Categories restricts the backup to the given categories. It defaults
+	// to the DefaultBackupCategories.
+	Categories []PathCategory
+	// Applications restricts the backup to the paths of the given
+	// applications. All the applications are backed up if empty.
+	Applications []string
+	// Passphrase encrypts the archive if set.
+	Passphrase string
+}
+
+type RestoreOptions struct {
+	// Passphrase decrypts the archive. It is required if the archive has been
+	// encrypted.
+	Passphrase string
+	// Force overwrites the existing files.
+	Force bool
+}
+
+// BackupVerificationError is returned when the content of an archive doesn't
+// match its manifest.
+type BackupVerificationError struct {
+	Reason string
+}
+
+func (e BackupVerificationError) Error() string {
+	return fmt.Sprintf("the archive is corrupted: %s", e.Reason)
+}
+
+// BackupConflictError is returned when restoring an archive would overwrite
+// existing files.
+type BackupConflictError struct {
+	Paths []string
+}
+
+func (e BackupConflictError) Error() string {
+	return fmt.Sprintf("restoring the archive would overwrite %d existing files: %s", len(e.Paths), strings.Join(e.Paths, ", "))
+}
+
+// Backup exports the files of the registered paths matching the options into a
+// single tar archive, written to w. The archive contains a manifest listing
+// every file with its checksum. The lock and PID files are left out.
+//
+// The archive is built in memory, so it can be encrypted as a whole. It is
+// meant for the configuration, the wallets and the keys, which is why only
+// the config and data categories are backed up by default. The state and the
+// cache, like the data-node storage, have to be requested in the options.
+func Backup(vegaPaths Paths, w io.Writer, options BackupOptions) (*BackupManifest, error) {
+	fsys := FileSystemOf(vegaPaths)
+
+	if len(options.Categories) == 0 {
+		options.Categories = DefaultBackupCategories
+	}
+
+	manifest := &BackupManifest{
+		Version:   backupFormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Files:     []BackupFile{},
+	}
+	contents := map[string][]byte{}
+
+	for _, definition := range RegisteredPaths() {
+		if !matchesBackupOptions(definition, options) {
+			continue
+		}
+
+		files, err := listBackupFiles(fsys, definition.Resolve(vegaPaths), definition.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		for relPath, content := range files {
+			key := archivePathFor(definition.Category, relPath)
+			if _, ok := contents[key]; ok {
+				// The file has already been collected through a parent path.
+				continue
+			}
+
+			sum := sha256.Sum256(content)
+			contents[key] = content
+			manifest.Files = append(manifest.Files, BackupFile{
+				Category: definition.Category,
+				Path:     relPath,
+				Size:     int64(len(content)),
+				SHA256:   hex.EncodeToString(sum[:]),
+			})
+		}
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return archivePathFor(manifest.Files[i].Category, manifest.Files[i].Path) < archivePathFor(manifest.Files[j].Category, manifest.Files[j].Path)
+	})
+
+	archive, err := buildBackupArchive(manifest, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Passphrase != "" {
+		archive, err = vgcrypto.Encrypt(archive, options.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't encrypt the archive: %w", err)
+		}
+	}
+
+	if _, err := w.Write(archive); err != nil {
+		return nil, fmt.Errorf("couldn't write the archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// VerifyBackup reads the archive and verifies its content against its
+// manifest, without restoring anything.
+func VerifyBackup(r io.Reader, passphrase string) (*BackupManifest, error) {
+	manifest, _, err := readBackupArchive(r, passphrase)
+	return manifest, err
+}
+
+// Restore writes the files of the archive into the given Paths, which may be
+// a different implementation, or a different home, than the one the archive
+// has been created from. The archive is verified first. It fails with a
+// BackupConflictError if some files already exist, unless forced.
+func Restore(vegaPaths Paths, r io.Reader, options RestoreOptions) (*BackupManifest, error) {
+	manifest, contents, err := readBackupArchive(r, options.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := FileSystemOf(vegaPaths)
+
+	if !options.Force {
+		conflicts := []string{}
+		for _, file := range manifest.Files {
+			destination := resolveBackupFile(vegaPaths, file)
+			exists, err := vgfs.PathExistsIn(fsys, destination)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't verify the presence of %s: %w", destination, err)
+			}
+			if exists {
+				conflicts = append(conflicts, destination)
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil, BackupConflictError{Paths: conflicts}
+		}
+	}
+
+	for _, file := range manifest.Files {
+		destination, err := createBackupFilePath(vegaPaths, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mode := PermissionsFor(vegaPaths, file.Category, filepath.FromSlash(file.Path)).FileMode
+		if err := vgfs.WriteFileWithModeIn(fsys, destination, contents[archivePathFor(file.Category, file.Path)], mode); err != nil {
+			return nil, fmt.Errorf("couldn't restore %s: %w", destination, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+func matchesBackupOptions(definition PathDefinition, options BackupOptions) bool {
+	found := false
+	for _, category := range options.Categories {
+		if category == definition.Category {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	if len(options.Applications) > 0 {
+		for _, application := range options.Applications {
+			if application == definition.Application {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// listBackupFiles returns the content of the files at the given path, indexed
+// by their path relative to the category root, with forward slashes.
+func listBackupFiles(fsys vgfs.FileSystem, fullPath, relPath string) (map[string][]byte, error) {
+	info, err := fsys.Stat(fullPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("couldn't inspect %s: %w", fullPath, err)
+	}
+
+	files := map[string][]byte{}
+
+	if !info.IsDir() {
+		if isExcludedFromBackup(info.Name()) {
+			return files, nil
+		}
+		content, err := vgfs.ReadFileIn(fsys, fullPath)
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.ToSlash(relPath)] = content
+		return files, nil
+	}
+
+	entries, err := fsys.ReadDir(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read directory %s: %w", fullPath, err)
+	}
+
+	for _, entry := range entries {
+		subFiles, err := listBackupFiles(fsys, filepath.Join(fullPath, entry.Name()), filepath.Join(relPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for subPath, content := range subFiles {
+			files[subPath] = content
+		}
+	}
+
+	return files, nil
+}
+
+func isExcludedFromBackup(name string) bool {
+	return strings.HasSuffix(name, ".lock") || name == instancePIDFileName
+}
+
+func archivePathFor(category PathCategory, relPath string) string {
+	return path.Join(backupFilesDir, string(category), relPath)
+}
+
+func buildBackupArchive(manifest *BackupManifest, contents map[string][]byte) ([]byte, error) {
+	rawManifest, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal the manifest: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	writeEntry := func(name string, content []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("couldn't write the header of %s: %w", name, err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return fmt.Errorf("couldn't write %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := writeEntry(backupManifestName, rawManifest); err != nil {
+		return nil, err
+	}
+
+	for _, file := range manifest.Files {
+		name := archivePathFor(file.Category, file.Path)
+		if err := writeEntry(name, contents[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("couldn't finalize the archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func readBackupArchive(r io.Reader, passphrase string) (*BackupManifest, map[string][]byte, error) {
+	archive, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't read the archive: %w", err)
+	}
+
+	if passphrase != "" {
+		archive, err = vgcrypto.Decrypt(archive, passphrase)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't decrypt the archive: %w", err)
+		}
+	}
+
+	var manifest *BackupManifest
+	contents := map[string][]byte{}
+
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if passphrase == "" {
+				return nil, nil, ErrBackupMayBeEncrypted
+			}
+			return nil, nil, fmt.Errorf("couldn't read the archive: %w", err)
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't read %s from the archive: %w", header.Name, err)
+		}
+
+		if header.Name == backupManifestName {
+			manifest = &BackupManifest{}
+			if err := json.Unmarshal(content, manifest); err != nil {
+				return nil, nil, BackupVerificationError{Reason: fmt.Sprintf("invalid manifest: %v", err)}
+			}
+			continue
+		}
+
+		contents[header.Name] = content
+	}
+
+	if manifest == nil {
+		if passphrase == "" {
+			return nil, nil, ErrBackupMayBeEncrypted
+		}
+		return nil, nil, BackupVerificationError{Reason: "the manifest is missing"}
+	}
+
+	if err := verifyBackupContents(manifest, contents); err != nil {
+		return nil, nil, err
+	}
+
+	return manifest, contents, nil
+}
+
+func verifyBackupContents(manifest *BackupManifest, contents map[string][]byte) error {
+	if manifest.Version != backupFormatVersion {
+		return BackupVerificationError{Reason: fmt.Sprintf("unsupported format version %d", manifest.Version)}
+	}
+
+	expected := map[string]struct{}{}
+	for _, file := range manifest.Files {
+		switch file.Category {
+		case CachePathCategory, ConfigPathCategory, DataPathCategory, StatePathCategory:
+		default:
+			return BackupVerificationError{Reason: fmt.Sprintf("file %s has an unsupported category %q", file.Path, file.Category)}
+		}
+
+		if isUnsafeBackupPath(file.Path) {
+			return BackupVerificationError{Reason: fmt.Sprintf("file %s has an unsafe path", file.Path)}
+		}
+
+		name := archivePathFor(file.Category, file.Path)
+		content, ok := contents[name]
+		if !ok {
+			return BackupVerificationError{Reason: fmt.Sprintf("file %s is missing", name)}
+		}
+
+		sum := sha256.Sum256(content)
+		if int64(len(content)) != file.Size || hex.EncodeToString(sum[:]) != file.SHA256 {
+			return BackupVerificationError{Reason: fmt.Sprintf("file %s doesn't match its checksum", name)}
+		}
+
+		expected[name] = struct{}{}
+	}
+
+	for name := range contents {
+		if _, ok := expected[name]; !ok {
+			return BackupVerificationError{Reason: fmt.Sprintf("file %s is not listed in the manifest", name)}
+		}
+	}
+
+	return nil
+}
+
+// isUnsafeBackupPath tells if the path of a manifest entry could escape the
+// root of its category once restored. The path is verified both in its slash
+// form, and in the form of the current OS, as a backslash or a volume name
+// only becomes meaningful on Windows. The backslashes are rejected outright,
+// as the manifests only use forward slashes.
+func isUnsafeBackupPath(relPath string) bool {
+	if strings.Contains(relPath, "\\") {
+		return true
+	}
+
+	cleanPath := path.Clean(relPath)
+	if cleanPath != relPath || path.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		return true
+	}
+
+	osPath := filepath.FromSlash(relPath)
+	if filepath.IsAbs(osPath) || filepath.VolumeName(osPath) != "" {
+		return true
+	}
+	for _, segment := range strings.Split(osPath, string(filepath.Separator)) {
+		if segment == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resolveBackupFile(vegaPaths Paths, file BackupFile) string {
+	return PathDefinition{
+		Category: file.Category,
+		Path:     filepath.FromSlash(file.Path),
+	}.Resolve(vegaPaths)
+}
+
+func createBackupFilePath(vegaPaths Paths, file BackupFile) (string, error) {
+	fullPath, err := CreatePathFor(vegaPaths, file.Category, filepath.FromSlash(file.Path))
+	if err != nil {
+		return "", fmt.Errorf("couldn't create the directory of %s: %w", file.Path, err)
+	}
+
+	return fullPath, nil
+}
@@ -0,0 +1,257 @@
+package paths_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	t.Run("Backing up and restoring re-maps the files", testBackingUpAndRestoringReMapsTheFiles)
+	t.Run("Backing up filters by category and application", testBackingUpFiltersByCategoryAndApplication)
+	t.Run("Backing up leaves the state out by default", testBackingUpLeavesTheStateOutByDefault)
+	t.Run("Encrypted backup requires the passphrase", testEncryptedBackupRequiresThePassphrase)
+	t.Run("Restoring corrupted backup fails", testRestoringCorruptedBackupFails)
+	t.Run("Restoring over existing files fails unless forced", testRestoringOverExistingFilesFailsUnlessForced)
+	t.Run("Restoring honours the policy of the target", testRestoringHonoursThePolicyOfTheTarget)
+	t.Run("Restoring unsafe paths fails", testRestoringUnsafePathsFails)
+}
+
+func setUpBackupSource(t *testing.T) *paths.CustomPaths {
+	t.Helper()
+
+	vegaPaths := newMemoryCustomPaths()
+	writeTestFile(t, vegaPaths, vegaPaths.ConfigPathFor(paths.NodeDefaultConfigFile), "node-config")
+	writeTestFile(t, vegaPaths, vegaPaths.DataPathFor(paths.JoinDataPath(paths.VegaNodeWalletsDataHome, "vega-wallet")), "node-wallet")
+	writeTestFile(t, vegaPaths, vegaPaths.DataPathFor(paths.WalletServicePrivateRSAKeyDataFile), "private-key")
+	writeTestFile(t, vegaPaths, vegaPaths.StatePathFor(paths.JoinStatePath(paths.NodeLogsHome, "node.log")), "logs")
+	writeTestFile(t, vegaPaths, vegaPaths.StatePathFor(paths.JoinStatePath(paths.NodeStateHome, "instance.pid")), "1234")
+
+	return vegaPaths
+}
+
+func writeTestFile(t *testing.T, vegaPaths *paths.CustomPaths, path, content string) {
+	t.Helper()
+
+	fsys := vegaPaths.FileSystem
+	require.NoError(t, vgfs.EnsureDirIn(fsys, filepath.Dir(path)))
+	require.NoError(t, vgfs.WriteFileIn(fsys, path, []byte(content)))
+}
+
+func readTestFile(t *testing.T, vegaPaths *paths.CustomPaths, path string) string {
+	t.Helper()
+
+	content, err := vgfs.ReadFileIn(vegaPaths.FileSystem, path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func testBackingUpAndRestoringReMapsTheFiles(t *testing.T) {
+	source := setUpBackupSource(t)
+	archive := &bytes.Buffer{}
+
+	manifest, err := paths.Backup(source, archive, paths.BackupOptions{
+		Categories: []paths.PathCategory{paths.ConfigPathCategory, paths.DataPathCategory, paths.StatePathCategory},
+	})
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 4)
+
+	target := &paths.CustomPaths{
+		CustomHome: "/new-hardware/vega",
+		FileSystem: vgfs.NewMemoryFileSystem(),
+	}
+	restoredManifest, err := paths.Restore(target, archive, paths.RestoreOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Files, restoredManifest.Files)
+
+	assert.Equal(t, "node-config", readTestFile(t, target, target.ConfigPathFor(paths.NodeDefaultConfigFile)))
+	assert.Equal(t, "node-wallet", readTestFile(t, target, target.DataPathFor(paths.JoinDataPath(paths.VegaNodeWalletsDataHome, "vega-wallet"))))
+	assert.Equal(t, "private-key", readTestFile(t, target, target.DataPathFor(paths.WalletServicePrivateRSAKeyDataFile)))
+	assert.Equal(t, "logs", readTestFile(t, target, target.StatePathFor(paths.JoinStatePath(paths.NodeLogsHome, "node.log"))))
+
+	exists, err := vgfs.PathExistsIn(target.FileSystem, target.StatePathFor(paths.JoinStatePath(paths.NodeStateHome, "instance.pid")))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testBackingUpFiltersByCategoryAndApplication(t *testing.T) {
+	source := setUpBackupSource(t)
+
+	manifest, err := paths.Backup(source, &bytes.Buffer{}, paths.BackupOptions{
+		Categories:   []paths.PathCategory{paths.ConfigPathCategory, paths.DataPathCategory},
+		Applications: []string{"node"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, manifest.Files, 2)
+	assert.Equal(t, paths.ConfigPathCategory, manifest.Files[0].Category)
+	assert.Equal(t, "node/config.toml", manifest.Files[0].Path)
+	assert.Equal(t, paths.DataPathCategory, manifest.Files[1].Category)
+	assert.Equal(t, "node/wallets/vega/vega-wallet", manifest.Files[1].Path)
+}
+
+func testBackingUpLeavesTheStateOutByDefault(t *testing.T) {
+	source := setUpBackupSource(t)
+	writeTestFile(t, source, source.CachePathFor(paths.JoinCachePath(paths.DataNodeCacheHome, "blocks")), "blocks")
+
+	manifest, err := paths.Backup(source, &bytes.Buffer{}, paths.BackupOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, manifest.Files, 3)
+	for _, file := range manifest.Files {
+		assert.Contains(t, []paths.PathCategory{paths.ConfigPathCategory, paths.DataPathCategory}, file.Category)
+	}
+}
+
+func testEncryptedBackupRequiresThePassphrase(t *testing.T) {
+	source := setUpBackupSource(t)
+	archive := &bytes.Buffer{}
+	_, err := paths.Backup(source, archive, paths.BackupOptions{Passphrase: "passphrase"})
+	require.NoError(t, err)
+	rawArchive := archive.Bytes()
+
+	_, err = paths.VerifyBackup(bytes.NewReader(rawArchive), "")
+	require.ErrorIs(t, err, paths.ErrBackupMayBeEncrypted)
+
+	_, err = paths.VerifyBackup(bytes.NewReader(rawArchive), "wrong-passphrase")
+	require.Error(t, err)
+
+	manifest, err := paths.VerifyBackup(bytes.NewReader(rawArchive), "passphrase")
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 3)
+
+	target := newMemoryCustomPaths()
+	_, err = paths.Restore(target, bytes.NewReader(rawArchive), paths.RestoreOptions{Passphrase: "passphrase"})
+	require.NoError(t, err)
+	assert.Equal(t, "node-config", readTestFile(t, target, target.ConfigPathFor(paths.NodeDefaultConfigFile)))
+}
+
+func testRestoringCorruptedBackupFails(t *testing.T) {
+	source := setUpBackupSource(t)
+	archive := &bytes.Buffer{}
+	_, err := paths.Backup(source, archive, paths.BackupOptions{})
+	require.NoError(t, err)
+
+	corrupted := bytes.Replace(archive.Bytes(), []byte("node-config"), []byte("evil-config"), 1)
+
+	target := newMemoryCustomPaths()
+	_, err = paths.Restore(target, bytes.NewReader(corrupted), paths.RestoreOptions{})
+
+	var verificationErr paths.BackupVerificationError
+	require.ErrorAs(t, err, &verificationErr)
+	exists, err := vgfs.PathExistsIn(target.FileSystem, target.ConfigPathFor(paths.NodeDefaultConfigFile))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testRestoringOverExistingFilesFailsUnlessForced(t *testing.T) {
+	source := setUpBackupSource(t)
+	archive := &bytes.Buffer{}
+	_, err := paths.Backup(source, archive, paths.BackupOptions{})
+	require.NoError(t, err)
+	rawArchive := archive.Bytes()
+
+	target := newMemoryCustomPaths()
+	writeTestFile(t, target, target.ConfigPathFor(paths.NodeDefaultConfigFile), "existing-config")
+
+	_, err = paths.Restore(target, bytes.NewReader(rawArchive), paths.RestoreOptions{})
+	var conflictErr paths.BackupConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, []string{target.ConfigPathFor(paths.NodeDefaultConfigFile)}, conflictErr.Paths)
+	assert.Equal(t, "existing-config", readTestFile(t, target, target.ConfigPathFor(paths.NodeDefaultConfigFile)))
+
+	_, err = paths.Restore(target, bytes.NewReader(rawArchive), paths.RestoreOptions{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, "node-config", readTestFile(t, target, target.ConfigPathFor(paths.NodeDefaultConfigFile)))
+}
+
+func testRestoringHonoursThePolicyOfTheTarget(t *testing.T) {
+	source := setUpBackupSource(t)
+	writeTestFile(t, source, source.DataPathFor(paths.WalletServicePublicRSAKeyDataFile), "public-key")
+	archive := &bytes.Buffer{}
+	_, err := paths.Backup(source, archive, paths.BackupOptions{})
+	require.NoError(t, err)
+
+	target := newMemoryCustomPaths()
+	target.Permissions = paths.DefaultPermissionPolicy()
+	target.Permissions.OverrideDataPath(paths.WalletServiceRSAKeysDataHome, paths.Permissions{DirMode: 0755})
+	target.Permissions.OverrideDataPath(paths.WalletServicePublicRSAKeyDataFile, paths.Permissions{FileMode: 0644})
+
+	_, err = paths.Restore(target, archive, paths.RestoreOptions{})
+	require.NoError(t, err)
+
+	assertMemoryDirMode(t, target.FileSystem, target.DataPathFor(paths.WalletServiceRSAKeysDataHome), 0755)
+	assertMemoryFileMode(t, target.FileSystem, target.DataPathFor(paths.WalletServicePublicRSAKeyDataFile), 0644)
+	assertMemoryFileMode(t, target.FileSystem, target.DataPathFor(paths.WalletServicePrivateRSAKeyDataFile), 0600)
+}
+
+func assertMemoryFileMode(t *testing.T, fsys vgfs.FileSystem, filePath string, mode fs.FileMode) {
+	t.Helper()
+
+	info, err := fsys.Stat(filePath)
+	require.NoError(t, err)
+	assert.False(t, info.IsDir())
+	assert.Equal(t, mode, info.Mode().Perm())
+}
+
+func testRestoringUnsafePathsFails(t *testing.T) {
+	tcs := []struct {
+		name string
+		path string
+	}{
+		{name: "with parent directory", path: "../outside"},
+		{name: "with absolute path", path: "/outside"},
+		{name: "with backslashes", path: `..\..\outside`},
+		{name: "with volume name", path: `C:\outside`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(tt *testing.T) {
+			archive := buildTestArchive(tt, &paths.BackupManifest{
+				Version: 1,
+				Files: []paths.BackupFile{{
+					Category: paths.ConfigPathCategory,
+					Path:     tc.path,
+				}},
+			})
+
+			target := newMemoryCustomPaths()
+			_, err := paths.Restore(target, bytes.NewReader(archive), paths.RestoreOptions{})
+
+			var verificationErr paths.BackupVerificationError
+			require.ErrorAs(tt, err, &verificationErr)
+			assert.Contains(tt, verificationErr.Reason, "unsafe path")
+		})
+	}
+}
+
+// buildTestArchive builds an archive holding the manifest only, so the
+// manifest can describe files the backup would never produce.
+func buildTestArchive(t *testing.T, manifest *paths.BackupManifest) []byte {
+	t.Helper()
+
+	rawManifest, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: "manifest.json",
+		Mode: 0600,
+		Size: int64(len(rawManifest)),
+	}))
+	_, err = tw.Write(rawManifest)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,275 @@
+package paths
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	vgcrypto "code.vegaprotocol.io/shared/libs/crypto"
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+// The node writes its checkpoints in the CheckpointStateHome folder, in files
+// named after the time they were taken, the block height, and the hash of
+// their content:
+//
+// CheckpointStateHome
+// 	├── 20220314120000-1200-0a1b2c...3d4e.cp
+// 	└── 20220314130000-2400-5f6a7b...8c9d.cp
+
+const (
+	// CheckpointTimestampLayout is the layout of the time in the name of the
+	// checkpoint files. It is expressed in UTC.
+	CheckpointTimestampLayout = "20060102150405"
+	// CheckpointFileExt is the extension of the checkpoint files.
+	CheckpointFileExt = ".cp"
+)
+
+var (
+	ErrNotACheckpointFile = errors.New("not a checkpoint file")
+	ErrNoCheckpointFound  = errors.New("no checkpoint found")
+
+	checkpointFileNameRegex = regexp.MustCompile(`^(\d{14})-(\d+)-([0-9a-fA-F]+)\.cp$`)
+)
+
+// CheckpointHashFunc computes the hash, hex-encoded, of the content of a
+// checkpoint file.
+type CheckpointHashFunc func(content []byte) string
+
+// DefaultCheckpointHashFunc hashes the content with SHA3-256.
+func DefaultCheckpointHashFunc(content []byte) string {
+	return hex.EncodeToString(vgcrypto.Hash(content))
+}
+
+// CheckpointFile is a checkpoint file found in the CheckpointStateHome.
+type CheckpointFile struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+	Height    uint64    `json:"height"`
+	// Hash is the hex-encoded hash found in the name of the file.
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// CheckpointFileNameFor returns the name of the file for the checkpoint taken
+// at the given time and height.
+func CheckpointFileNameFor(timestamp time.Time, height uint64, hash string) string {
+	return fmt.Sprintf("%s-%d-%s%s", timestamp.UTC().Format(CheckpointTimestampLayout), height, hash, CheckpointFileExt)
+}
+
+// ParseCheckpointFileName extracts the time, the height and the hash from the
+// name of a checkpoint file. It returns ErrNotACheckpointFile if the name
+// doesn't follow the checkpoint file naming.
+func ParseCheckpointFileName(name string) (*CheckpointFile, error) {
+	matches := checkpointFileNameRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return nil, ErrNotACheckpointFile
+	}
+
+	timestamp, err := time.ParseInLocation(CheckpointTimestampLayout, matches[1], time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the time of checkpoint %s: %w", name, err)
+	}
+
+	height, err := strconv.ParseUint(matches[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the height of checkpoint %s: %w", name, err)
+	}
+
+	return &CheckpointFile{
+		Name:      name,
+		Timestamp: timestamp,
+		Height:    height,
+		Hash:      strings.ToLower(matches[3]),
+	}, nil
+}
+
+// ListCheckpoints returns the checkpoint files of the CheckpointStateHome,
+// sorted by height, and then by time. The files that don't follow the
+// checkpoint file naming are ignored.
+func ListCheckpoints(vegaPaths Paths) ([]CheckpointFile, error) {
+	fsys := FileSystemOf(vegaPaths)
+	checkpointsHome := vegaPaths.StatePathFor(CheckpointStateHome)
+
+	entries, err := fsys.ReadDir(checkpointsHome)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []CheckpointFile{}, nil
+		}
+		return nil, fmt.Errorf("couldn't read directory %s: %w", checkpointsHome, err)
+	}
+
+	checkpoints := []CheckpointFile{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		checkpoint, err := ParseCheckpointFileName(entry.Name())
+		if err != nil {
+			if errors.Is(err, ErrNotACheckpointFile) {
+				continue
+			}
+			return nil, err
+		}
+
+		checkpoint.Path = filepath.Join(checkpointsHome, entry.Name())
+		info, err := fsys.Stat(checkpoint.Path)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't inspect %s: %w", checkpoint.Path, err)
+		}
+		checkpoint.Size = info.Size()
+
+		checkpoints = append(checkpoints, *checkpoint)
+	}
+
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		if checkpoints[i].Height != checkpoints[j].Height {
+			return checkpoints[i].Height < checkpoints[j].Height
+		}
+		return checkpoints[i].Timestamp.Before(checkpoints[j].Timestamp)
+	})
+
+	return checkpoints, nil
+}
+
+// LatestCheckpointAt returns the most recent checkpoint taken at, or before,
+// the given height. It returns ErrNoCheckpointFound if there is none.
+func LatestCheckpointAt(vegaPaths Paths, height uint64) (*CheckpointFile, error) {
+	checkpoints, err := ListCheckpoints(vegaPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		if checkpoints[i].Height <= height {
+			return &checkpoints[i], nil
+		}
+	}
+
+	return nil, ErrNoCheckpointFound
+}
+
+// CheckpointHashMismatchError is returned when the hash of the content of a
+// checkpoint file doesn't match the one in its name.
+type CheckpointHashMismatchError struct {
+	Path         string
+	ExpectedHash string
+	ActualHash   string
+}
+
+func (e CheckpointHashMismatchError) Error() string {
+	return fmt.Sprintf("checkpoint %s is corrupted: its name has hash %s, but its content has hash %s", e.Path, e.ExpectedHash, e.ActualHash)
+}
+
+// VerifyCheckpoint verifies the hash of the content of the checkpoint file
+// matches the one in its name. If no hash function is given, the
+// DefaultCheckpointHashFunc is used.
+func VerifyCheckpoint(vegaPaths Paths, checkpoint CheckpointFile, hashFunc CheckpointHashFunc) error {
+	if hashFunc == nil {
+		hashFunc = DefaultCheckpointHashFunc
+	}
+
+	content, err := vgfs.ReadFileIn(FileSystemOf(vegaPaths), checkpoint.Path)
+	if err != nil {
+		return fmt.Errorf("couldn't read checkpoint %s: %w", checkpoint.Path, err)
+	}
+
+	actualHash := strings.ToLower(hashFunc(content))
+	if actualHash != checkpoint.Hash {
+		return CheckpointHashMismatchError{
+			Path:         checkpoint.Path,
+			ExpectedHash: checkpoint.Hash,
+			ActualHash:   actualHash,
+		}
+	}
+
+	return nil
+}
+
+// CheckpointVerification is the result of the verification of a checkpoint
+// file.
+type CheckpointVerification struct {
+	Checkpoint CheckpointFile `json:"checkpoint"`
+	Valid      bool           `json:"valid"`
+	Reason     string         `json:"reason,omitempty"`
+}
+
+// VerifyCheckpoints verifies the hash of every checkpoint file, in the order
+// of ListCheckpoints.
+func VerifyCheckpoints(vegaPaths Paths, hashFunc CheckpointHashFunc) ([]CheckpointVerification, error) {
+	checkpoints, err := ListCheckpoints(vegaPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications := make([]CheckpointVerification, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		verification := CheckpointVerification{
+			Checkpoint: checkpoint,
+			Valid:      true,
+		}
+		if err := VerifyCheckpoint(vegaPaths, checkpoint, hashFunc); err != nil {
+			verification.Valid = false
+			verification.Reason = err.Error()
+		}
+		verifications = append(verifications, verification)
+	}
+
+	return verifications, nil
+}
+
+// PruneCheckpoints removes the checkpoint files according to the retention
+// policy. The checkpoints at the highest heights are the most recent ones, and
+// their age is the one of the time in their name. The files that don't follow
+// the checkpoint file naming are never removed.
+func PruneCheckpoints(vegaPaths Paths, policy RetentionPolicy, options PurgeOptions) (*PurgeResult, error) {
+	checkpoints, err := ListCheckpoints(vegaPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := FileSystemOf(vegaPaths)
+	result := &PurgeResult{
+		Name:   "CheckpointStateHome",
+		Path:   vegaPaths.StatePathFor(CheckpointStateHome),
+		DryRun: options.DryRun,
+		Purged: []PurgedEntry{},
+	}
+
+	now := time.Now()
+	var keptSize int64
+	for rank := 0; rank < len(checkpoints); rank++ {
+		// The checkpoints are listed from the oldest to the most recent.
+		checkpoint := checkpoints[len(checkpoints)-1-rank]
+
+		if !policy.requiresPurge(rank, now.Sub(checkpoint.Timestamp), keptSize+checkpoint.Size) {
+			keptSize += checkpoint.Size
+			result.Kept++
+			continue
+		}
+
+		if !options.DryRun {
+			if err := fsys.Remove(checkpoint.Path); err != nil {
+				return nil, fmt.Errorf("couldn't remove %s: %w", checkpoint.Path, err)
+			}
+		}
+
+		result.Purged = append(result.Purged, PurgedEntry{
+			Path:    checkpoint.Path,
+			Size:    checkpoint.Size,
+			ModTime: checkpoint.Timestamp,
+		})
+		result.FreedSize += checkpoint.Size
+	}
+
+	return result, nil
+}
@@ -0,0 +1,187 @@
+package paths_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoints(t *testing.T) {
+	t.Run("Parsing checkpoint file names succeeds", testParsingCheckpointFileNamesSucceeds)
+	t.Run("Parsing invalid checkpoint file names fails", testParsingInvalidCheckpointFileNamesFails)
+	t.Run("Listing checkpoints sorts them by height", testListingCheckpointsSortsThemByHeight)
+	t.Run("Listing checkpoints without directory returns nothing", testListingCheckpointsWithoutDirectoryReturnsNothing)
+	t.Run("Getting the latest checkpoint at a height succeeds", testGettingTheLatestCheckpointAtHeightSucceeds)
+	t.Run("Verifying checkpoints detects corrupted files", testVerifyingCheckpointsDetectsCorruptedFiles)
+	t.Run("Verifying checkpoints with a custom hash succeeds", testVerifyingCheckpointsWithCustomHashSucceeds)
+	t.Run("Pruning checkpoints keeps the highest ones", testPruningCheckpointsKeepsTheHighestOnes)
+	t.Run("Pruning checkpoints removes the ones above max age", testPruningCheckpointsRemovesTheOnesAboveMaxAge)
+}
+
+func testParsingCheckpointFileNamesSucceeds(t *testing.T) {
+	checkpoint, err := paths.ParseCheckpointFileName("20220314120000-1200-0A1B2C.cp")
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 3, 14, 12, 0, 0, 0, time.UTC), checkpoint.Timestamp)
+	assert.Equal(t, uint64(1200), checkpoint.Height)
+	assert.Equal(t, "0a1b2c", checkpoint.Hash)
+	assert.Equal(t, "20220314120000-1200-0a1b2c.cp", paths.CheckpointFileNameFor(checkpoint.Timestamp, checkpoint.Height, checkpoint.Hash))
+}
+
+func testParsingInvalidCheckpointFileNamesFails(t *testing.T) {
+	names := []string{
+		"20220314120000-1200-0a1b2c.txt",
+		"2022031412-1200-0a1b2c.cp",
+		"20220314120000-height-0a1b2c.cp",
+		"20220314120000-1200-not-hex.cp",
+		"20221314120000-1200-0a1b2c.cp",
+	}
+
+	for _, name := range names {
+		_, err := paths.ParseCheckpointFileName(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func testListingCheckpointsSortsThemByHeight(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	now := time.Now()
+	second := writeCheckpoint(t, vegaPaths, now.Add(-1*time.Hour), 2400, "second")
+	first := writeCheckpoint(t, vegaPaths, now.Add(-2*time.Hour), 1200, "first")
+	third := writeCheckpoint(t, vegaPaths, now, 3600, "third")
+	checkpointsHome := vegaPaths.StatePathFor(paths.CheckpointStateHome)
+	require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, filepath.Join(checkpointsHome, "notes.txt"), []byte("hello")))
+
+	checkpoints, err := paths.ListCheckpoints(vegaPaths)
+
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3)
+	assert.Equal(t, []string{first, second, third}, []string{checkpoints[0].Name, checkpoints[1].Name, checkpoints[2].Name})
+	assert.Equal(t, filepath.Join(checkpointsHome, first), checkpoints[0].Path)
+	assert.Equal(t, int64(len("first")), checkpoints[0].Size)
+}
+
+func testListingCheckpointsWithoutDirectoryReturnsNothing(t *testing.T) {
+	checkpoints, err := paths.ListCheckpoints(newMemoryCustomPaths())
+
+	require.NoError(t, err)
+	assert.Empty(t, checkpoints)
+}
+
+func testGettingTheLatestCheckpointAtHeightSucceeds(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	now := time.Now()
+	writeCheckpoint(t, vegaPaths, now.Add(-2*time.Hour), 1200, "first")
+	second := writeCheckpoint(t, vegaPaths, now.Add(-1*time.Hour), 2400, "second")
+	third := writeCheckpoint(t, vegaPaths, now, 3600, "third")
+
+	checkpoint, err := paths.LatestCheckpointAt(vegaPaths, 3000)
+	require.NoError(t, err)
+	assert.Equal(t, second, checkpoint.Name)
+
+	checkpoint, err = paths.LatestCheckpointAt(vegaPaths, 3600)
+	require.NoError(t, err)
+	assert.Equal(t, third, checkpoint.Name)
+
+	_, err = paths.LatestCheckpointAt(vegaPaths, 1000)
+	assert.ErrorIs(t, err, paths.ErrNoCheckpointFound)
+}
+
+func testVerifyingCheckpointsDetectsCorruptedFiles(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	now := time.Now()
+	writeCheckpoint(t, vegaPaths, now.Add(-1*time.Hour), 1200, "first")
+	corrupted := writeCheckpoint(t, vegaPaths, now, 2400, "second")
+	corruptedPath := vegaPaths.StatePathFor(paths.StatePath(filepath.Join(paths.CheckpointStateHome.String(), corrupted)))
+	require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, corruptedPath, []byte("tampered")))
+
+	verifications, err := paths.VerifyCheckpoints(vegaPaths, nil)
+
+	require.NoError(t, err)
+	require.Len(t, verifications, 2)
+	assert.True(t, verifications[0].Valid)
+	assert.False(t, verifications[1].Valid)
+	assert.NotEmpty(t, verifications[1].Reason)
+
+	err = paths.VerifyCheckpoint(vegaPaths, verifications[1].Checkpoint, nil)
+	var mismatchErr paths.CheckpointHashMismatchError
+	require.ErrorAs(t, err, &mismatchErr)
+	assert.Equal(t, paths.DefaultCheckpointHashFunc([]byte("tampered")), mismatchErr.ActualHash)
+}
+
+func testVerifyingCheckpointsWithCustomHashSucceeds(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	checkpointsHome, err := vegaPaths.CreateStateDirFor(paths.CheckpointStateHome)
+	require.NoError(t, err)
+	name := paths.CheckpointFileNameFor(time.Now(), 1200, "c0ffee")
+	require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, filepath.Join(checkpointsHome, name), []byte("content")))
+
+	verifications, err := paths.VerifyCheckpoints(vegaPaths, func(content []byte) string {
+		return "C0FFEE"
+	})
+
+	require.NoError(t, err)
+	require.Len(t, verifications, 1)
+	assert.True(t, verifications[0].Valid)
+}
+
+func testPruningCheckpointsKeepsTheHighestOnes(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	now := time.Now()
+	first := writeCheckpoint(t, vegaPaths, now.Add(-2*time.Hour), 1200, "first")
+	writeCheckpoint(t, vegaPaths, now.Add(-1*time.Hour), 2400, "second")
+	writeCheckpoint(t, vegaPaths, now, 3600, "third")
+
+	result, err := paths.PruneCheckpoints(vegaPaths, paths.RetentionPolicy{KeepLast: 2}, paths.PurgeOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, result.Purged, 1)
+	assert.True(t, strings.HasSuffix(result.Purged[0].Path, first))
+	checkpoints, err := paths.ListCheckpoints(vegaPaths)
+	require.NoError(t, err)
+	assert.Len(t, checkpoints, 3)
+
+	result, err = paths.PruneCheckpoints(vegaPaths, paths.RetentionPolicy{KeepLast: 2}, paths.PurgeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Kept)
+	assert.Equal(t, int64(len("first")), result.FreedSize)
+	checkpoints, err = paths.ListCheckpoints(vegaPaths)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+	assert.Equal(t, uint64(2400), checkpoints[0].Height)
+}
+
+func testPruningCheckpointsRemovesTheOnesAboveMaxAge(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	now := time.Now()
+	writeCheckpoint(t, vegaPaths, now.Add(-72*time.Hour), 1200, "first")
+	writeCheckpoint(t, vegaPaths, now.Add(-48*time.Hour), 2400, "second")
+	third := writeCheckpoint(t, vegaPaths, now, 3600, "third")
+
+	result, err := paths.PruneCheckpoints(vegaPaths, paths.RetentionPolicy{MaxAge: 24 * time.Hour}, paths.PurgeOptions{})
+
+	require.NoError(t, err)
+	assert.Len(t, result.Purged, 2)
+	checkpoints, err := paths.ListCheckpoints(vegaPaths)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+	assert.Equal(t, third, checkpoints[0].Name)
+}
+
+func writeCheckpoint(t *testing.T, vegaPaths *paths.CustomPaths, timestamp time.Time, height uint64, content string) string {
+	t.Helper()
+
+	checkpointsHome, err := vegaPaths.CreateStateDirFor(paths.CheckpointStateHome)
+	require.NoError(t, err)
+
+	name := paths.CheckpointFileNameFor(timestamp, height, paths.DefaultCheckpointHashFunc([]byte(content)))
+	require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, filepath.Join(checkpointsHome, name), []byte(content)))
+
+	return name
+}
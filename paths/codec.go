@@ -0,0 +1,148 @@
+package paths
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Codec encodes and decodes the content of structured files.
+type Codec interface {
+	// Name returns the name of the format, as displayed in error messages.
+	Name() string
+	Encode(v interface{}) ([]byte, error)
+	Decode(buf []byte, v interface{}) error
+}
+
+var (
+	TOMLCodec Codec = tomlCodec{}
+	JSONCodec Codec = jsonCodec{}
+	YAMLCodec Codec = yamlCodec{}
+
+	// DefaultCodec is used when the format of a file can't be determined from
+	// its extension or content type.
+	DefaultCodec = TOMLCodec
+)
+
+var codecs = struct {
+	mu            sync.RWMutex
+	byExtension   map[string]Codec
+	byContentType map[string]Codec
+}{
+	byExtension:   map[string]Codec{},
+	byContentType: map[string]Codec{},
+}
+
+func init() {
+	RegisterCodec(TOMLCodec, []string{".toml"}, []string{"application/toml"})
+	RegisterCodec(JSONCodec, []string{".json"}, []string{"application/json", "text/json"})
+	RegisterCodec(YAMLCodec, []string{".yaml", ".yml"}, []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"})
+}
+
+// RegisterCodec associates a codec to file extensions, like ".toml", and HTTP
+// content types, like "application/toml". It replaces any codec previously
+// registered for them.
+func RegisterCodec(codec Codec, extensions []string, contentTypes []string) {
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+
+	for _, ext := range extensions {
+		codecs.byExtension[strings.ToLower(ext)] = codec
+	}
+	for _, contentType := range contentTypes {
+		codecs.byContentType[strings.ToLower(contentType)] = codec
+	}
+}
+
+// CodecForPath returns the codec registered for the extension of the given
+// path, or the DefaultCodec if there is none.
+func CodecForPath(path string) Codec {
+	if codec, ok := codecForExtension(filepath.Ext(path)); ok {
+		return codec
+	}
+	return DefaultCodec
+}
+
+// CodecForContentType returns the codec registered for the given HTTP content
+// type, or the DefaultCodec if there is none. Parameters, like the charset,
+// are ignored.
+func CodecForContentType(contentType string) Codec {
+	if codec, ok := codecForContentType(contentType); ok {
+		return codec
+	}
+	return DefaultCodec
+}
+
+func codecForExtension(ext string) (Codec, bool) {
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+
+	codec, ok := codecs.byExtension[strings.ToLower(ext)]
+	return codec, ok
+}
+
+func codecForContentType(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+
+	codec, ok := codecs.byContentType[mediaType]
+	return codec, ok
+}
+
+type tomlCodec struct{}
+
+func (tomlCodec) Name() string {
+	return "TOML"
+}
+
+func (tomlCodec) Encode(v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (tomlCodec) Decode(buf []byte, v interface{}) error {
+	_, err := toml.Decode(string(buf), v)
+	return err
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "JSON"
+}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func (jsonCodec) Decode(buf []byte, v interface{}) error {
+	return json.Unmarshal(buf, v)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Name() string {
+	return "YAML"
+}
+
+func (yamlCodec) Encode(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (yamlCodec) Decode(buf []byte, v interface{}) error {
+	return yaml.Unmarshal(buf, v)
+}
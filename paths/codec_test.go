@@ -0,0 +1,129 @@
+package paths_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs(t *testing.T) {
+	t.Run("Getting codec for path succeeds", testGettingCodecForPathSucceeds)
+	t.Run("Getting codec for content type succeeds", testGettingCodecForContentTypeSucceeds)
+	t.Run("Writing and reading JSON file succeeds", testWritingAndReadingJSONFileSucceeds)
+	t.Run("Writing and reading YAML file succeeds", testWritingAndReadingYAMLFileSucceeds)
+	t.Run("Reading invalid file names the format", testReadingInvalidFileNamesTheFormat)
+	t.Run("Fetching JSON document succeeds", testFetchingJSONDocumentSucceeds)
+	t.Run("Fetching YAML document by URL extension succeeds", testFetchingYAMLDocumentByURLExtensionSucceeds)
+}
+
+func testGettingCodecForPathSucceeds(t *testing.T) {
+	assert.Equal(t, paths.TOMLCodec, paths.CodecForPath("/home/vega/config.toml"))
+	assert.Equal(t, paths.JSONCodec, paths.CodecForPath("/home/vega/config.JSON"))
+	assert.Equal(t, paths.YAMLCodec, paths.CodecForPath("/home/vega/config.yaml"))
+	assert.Equal(t, paths.YAMLCodec, paths.CodecForPath("/home/vega/config.yml"))
+	assert.Equal(t, paths.TOMLCodec, paths.CodecForPath("/home/vega/wallets.encrypted"))
+	assert.Equal(t, paths.TOMLCodec, paths.CodecForPath("/home/vega/config"))
+}
+
+func testGettingCodecForContentTypeSucceeds(t *testing.T) {
+	assert.Equal(t, paths.JSONCodec, paths.CodecForContentType("application/json; charset=utf-8"))
+	assert.Equal(t, paths.YAMLCodec, paths.CodecForContentType("application/x-yaml"))
+	assert.Equal(t, paths.TOMLCodec, paths.CodecForContentType("application/toml"))
+	assert.Equal(t, paths.TOMLCodec, paths.CodecForContentType("text/plain"))
+	assert.Equal(t, paths.TOMLCodec, paths.CodecForContentType(""))
+}
+
+func testWritingAndReadingJSONFileSucceeds(t *testing.T) {
+	path := vgtest.RandomPath() + ".json"
+	defer os.RemoveAll(path)
+	data := &DummyData{
+		Name: "Jane",
+		Age:  40,
+	}
+
+	err := paths.WriteStructuredFile(path, data)
+	require.NoError(t, err)
+
+	buf, err := vgfs.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Name": "Jane", "Age": 40}`, string(buf))
+
+	readData := &DummyData{}
+	err = paths.ReadStructuredFile(path, readData)
+	require.NoError(t, err)
+	assert.Equal(t, data, readData)
+}
+
+func testWritingAndReadingYAMLFileSucceeds(t *testing.T) {
+	path := vgtest.RandomPath() + ".yaml"
+	defer os.RemoveAll(path)
+	data := &DummyData{
+		Name: "Jane",
+		Age:  40,
+	}
+
+	err := paths.WriteStructuredFile(path, data)
+	require.NoError(t, err)
+
+	readData := &DummyData{}
+	err = paths.ReadStructuredFile(path, readData)
+	require.NoError(t, err)
+	assert.Equal(t, data, readData)
+}
+
+func testReadingInvalidFileNamesTheFormat(t *testing.T) {
+	tcs := []struct {
+		ext    string
+		format string
+	}{
+		{ext: ".toml", format: "TOML"},
+		{ext: ".json", format: "JSON"},
+		{ext: ".yaml", format: "YAML"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.format, func(tt *testing.T) {
+			path := vgtest.RandomPath() + tc.ext
+			defer os.RemoveAll(path)
+			require.NoError(tt, vgfs.WriteFile(path, []byte("{{ not valid :")))
+
+			err := paths.ReadStructuredFile(path, &DummyData{})
+			require.Error(tt, err)
+			assert.Contains(tt, err.Error(), "invalid "+tc.format+" file")
+		})
+	}
+}
+
+func testFetchingJSONDocumentSucceeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Name": "Jane", "Age": 40}`))
+	}))
+	defer server.Close()
+
+	readData := &DummyData{}
+	err := paths.FetchStructuredFile(server.URL+"/network", readData)
+	require.NoError(t, err)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
+
+func testFetchingYAMLDocumentByURLExtensionSucceeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("name: Jane\nage: 40\n"))
+	}))
+	defer server.Close()
+
+	readData := &DummyData{}
+	err := paths.FetchStructuredFile(server.URL+"/network.yaml", readData)
+	require.NoError(t, err)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
@@ -2,6 +2,7 @@ package paths
 
 import (
 	"fmt"
+	"io/fs"
 	"path/filepath"
 
 	vgfs "code.vegaprotocol.io/shared/libs/fs"
@@ -21,54 +22,62 @@ import (
 
 type CustomPaths struct {
 	CustomHome string
+
+	// FileSystem is the file system the directories are created on. It
+	// defaults to the OS file system.
+	FileSystem vgfs.FileSystem
+
+	// Permissions is the policy deciding the mode of the created directories.
+	// It defaults to the DefaultPermissions.
+	Permissions *PermissionPolicy
 }
 
 // CreateCacheDirFor builds the path for cache files at the configured home and
 // creates intermediate directories.
 func (p *CustomPaths) CreateCacheDirFor(relDirPath CachePath) (string, error) {
-	return CreateCustomCacheDirFor(p.CustomHome, relDirPath)
+	return createCustomDirIn(p.fileSystem(), CustomCachePathFor(p.CustomHome, relDirPath), p.Permissions.dirModeFor(CachePathCategory, relDirPath.String()))
 }
 
 // CreateCachePathFor builds the path for cache directories at the configured home
 // and creates intermediate directories.
 func (p *CustomPaths) CreateCachePathFor(relFilePath CachePath) (string, error) {
-	return CreateCustomCachePathFor(p.CustomHome, relFilePath)
+	return createCustomPathIn(p.fileSystem(), CustomCachePathFor(p.CustomHome, relFilePath), p.Permissions.parentDirModeFor(CachePathCategory, relFilePath.String()))
 }
 
 // CreateConfigDirFor builds the path for configuration files at a given configured
 // home and creates intermediate directories.
 func (p *CustomPaths) CreateConfigDirFor(relDirPath ConfigPath) (string, error) {
-	return CreateCustomConfigDirFor(p.CustomHome, relDirPath)
+	return createCustomDirIn(p.fileSystem(), CustomConfigPathFor(p.CustomHome, relDirPath), p.Permissions.dirModeFor(ConfigPathCategory, relDirPath.String()))
 }
 
 // CreateConfigPathFor builds the path for config directories at the configured
 // home and creates intermediate directories.
 func (p *CustomPaths) CreateConfigPathFor(relFilePath ConfigPath) (string, error) {
-	return CreateCustomConfigPathFor(p.CustomHome, relFilePath)
+	return createCustomPathIn(p.fileSystem(), CustomConfigPathFor(p.CustomHome, relFilePath), p.Permissions.parentDirModeFor(ConfigPathCategory, relFilePath.String()))
 }
 
 // CreateDataDirFor builds the path for data files at the configured home and
 // creates intermediate directories.
 func (p *CustomPaths) CreateDataDirFor(relDirPath DataPath) (string, error) {
-	return CreateCustomDataDirFor(p.CustomHome, relDirPath)
+	return createCustomDirIn(p.fileSystem(), CustomDataPathFor(p.CustomHome, relDirPath), p.Permissions.dirModeFor(DataPathCategory, relDirPath.String()))
 }
 
 // CreateDataPathFor builds the path for data directories at the configured home
 // and creates intermediate directories.
 func (p *CustomPaths) CreateDataPathFor(relFilePath DataPath) (string, error) {
-	return CreateCustomDataPathFor(p.CustomHome, relFilePath)
+	return createCustomPathIn(p.fileSystem(), CustomDataPathFor(p.CustomHome, relFilePath), p.Permissions.parentDirModeFor(DataPathCategory, relFilePath.String()))
 }
 
 // CreateStateDirFor builds the path for cache files at the configured home and
 // creates intermediate directories.
 func (p *CustomPaths) CreateStateDirFor(relDirPath StatePath) (string, error) {
-	return CreateCustomStateDirFor(p.CustomHome, relDirPath)
+	return createCustomDirIn(p.fileSystem(), CustomStatePathFor(p.CustomHome, relDirPath), p.Permissions.dirModeFor(StatePathCategory, relDirPath.String()))
 }
 
 // CreateStatePathFor builds the path for data directories at the configured home
 // and creates intermediate directories.
 func (p *CustomPaths) CreateStatePathFor(relFilePath StatePath) (string, error) {
-	return CreateCustomStatePathFor(p.CustomHome, relFilePath)
+	return createCustomPathIn(p.fileSystem(), CustomStatePathFor(p.CustomHome, relFilePath), p.Permissions.parentDirModeFor(StatePathCategory, relFilePath.String()))
 }
 
 // CachePathFor builds the path for a cache file or directories at the
@@ -95,96 +104,78 @@ func (p *CustomPaths) StatePathFor(relPath StatePath) string {
 	return CustomStatePathFor(p.CustomHome, relPath)
 }
 
+// Roots returns the root of every category, under the custom home.
+func (p *CustomPaths) Roots() map[PathCategory]Root {
+	return map[PathCategory]Root{
+		CachePathCategory:  {Path: CustomCachePathFor(p.CustomHome, ""), Origin: CustomHomeRootOrigin},
+		ConfigPathCategory: {Path: CustomConfigPathFor(p.CustomHome, ""), Origin: CustomHomeRootOrigin},
+		DataPathCategory:   {Path: CustomDataPathFor(p.CustomHome, ""), Origin: CustomHomeRootOrigin},
+		StatePathCategory:  {Path: CustomStatePathFor(p.CustomHome, ""), Origin: CustomHomeRootOrigin},
+	}
+}
+
+func (p *CustomPaths) fileSystem() vgfs.FileSystem {
+	return vgfs.OrDefault(p.FileSystem)
+}
+
+func (p *CustomPaths) permissionPolicy() *PermissionPolicy {
+	return p.Permissions
+}
+
 // CreateCustomCachePathFor builds the path for cache files at a given root path and
 // creates intermediate directories. It scoped the files under a "cache" folder,
 // and follow the default structure.
 func CreateCustomCachePathFor(customHome string, relFilePath CachePath) (string, error) {
-	fullPath := CustomCachePathFor(customHome, relFilePath)
-	dir := filepath.Dir(fullPath)
-	if err := vgfs.EnsureDir(dir); err != nil {
-		return "", fmt.Errorf("couldn't create directories for file: %w", err)
-	}
-	return fullPath, nil
+	return createCustomPathIn(vgfs.OSFileSystem{}, CustomCachePathFor(customHome, relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateCustomCacheDirFor builds the path for cache directories at a given root path
 // and creates intermediate directories. It scoped the files under a "data"
 // folder, and follow the default structure.
 func CreateCustomCacheDirFor(customHome string, relDirPath CachePath) (string, error) {
-	path := CustomCachePathFor(customHome, relDirPath)
-	if err := vgfs.EnsureDir(path); err != nil {
-		return "", fmt.Errorf("couldn't create directories: %w", err)
-	}
-	return path, nil
+	return createCustomDirIn(vgfs.OSFileSystem{}, CustomCachePathFor(customHome, relDirPath), vgfs.DefaultDirMode)
 }
 
 // CreateCustomConfigPathFor builds the path for configuration files at a given root
 // path and creates intermediate directories. It scoped the files under a
 // "config" folder, and follow the default structure.
 func CreateCustomConfigPathFor(customHome string, relFilePath ConfigPath) (string, error) {
-	fullPath := CustomConfigPathFor(customHome, relFilePath)
-	dir := filepath.Dir(fullPath)
-	if err := vgfs.EnsureDir(dir); err != nil {
-		return "", fmt.Errorf("couldn't create directories for file: %w", err)
-	}
-	return fullPath, nil
+	return createCustomPathIn(vgfs.OSFileSystem{}, CustomConfigPathFor(customHome, relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateCustomConfigDirFor builds the path for config directories at a given root path
 // and creates intermediate directories. It scoped the files under a "data"
 // folder, and follow the default structure.
 func CreateCustomConfigDirFor(customHome string, relDirPath ConfigPath) (string, error) {
-	path := CustomConfigPathFor(customHome, relDirPath)
-	if err := vgfs.EnsureDir(path); err != nil {
-		return "", fmt.Errorf("couldn't create directories: %w", err)
-	}
-	return path, nil
+	return createCustomDirIn(vgfs.OSFileSystem{}, CustomConfigPathFor(customHome, relDirPath), vgfs.DefaultDirMode)
 }
 
 // CreateCustomDataPathFor builds the path for data files at a given root path and
 // creates intermediate directories. It scoped the files under a "data" folder,
 // and follow the default structure.
 func CreateCustomDataPathFor(customHome string, relFilePath DataPath) (string, error) {
-	fullPath := CustomDataPathFor(customHome, relFilePath)
-	dir := filepath.Dir(fullPath)
-	if err := vgfs.EnsureDir(dir); err != nil {
-		return "", fmt.Errorf("couldn't create directories for file: %w", err)
-	}
-	return fullPath, nil
+	return createCustomPathIn(vgfs.OSFileSystem{}, CustomDataPathFor(customHome, relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateCustomDataDirFor builds the path for data directories at a given root path
 // and creates intermediate directories. It scoped the files under a "data"
 // folder, and follow the default structure.
 func CreateCustomDataDirFor(customHome string, relDirPath DataPath) (string, error) {
-	path := CustomDataPathFor(customHome, relDirPath)
-	if err := vgfs.EnsureDir(path); err != nil {
-		return "", fmt.Errorf("couldn't create directories: %w", err)
-	}
-	return path, nil
+	return createCustomDirIn(vgfs.OSFileSystem{}, CustomDataPathFor(customHome, relDirPath), vgfs.DefaultDirMode)
 }
 
 // CreateCustomStatePathFor builds the path for cache files at a given root path and
 // creates intermediate directories. It scoped the files under a "cache" folder,
 // and follow the default structure.
 func CreateCustomStatePathFor(customHome string, relFilePath StatePath) (string, error) {
-	fullPath := CustomStatePathFor(customHome, relFilePath)
-	dir := filepath.Dir(fullPath)
-	if err := vgfs.EnsureDir(dir); err != nil {
-		return "", fmt.Errorf("couldn't create directories for file: %w", err)
-	}
-	return fullPath, nil
+	return createCustomPathIn(vgfs.OSFileSystem{}, CustomStatePathFor(customHome, relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateCustomStateDirFor builds the path for data directories at a given root path
 // and creates intermediate directories. It scoped the files under a "data"
 // folder, and follow the default structure.
 func CreateCustomStateDirFor(customHome string, relDirPath StatePath) (string, error) {
-	path := CustomStatePathFor(customHome, relDirPath)
-	if err := vgfs.EnsureDir(path); err != nil {
-		return "", fmt.Errorf("couldn't create directories: %w", err)
-	}
-	return path, nil
+	return createCustomDirIn(vgfs.OSFileSystem{}, CustomStatePathFor(customHome, relDirPath), vgfs.DefaultDirMode)
 }
 
 // CustomCachePathFor builds the path for a cache file or directories at a given
@@ -210,3 +201,18 @@ func CustomDataPathFor(customHome string, relPath DataPath) string {
 func CustomStatePathFor(customHome string, relPath StatePath) string {
 	return filepath.Join(customHome, "state", relPath.String())
 }
+
+func createCustomPathIn(fsys vgfs.FileSystem, fullPath string, dirMode fs.FileMode) (string, error) {
+	dir := filepath.Dir(fullPath)
+	if err := vgfs.EnsureDirWithModeIn(fsys, dir, dirMode); err != nil {
+		return "", fmt.Errorf("couldn't create directories for file: %w", err)
+	}
+	return fullPath, nil
+}
+
+func createCustomDirIn(fsys vgfs.FileSystem, path string, dirMode fs.FileMode) (string, error) {
+	if err := vgfs.EnsureDirWithModeIn(fsys, path, dirMode); err != nil {
+		return "", fmt.Errorf("couldn't create directories: %w", err)
+	}
+	return path, nil
+}
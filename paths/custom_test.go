@@ -1,10 +1,12 @@
 package paths_test
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
 
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
 	vgtest "code.vegaprotocol.io/shared/libs/test"
 	"code.vegaprotocol.io/shared/paths"
 
@@ -98,3 +100,45 @@ func testGettingCustomPathFromStructForStateFileSucceeds(t *testing.T) {
 	vgtest.AssertDirAccess(t, filepath.Dir(home))
 	assert.Equal(t, filepath.Join(home, "state", "fake-file.empty"), path)
 }
+
+func TestCustomPathsOnFileSystem(t *testing.T) {
+	t.Run("Creating custom paths on in-memory file system succeeds", testCreatingCustomPathsOnInMemoryFileSystemSucceeds)
+	t.Run("Creating custom paths on failing file system fails", testCreatingCustomPathsOnFailingFileSystemFails)
+}
+
+func testCreatingCustomPathsOnInMemoryFileSystemSucceeds(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	home := vgtest.RandomPath()
+	p := &paths.CustomPaths{
+		CustomHome: home,
+		FileSystem: fsys,
+	}
+
+	path, err := p.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "config", "node", "config.toml"), path)
+
+	exists, err := vgfs.PathExistsIn(fsys, filepath.Dir(path))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Nothing should have been created on the disk.
+	exists, err = vgfs.PathExists(home)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.Equal(t, fsys, paths.FileSystemOf(p))
+}
+
+func testCreatingCustomPathsOnFailingFileSystemFails(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	fsys.InjectFault(vgfs.OpMkdirAll, "", fs.ErrPermission)
+	p := &paths.CustomPaths{
+		CustomHome: vgtest.RandomPath(),
+		FileSystem: fsys,
+	}
+
+	path, err := p.CreateStateDirFor(paths.NodeLogsHome)
+	require.ErrorIs(t, err, fs.ErrPermission)
+	assert.Empty(t, path)
+}
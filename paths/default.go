@@ -2,8 +2,11 @@ package paths
 
 import (
 	"fmt"
+	"io/fs"
 	"path/filepath"
 
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+
 	"github.com/adrg/xdg"
 )
 
@@ -33,54 +36,62 @@ import (
 // $XDG_STATE_HOME
 // └── vega
 
-type DefaultPaths struct{}
+type DefaultPaths struct {
+	// FileSystem is the file system the directories are created on. It
+	// defaults to the OS file system.
+	FileSystem vgfs.FileSystem
+
+	// Permissions is the policy deciding the mode of the created directories.
+	// It defaults to the DefaultPermissions.
+	Permissions *PermissionPolicy
+}
 
 // CreateCachePathFor builds the default path for a cache file and creates
 // intermediate directories, if needed.
 func (p *DefaultPaths) CreateCachePathFor(relFilePath CachePath) (string, error) {
-	return CreateDefaultCachePathFor(relFilePath)
+	return createDefaultPathIn(p.fileSystem(), DefaultCachePathFor(relFilePath), p.Permissions.parentDirModeFor(CachePathCategory, relFilePath.String()))
 }
 
 // CreateCacheDirFor builds the default path for a cache directory and creates
 // it, along with intermediate directories, if needed.
 func (p *DefaultPaths) CreateCacheDirFor(relDirPath CachePath) (string, error) {
-	return CreateDefaultCacheDirFor(relDirPath)
+	return createDefaultDirIn(p.fileSystem(), DefaultCachePathFor(relDirPath), p.Permissions.dirModeFor(CachePathCategory, relDirPath.String()))
 }
 
 // CreateConfigPathFor builds the default path for a configuration file and
 // creates intermediate directories, if needed.
 func (p *DefaultPaths) CreateConfigPathFor(relFilePath ConfigPath) (string, error) {
-	return CreateDefaultConfigPathFor(relFilePath)
+	return createDefaultPathIn(p.fileSystem(), DefaultConfigPathFor(relFilePath), p.Permissions.parentDirModeFor(ConfigPathCategory, relFilePath.String()))
 }
 
 // CreateConfigDirFor builds the default path for a config directory and creates
 // it, along with intermediate directories, if needed.
 func (p *DefaultPaths) CreateConfigDirFor(relDirPath ConfigPath) (string, error) {
-	return CreateDefaultConfigDirFor(relDirPath)
+	return createDefaultDirIn(p.fileSystem(), DefaultConfigPathFor(relDirPath), p.Permissions.dirModeFor(ConfigPathCategory, relDirPath.String()))
 }
 
 // CreateDataPathFor builds the default path for a data file and creates
 // intermediate directories, if needed.
 func (p *DefaultPaths) CreateDataPathFor(relFilePath DataPath) (string, error) {
-	return CreateDefaultDataPathFor(relFilePath)
+	return createDefaultPathIn(p.fileSystem(), DefaultDataPathFor(relFilePath), p.Permissions.parentDirModeFor(DataPathCategory, relFilePath.String()))
 }
 
 // CreateDataDirFor builds the default path for a data directory and creates
 // it, along with intermediate directories, if needed.
 func (p *DefaultPaths) CreateDataDirFor(relDirPath DataPath) (string, error) {
-	return CreateDefaultDataDirFor(relDirPath)
+	return createDefaultDirIn(p.fileSystem(), DefaultDataPathFor(relDirPath), p.Permissions.dirModeFor(DataPathCategory, relDirPath.String()))
 }
 
 // CreateStatePathFor builds the default path for a state file and creates
 // intermediate directories, if needed.
 func (p *DefaultPaths) CreateStatePathFor(relFilePath StatePath) (string, error) {
-	return CreateDefaultStatePathFor(relFilePath)
+	return createDefaultPathIn(p.fileSystem(), DefaultStatePathFor(relFilePath), p.Permissions.parentDirModeFor(StatePathCategory, relFilePath.String()))
 }
 
 // CreateStateDirFor builds the default path for a state directory and creates
 // it, along with intermediate directories, if needed.
 func (p *DefaultPaths) CreateStateDirFor(relDirPath StatePath) (string, error) {
-	return CreateDefaultStateDirFor(relDirPath)
+	return createDefaultDirIn(p.fileSystem(), DefaultStatePathFor(relDirPath), p.Permissions.dirModeFor(StatePathCategory, relDirPath.String()))
 }
 
 // CachePathFor build the default path for a cache file or directory. It
@@ -107,88 +118,70 @@ func (p *DefaultPaths) StatePathFor(relPath StatePath) string {
 	return DefaultStatePathFor(relPath)
 }
 
+// Roots returns the XDG root of every category.
+func (p *DefaultPaths) Roots() map[PathCategory]Root {
+	return map[PathCategory]Root{
+		CachePathCategory:  {Path: DefaultCachePathFor(""), Origin: XDGRootOrigin},
+		ConfigPathCategory: {Path: DefaultConfigPathFor(""), Origin: XDGRootOrigin},
+		DataPathCategory:   {Path: DefaultDataPathFor(""), Origin: XDGRootOrigin},
+		StatePathCategory:  {Path: DefaultStatePathFor(""), Origin: XDGRootOrigin},
+	}
+}
+
+func (p *DefaultPaths) fileSystem() vgfs.FileSystem {
+	return vgfs.OrDefault(p.FileSystem)
+}
+
+func (p *DefaultPaths) permissionPolicy() *PermissionPolicy {
+	return p.Permissions
+}
+
 // CreateDefaultCachePathFor builds the default path for a cache file and creates
 // intermediate directories, if needed.
 func CreateDefaultCachePathFor(relFilePath CachePath) (string, error) {
-	path, err := xdg.CacheFile(filepath.Join(VegaHome, relFilePath.String()))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory for file: %w", err)
-	}
-	return path, nil
+	return createDefaultPathIn(vgfs.OSFileSystem{}, DefaultCachePathFor(relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateDefaultCacheDirFor builds the default path for a cache directory and creates
 // it, along with intermediate directories, if needed.
 func CreateDefaultCacheDirFor(relDirPath CachePath) (string, error) {
-	// We append fake-file to xdg library creates all directory up to fake-file.
-	path, err := xdg.CacheFile(filepath.Join(VegaHome, relDirPath.String(), "fake-file"))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory: %w", err)
-	}
-	return filepath.Dir(path), nil
+	return createDefaultDirIn(vgfs.OSFileSystem{}, DefaultCachePathFor(relDirPath), vgfs.DefaultDirMode)
 }
 
 // CreateDefaultConfigPathFor builds the default path for a configuration file and
 // creates intermediate directories, if needed.
 func CreateDefaultConfigPathFor(relFilePath ConfigPath) (string, error) {
-	path, err := xdg.ConfigFile(filepath.Join(VegaHome, relFilePath.String()))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory for file: %w", err)
-	}
-	return path, nil
+	return createDefaultPathIn(vgfs.OSFileSystem{}, DefaultConfigPathFor(relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateDefaultConfigDirFor builds the default path for a config directory and creates
 // it, along with intermediate directories, if needed.
 func CreateDefaultConfigDirFor(relDirPath ConfigPath) (string, error) {
-	// We append fake-file to xdg library creates all directory up to fake-file.
-	path, err := xdg.ConfigFile(filepath.Join(VegaHome, relDirPath.String(), "fake-file"))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory: %w", err)
-	}
-	return filepath.Dir(path), nil
+	return createDefaultDirIn(vgfs.OSFileSystem{}, DefaultConfigPathFor(relDirPath), vgfs.DefaultDirMode)
 }
 
 // CreateDefaultDataPathFor builds the default path for a data file and creates
 // intermediate directories, if needed.
 func CreateDefaultDataPathFor(relFilePath DataPath) (string, error) {
-	path, err := xdg.DataFile(filepath.Join(VegaHome, relFilePath.String()))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory for file: %w", err)
-	}
-	return path, nil
+	return createDefaultPathIn(vgfs.OSFileSystem{}, DefaultDataPathFor(relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateDefaultDataDirFor builds the default path for a data directory and creates
 // it, along with intermediate directories, if needed.
 func CreateDefaultDataDirFor(relDirPath DataPath) (string, error) {
-	// We append fake-file to xdg library creates all directory up to fake-file.
-	path, err := xdg.DataFile(filepath.Join(VegaHome, relDirPath.String(), "fake-file"))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory: %w", err)
-	}
-	return filepath.Dir(path), nil
+	return createDefaultDirIn(vgfs.OSFileSystem{}, DefaultDataPathFor(relDirPath), vgfs.DefaultDirMode)
 }
 
 // CreateDefaultStatePathFor builds the default path for a state file and creates
 // intermediate directories, if needed.
 func CreateDefaultStatePathFor(relFilePath StatePath) (string, error) {
-	path, err := xdg.StateFile(filepath.Join(VegaHome, relFilePath.String()))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory for file: %w", err)
-	}
-	return path, nil
+	return createDefaultPathIn(vgfs.OSFileSystem{}, DefaultStatePathFor(relFilePath), vgfs.DefaultDirMode)
 }
 
 // CreateDefaultStateDirFor builds the default path for a state directory and creates
 // it, along with intermediate directories, if needed.
 func CreateDefaultStateDirFor(relDirPath StatePath) (string, error) {
-	// We append fake-file to xdg library creates all directory up to fake-file.
-	path, err := xdg.StateFile(filepath.Join(VegaHome, relDirPath.String(), "fake-file"))
-	if err != nil {
-		return "", fmt.Errorf("couldn't create the default directory: %w", err)
-	}
-	return filepath.Dir(path), nil
+	return createDefaultDirIn(vgfs.OSFileSystem{}, DefaultStatePathFor(relDirPath), vgfs.DefaultDirMode)
 }
 
 // DefaultCachePathFor build the default path for a cache file or directory. It
@@ -214,3 +207,17 @@ func DefaultDataPathFor(relPath DataPath) string {
 func DefaultStatePathFor(relPath StatePath) string {
 	return filepath.Join(xdg.StateHome, VegaHome, relPath.String())
 }
+
+func createDefaultPathIn(fsys vgfs.FileSystem, fullPath string, dirMode fs.FileMode) (string, error) {
+	if err := vgfs.EnsureDirWithModeIn(fsys, filepath.Dir(fullPath), dirMode); err != nil {
+		return "", fmt.Errorf("couldn't create the default directory for file: %w", err)
+	}
+	return fullPath, nil
+}
+
+func createDefaultDirIn(fsys vgfs.FileSystem, path string, dirMode fs.FileMode) (string, error) {
+	if err := vgfs.EnsureDirWithModeIn(fsys, path, dirMode); err != nil {
+		return "", fmt.Errorf("couldn't create the default directory: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,176 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+// DoctorIssueType identifies the problems detected by the doctor.
+type DoctorIssueType string
+
+const (
+	MissingPathIssue      DoctorIssueType = "missing"
+	WrongTypeIssue        DoctorIssueType = "wrong-type"
+	WrongPermissionsIssue DoctorIssueType = "wrong-permissions"
+	UnreadablePathIssue   DoctorIssueType = "unreadable"
+	ForeignOwnerIssue     DoctorIssueType = "foreign-owner"
+)
+
+type DoctorOptions struct {
+	// Repair restores the expected permissions on the paths that have wrong
+	// ones.
+	Repair bool
+
+	// IgnoreMissing doesn't report the paths that don't exist. Most of the
+	// paths are only created when the application using them runs for the
+	// first time, so a missing path is not necessarily a problem.
+	IgnoreMissing bool
+}
+
+type DoctorIssue struct {
+	Type     DoctorIssueType `json:"type"`
+	Message  string          `json:"message"`
+	Repaired bool            `json:"repaired,omitempty"`
+}
+
+// DoctorEntry is the diagnostic of a single path.
+type DoctorEntry struct {
+	Name        string        `json:"name"`
+	Category    PathCategory  `json:"category"`
+	Kind        PathKind      `json:"kind"`
+	Application string        `json:"application"`
+	Path        string        `json:"path"`
+	Issues      []DoctorIssue `json:"issues,omitempty"`
+}
+
+// DoctorReport is the diagnostic of the Vega file structure.
+type DoctorReport struct {
+	Entries []DoctorEntry `json:"entries"`
+}
+
+// Healthy tells if no issue remains once the repairs, if any, are applied.
+func (r *DoctorReport) Healthy() bool {
+	for _, entry := range r.Entries {
+		for _, issue := range entry.Issues {
+			if !issue.Repaired {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Doctor verifies every registered cache, config, data and state path against the
+// expected structure: directories must be directories, and files must be
+// files, with the permissions the permission policy of the Paths grants them,
+// by default 0700 and 0600. Every path must be readable, and owned by the
+// current user.
+//
+// The permissions are not verified on Windows, as they don't map to the Unix
+// ones.
+func Doctor(vegaPaths Paths, options DoctorOptions) *DoctorReport {
+	fsys := FileSystemOf(vegaPaths)
+
+	report := &DoctorReport{
+		Entries: []DoctorEntry{},
+	}
+
+	for _, definition := range RegisteredPaths() {
+		entry := DoctorEntry{
+			Name:        definition.Name,
+			Category:    definition.Category,
+			Kind:        definition.Kind,
+			Application: definition.Application,
+			Path:        definition.Resolve(vegaPaths),
+		}
+		entry.Issues = diagnosePath(fsys, entry.Path, entry.Kind, expectedPermFor(vegaPaths, definition), options)
+		report.Entries = append(report.Entries, entry)
+	}
+
+	return report
+}
+
+// expectedPermFor returns the permissions the permission policy of the Paths
+// grants the path of the definition.
+func expectedPermFor(vegaPaths Paths, definition PathDefinition) fs.FileMode {
+	permissions := PermissionsFor(vegaPaths, definition.Category, definition.Path)
+	if definition.Kind == DirPathKind {
+		return permissions.DirMode.Perm()
+	}
+	return permissions.FileMode.Perm()
+}
+
+func diagnosePath(fsys vgfs.FileSystem, path string, kind PathKind, expectedPerm fs.FileMode, options DoctorOptions) []DoctorIssue {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if options.IgnoreMissing {
+				return nil
+			}
+			return []DoctorIssue{{
+				Type:    MissingPathIssue,
+				Message: fmt.Sprintf("the %s doesn't exist", kind),
+			}}
+		}
+		return []DoctorIssue{{
+			Type:    UnreadablePathIssue,
+			Message: fmt.Sprintf("couldn't inspect the path: %v", err),
+		}}
+	}
+
+	if info.IsDir() != (kind == DirPathKind) {
+		return []DoctorIssue{{
+			Type:    WrongTypeIssue,
+			Message: fmt.Sprintf("expected a %s", kind),
+		}}
+	}
+
+	issues := []DoctorIssue{}
+
+	if isOwnedByAnotherUser(info) {
+		issues = append(issues, DoctorIssue{
+			Type:    ForeignOwnerIssue,
+			Message: "the path is owned by another user",
+		})
+	}
+
+	if checksPermissions {
+		if info.Mode().Perm() != expectedPerm {
+			issue := DoctorIssue{
+				Type:    WrongPermissionsIssue,
+				Message: fmt.Sprintf("expected permissions %#o, got %#o", expectedPerm, info.Mode().Perm()),
+			}
+			if options.Repair {
+				if err := fsys.Chmod(path, expectedPerm); err != nil {
+					issue.Message = fmt.Sprintf("%s, and couldn't be repaired: %v", issue.Message, err)
+				} else {
+					issue.Repaired = true
+				}
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	// Readability is verified after the repair, as wrong permissions are the
+	// usual cause of it.
+	if kind == DirPathKind {
+		_, err = fsys.ReadDir(path)
+	} else {
+		_, err = fsys.ReadFile(path)
+	}
+	if err != nil {
+		issues = append(issues, DoctorIssue{
+			Type:    UnreadablePathIssue,
+			Message: fmt.Sprintf("couldn't read the %s: %v", kind, err),
+		})
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return issues
+}
@@ -0,0 +1,183 @@
+package paths_test
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctor(t *testing.T) {
+	t.Run("Doctor reports missing paths", testDoctorReportsMissingPaths)
+	t.Run("Doctor ignores missing paths on demand", testDoctorIgnoresMissingPathsOnDemand)
+	t.Run("Doctor reports wrong types", testDoctorReportsWrongTypes)
+	t.Run("Doctor reports and repairs wrong permissions", testDoctorReportsAndRepairsWrongPermissions)
+	t.Run("Doctor expects the permissions of the policy", testDoctorExpectsThePermissionsOfThePolicy)
+	t.Run("Doctor reports unreadable paths", testDoctorReportsUnreadablePaths)
+	t.Run("Doctor report can be printed as JSON", testDoctorReportCanBePrintedAsJSON)
+}
+
+func testDoctorReportsMissingPaths(t *testing.T) {
+	vegaPaths := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: vgfs.NewMemoryFileSystem(),
+	}
+
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{})
+
+	assert.False(t, report.Healthy())
+	entry := findDoctorEntry(t, report, "NodeDefaultConfigFile")
+	assert.Equal(t, paths.ConfigPathCategory, entry.Category)
+	assert.Equal(t, paths.FilePathKind, entry.Kind)
+	require.Len(t, entry.Issues, 1)
+	assert.Equal(t, paths.MissingPathIssue, entry.Issues[0].Type)
+}
+
+func testDoctorIgnoresMissingPathsOnDemand(t *testing.T) {
+	vegaPaths := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: vgfs.NewMemoryFileSystem(),
+	}
+	_, err := vegaPaths.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true})
+
+	assert.True(t, report.Healthy())
+}
+
+func testDoctorReportsWrongTypes(t *testing.T) {
+	vegaPaths := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: vgfs.NewMemoryFileSystem(),
+	}
+	_, err := vegaPaths.CreateConfigDirFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true})
+
+	assert.False(t, report.Healthy())
+	entry := findDoctorEntry(t, report, "NodeDefaultConfigFile")
+	require.Len(t, entry.Issues, 1)
+	assert.Equal(t, paths.WrongTypeIssue, entry.Issues[0].Type)
+}
+
+func testDoctorReportsAndRepairsWrongPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not verified on Windows")
+	}
+
+	fsys := vgfs.NewMemoryFileSystem()
+	vegaPaths := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: fsys,
+	}
+	logsHome, err := vegaPaths.CreateStateDirFor(paths.NodeLogsHome)
+	require.NoError(t, err)
+	require.NoError(t, fsys.Chmod(logsHome, 0755))
+
+	// Diagnose only.
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true})
+
+	assert.False(t, report.Healthy())
+	entry := findDoctorEntry(t, report, "NodeLogsHome")
+	require.Len(t, entry.Issues, 1)
+	assert.Equal(t, paths.WrongPermissionsIssue, entry.Issues[0].Type)
+	assert.False(t, entry.Issues[0].Repaired)
+
+	// Repair.
+	report = paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true, Repair: true})
+
+	assert.True(t, report.Healthy())
+	entry = findDoctorEntry(t, report, "NodeLogsHome")
+	require.Len(t, entry.Issues, 1)
+	assert.True(t, entry.Issues[0].Repaired)
+
+	info, err := fsys.Stat(logsHome)
+	require.NoError(t, err)
+	assert.Equal(t, "-rwx------", info.Mode().Perm().String())
+
+	report = paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true})
+	assert.Empty(t, findDoctorEntry(t, report, "NodeLogsHome").Issues)
+}
+
+func testDoctorExpectsThePermissionsOfThePolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not verified on Windows")
+	}
+
+	fsys := vgfs.NewMemoryFileSystem()
+	vegaPaths := &paths.CustomPaths{
+		CustomHome:  "/vega",
+		FileSystem:  fsys,
+		Permissions: paths.DefaultPermissionPolicy(),
+	}
+	vegaPaths.Permissions.OverrideStatePath(paths.DataNodeStorageHome, paths.Permissions{DirMode: 0750})
+	storageHome, err := vegaPaths.CreateStateDirFor(paths.DataNodeStorageHome)
+	require.NoError(t, err)
+
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true, Repair: true})
+
+	assert.True(t, report.Healthy())
+	assert.Empty(t, findDoctorEntry(t, report, "DataNodeStorageHome").Issues)
+	info, err := fsys.Stat(storageHome)
+	require.NoError(t, err)
+	assert.Equal(t, "-rwxr-x---", info.Mode().Perm().String())
+
+	// The mode of the policy is expected, not the default one.
+	require.NoError(t, fsys.Chmod(storageHome, 0700))
+	report = paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true})
+	entry := findDoctorEntry(t, report, "DataNodeStorageHome")
+	require.Len(t, entry.Issues, 1)
+	assert.Equal(t, paths.WrongPermissionsIssue, entry.Issues[0].Type)
+	assert.Contains(t, entry.Issues[0].Message, "expected permissions 0750")
+}
+
+func testDoctorReportsUnreadablePaths(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	vegaPaths := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: fsys,
+	}
+	configFile, err := vegaPaths.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+	require.NoError(t, vgfs.WriteFileIn(fsys, configFile, []byte("config")))
+	fsys.InjectFault(vgfs.OpReadFile, configFile, errors.New("permission denied"))
+
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{IgnoreMissing: true})
+
+	entry := findDoctorEntry(t, report, "NodeDefaultConfigFile")
+	require.Len(t, entry.Issues, 1)
+	assert.Equal(t, paths.UnreadablePathIssue, entry.Issues[0].Type)
+}
+
+func testDoctorReportCanBePrintedAsJSON(t *testing.T) {
+	vegaPaths := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: vgfs.NewMemoryFileSystem(),
+	}
+
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{})
+
+	buf, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), `"type":"missing"`)
+}
+
+func findDoctorEntry(t *testing.T, report *paths.DoctorReport, name string) paths.DoctorEntry {
+	t.Helper()
+
+	for _, entry := range report.Entries {
+		if entry.Name == name {
+			return entry
+		}
+	}
+	require.Failf(t, "entry not found", "no entry named %s", name)
+	return paths.DoctorEntry{}
+}
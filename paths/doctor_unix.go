@@ -0,0 +1,23 @@
+//go:build !windows
+
+package paths
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// checksPermissions tells if the doctor verifies the permissions.
+const checksPermissions = true
+
+// isOwnedByAnotherUser tells if the file belongs to another user than the
+// current one. It can't tell for files that don't come from the OS file
+// system, and reports them as owned by the current user.
+func isOwnedByAnotherUser(info fs.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	return int(stat.Uid) != os.Getuid()
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package paths
+
+import "io/fs"
+
+// checksPermissions tells if the doctor verifies the permissions. The Windows
+// access control lists don't map to the Unix permissions, so they are not
+// verified.
+const checksPermissions = false
+
+// isOwnedByAnotherUser is not supported on Windows. It reports all the files
+// as owned by the current user.
+func isOwnedByAnotherUser(_ fs.FileInfo) bool {
+	return false
+}
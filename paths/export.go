@@ -0,0 +1,149 @@
+package paths
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	vgstrings "code.vegaprotocol.io/shared/libs/strings"
+)
+
+// EnvVarPrefix prefixes the names of the environment variables the paths are
+// exported to.
+const EnvVarPrefix = "VEGA_"
+
+// ExportFormat is a format the resolved paths can be exported to, as
+// environment variables.
+type ExportFormat string
+
+const (
+	// ShellExportFormat renders `export NAME='value'` lines, to be sourced by
+	// a POSIX shell.
+	ShellExportFormat ExportFormat = "shell"
+	// SystemdExportFormat renders `NAME="value"` lines, for the
+	// `EnvironmentFile` directive of a systemd unit.
+	SystemdExportFormat ExportFormat = "systemd"
+	// DockerExportFormat renders `NAME=value` lines, for the `--env-file`
+	// flag of docker. Docker doesn't interpret quotes, so the values are
+	// written as is.
+	DockerExportFormat ExportFormat = "docker"
+)
+
+// ExportFormats returns the supported export formats.
+func ExportFormats() []ExportFormat {
+	return []ExportFormat{
+		ShellExportFormat,
+		SystemdExportFormat,
+		DockerExportFormat,
+	}
+}
+
+// ParseExportFormat returns the export format with the given name.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	for _, format := range ExportFormats() {
+		if string(format) == strings.ToLower(name) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported export format %q", name)
+}
+
+// EnvVar is a resolved path exported as an environment variable.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// EnvVarNameFor derives the name of the environment variable from the name of
+// a path. For example, "NodeConfigHome" becomes "VEGA_NODE_CONFIG_HOME", and
+// "WalletServiceRSAKeysDataHome" becomes "VEGA_WALLET_SERVICE_RSA_KEYS_DATA_HOME".
+func EnvVarNameFor(pathName string) string {
+	return EnvVarPrefix + vgstrings.ToScreamingSnakeCase(pathName)
+}
+
+// EnvVars returns the paths of the response as environment variables, sorted
+// by name.
+func (r *ListPathsResponse) EnvVars() []EnvVar {
+	envVars := []EnvVar{}
+	for _, categoryPaths := range []map[string]string{r.CachePaths, r.ConfigPaths, r.DataPaths, r.StatePaths} {
+		for name, path := range categoryPaths {
+			envVars = append(envVars, EnvVar{
+				Name:  EnvVarNameFor(name),
+				Value: path,
+			})
+		}
+	}
+
+	sort.Slice(envVars, func(i, j int) bool {
+		return envVars[i].Name < envVars[j].Name
+	})
+
+	return envVars
+}
+
+// Export writes the paths of the response as environment variables, in the
+// given format.
+func (r *ListPathsResponse) Export(w io.Writer, format ExportFormat) error {
+	return ExportEnvVars(w, r.EnvVars(), format)
+}
+
+// ExportEnvVars writes the environment variables in the given format:
+//   - shell: `export NAME='value'`, with the single quotes of the value
+//     escaped,
+//   - systemd: `NAME="value"`, with the double quotes and the backslashes of
+//     the value escaped,
+//   - docker: `NAME=value`, with the value written as is, as docker reads
+//     everything after the first `=` literally, quotes included.
+//
+// The systemd and docker formats can't represent line breaks, so the values
+// containing some are refused.
+func ExportEnvVars(w io.Writer, envVars []EnvVar, format ExportFormat) error {
+	var render func(EnvVar) (string, error)
+	switch format {
+	case ShellExportFormat:
+		render = renderShellEnvVar
+	case SystemdExportFormat:
+		render = renderSystemdEnvVar
+	case DockerExportFormat:
+		render = renderDockerEnvVar
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+
+	for _, envVar := range envVars {
+		line, err := render(envVar)
+		if err != nil {
+			return fmt.Errorf("couldn't export %s: %w", envVar.Name, err)
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return fmt.Errorf("couldn't write %s: %w", envVar.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func renderShellEnvVar(envVar EnvVar) (string, error) {
+	// Nothing is interpreted between single quotes, so the only character to
+	// escape is the single quote itself, by closing and reopening the quotes.
+	value := strings.ReplaceAll(envVar.Value, `'`, `'\''`)
+	return fmt.Sprintf("export %s='%s'", envVar.Name, value), nil
+}
+
+func renderSystemdEnvVar(envVar EnvVar) (string, error) {
+	if strings.ContainsAny(envVar.Value, "\r\n") {
+		return "", fmt.Errorf("the value contains a line break")
+	}
+
+	value := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(envVar.Value)
+	return fmt.Sprintf(`%s="%s"`, envVar.Name, value), nil
+}
+
+func renderDockerEnvVar(envVar EnvVar) (string, error) {
+	if strings.ContainsAny(envVar.Value, "\r\n") {
+		return "", fmt.Errorf("the value contains a line break")
+	}
+
+	return fmt.Sprintf("%s=%s", envVar.Name, envVar.Value), nil
+}
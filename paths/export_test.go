@@ -0,0 +1,112 @@
+package paths_test
+
+import (
+	"bytes"
+	"testing"
+
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	t.Run("Deriving environment variable names succeeds", testDerivingEnvironmentVariableNamesSucceeds)
+	t.Run("Listing environment variables sorts them by name", testListingEnvironmentVariablesSortsThemByName)
+	t.Run("Exporting to shell quotes the values", testExportingToShellQuotesTheValues)
+	t.Run("Exporting to systemd quotes the values", testExportingToSystemdQuotesTheValues)
+	t.Run("Exporting to docker writes the values as is", testExportingToDockerWritesTheValuesAsIs)
+	t.Run("Exporting line breaks to env files fails", testExportingLineBreaksToEnvFilesFails)
+	t.Run("Exporting to unsupported format fails", testExportingToUnsupportedFormatFails)
+}
+
+func testDerivingEnvironmentVariableNamesSucceeds(t *testing.T) {
+	tcs := map[string]string{
+		"NodeConfigHome":                    "VEGA_NODE_CONFIG_HOME",
+		"WalletCLIConfigHome":               "VEGA_WALLET_CLI_CONFIG_HOME",
+		"WalletServiceRSAKeysDataHome":      "VEGA_WALLET_SERVICE_RSA_KEYS_DATA_HOME",
+		"WalletServicePublicRSAKeyDataFile": "VEGA_WALLET_SERVICE_PUBLIC_RSA_KEY_DATA_FILE",
+		"SnapshotDBStateFile":               "VEGA_SNAPSHOT_DB_STATE_FILE",
+		"my-app.config":                     "VEGA_MY_APP_CONFIG",
+	}
+
+	for pathName, expected := range tcs {
+		assert.Equal(t, expected, paths.EnvVarNameFor(pathName), pathName)
+	}
+}
+
+func testListingEnvironmentVariablesSortsThemByName(t *testing.T) {
+	response := &paths.ListPathsResponse{
+		ConfigPaths: map[string]string{"NodeConfigHome": "/vega/config/node"},
+		StatePaths:  map[string]string{"DataNodeStateHome": "/vega/state/data-node"},
+	}
+
+	assert.Equal(t, []paths.EnvVar{
+		{Name: "VEGA_DATA_NODE_STATE_HOME", Value: "/vega/state/data-node"},
+		{Name: "VEGA_NODE_CONFIG_HOME", Value: "/vega/config/node"},
+	}, response.EnvVars())
+}
+
+func testExportingToShellQuotesTheValues(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := paths.ExportEnvVars(buf, exportedEnvVars(), paths.ShellExportFormat)
+
+	require.NoError(t, err)
+	assert.Equal(t, `export VEGA_NODE_CONFIG_HOME='/home/my user/vega/config/node'
+export VEGA_NODE_STATE_HOME='/home/o'\''brien/$HOME/"state"'
+`, buf.String())
+}
+
+func testExportingToSystemdQuotesTheValues(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := paths.ExportEnvVars(buf, exportedEnvVars(), paths.SystemdExportFormat)
+
+	require.NoError(t, err)
+	assert.Equal(t, `VEGA_NODE_CONFIG_HOME="/home/my user/vega/config/node"
+VEGA_NODE_STATE_HOME="/home/o'brien/$HOME/\"state\""
+`, buf.String())
+}
+
+func testExportingToDockerWritesTheValuesAsIs(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := paths.ExportEnvVars(buf, exportedEnvVars(), paths.DockerExportFormat)
+
+	require.NoError(t, err)
+	assert.Equal(t, `VEGA_NODE_CONFIG_HOME=/home/my user/vega/config/node
+VEGA_NODE_STATE_HOME=/home/o'brien/$HOME/"state"
+`, buf.String())
+}
+
+func testExportingLineBreaksToEnvFilesFails(t *testing.T) {
+	envVars := []paths.EnvVar{{Name: "VEGA_NODE_CONFIG_HOME", Value: "/home/vega\n/config"}}
+
+	for _, format := range []paths.ExportFormat{paths.SystemdExportFormat, paths.DockerExportFormat} {
+		err := paths.ExportEnvVars(&bytes.Buffer{}, envVars, format)
+		assert.Error(t, err, format)
+	}
+
+	err := paths.ExportEnvVars(&bytes.Buffer{}, envVars, paths.ShellExportFormat)
+	assert.NoError(t, err)
+}
+
+func testExportingToUnsupportedFormatFails(t *testing.T) {
+	_, err := paths.ParseExportFormat("powershell")
+	assert.Error(t, err)
+
+	format, err := paths.ParseExportFormat("Systemd")
+	require.NoError(t, err)
+	assert.Equal(t, paths.SystemdExportFormat, format)
+
+	err = paths.ExportEnvVars(&bytes.Buffer{}, exportedEnvVars(), paths.ExportFormat("powershell"))
+	assert.Error(t, err)
+}
+
+func exportedEnvVars() []paths.EnvVar {
+	return []paths.EnvVar{
+		{Name: "VEGA_NODE_CONFIG_HOME", Value: "/home/my user/vega/config/node"},
+		{Name: "VEGA_NODE_STATE_HOME", Value: `/home/o'brien/$HOME/"state"`},
+	}
+}
@@ -0,0 +1,264 @@
+package paths
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultFetchTimeout is the timeout of the HTTP client used to fetch
+	// structured files, when none is specified.
+	DefaultFetchTimeout = 30 * time.Second
+
+	// DefaultMaxFetchedFileSize is the maximum size of a fetched structured
+	// file, when none is specified.
+	DefaultMaxFetchedFileSize int64 = 10 * 1024 * 1024
+)
+
+var ErrEmptyResponse = errors.New("empty response")
+
+// UnreachableSourceError is returned when the request couldn't reach the
+// server, or didn't get a response.
+type UnreachableSourceError struct {
+	URL string
+	Err error
+}
+
+func (e UnreachableSourceError) Error() string {
+	return fmt.Sprintf("couldn't load file from %s: %v", e.URL, e.Err)
+}
+
+func (e UnreachableSourceError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatusError is returned when the server doesn't reply with a 200 OK.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %d (%s) from %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// ResponseTooLargeError is returned when the body of the response exceeds
+// the maximum size allowed.
+type ResponseTooLargeError struct {
+	URL     string
+	MaxSize int64
+}
+
+func (e ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response from %s exceeds the maximum size of %d bytes", e.URL, e.MaxSize)
+}
+
+// ChecksumMismatchError is returned when the SHA-256 of the body of the
+// response doesn't match the expected one.
+type ChecksumMismatchError struct {
+	URL      string
+	Expected string
+	Actual   string
+}
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum of the response from %s is %s, expected %s", e.URL, e.Actual, e.Expected)
+}
+
+// VerificationError is returned when the custom verification of the body of
+// the response, like a signature check, fails.
+type VerificationError struct {
+	URL string
+	Err error
+}
+
+func (e VerificationError) Error() string {
+	return fmt.Sprintf("couldn't verify the response from %s: %v", e.URL, e.Err)
+}
+
+func (e VerificationError) Unwrap() error {
+	return e.Err
+}
+
+type FetchOptions struct {
+	// Client is the HTTP client used to fetch the file. It defaults to a
+	// client with a timeout of DefaultFetchTimeout.
+	Client *http.Client
+
+	// MaxSize is the maximum size of the body of the response, in bytes. It
+	// defaults to DefaultMaxFetchedFileSize.
+	MaxSize int64
+
+	// ExpectedSHA256 is the hex-encoded SHA-256 the body of the response must
+	// match. The check is skipped if empty.
+	ExpectedSHA256 string
+
+	// Verify is an optional custom verification of the body of the response,
+	// like a signature check. It is called before decoding.
+	Verify func(body []byte) error
+}
+
+// FetchStructuredFile fetches a structured file with the default options.
+func FetchStructuredFile(url string, v interface{}) error {
+	return FetchStructuredFileWithContext(context.Background(), url, v, FetchOptions{})
+}
+
+// FetchStructuredFileWithContext fetches the structured file at the given URL,
+// verifies it, and decodes it into v. The codec is picked from the content
+// type of the response, or from the extension of the URL.
+func FetchStructuredFileWithContext(ctx context.Context, url string, v interface{}, options FetchOptions) error {
+	doc, err := fetchDocument(ctx, url, options, nil)
+	if err != nil {
+		return err
+	}
+
+	return doc.decode(v)
+}
+
+type fetchedDocument struct {
+	url         string
+	statusCode  int
+	header      http.Header
+	contentType string
+	body        []byte
+}
+
+func (d *fetchedDocument) decode(v interface{}) error {
+	codec := codecForResponse(d.url, d.contentType)
+	if err := codec.Decode(d.body, v); err != nil {
+		return fmt.Errorf("invalid %s document: %w", codec.Name(), err)
+	}
+	return nil
+}
+
+// fetchDocument fetches the document at the given URL, and runs the checks
+// required by the options. The additional headers are added to the request.
+// A 304 Not Modified is returned as is, without body, so the caller can use its
+// cached copy.
+func fetchDocument(ctx context.Context, url string, options FetchOptions, header http.Header) (*fetchedDocument, error) {
+	client := options.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultFetchTimeout}
+	}
+
+	maxSize := options.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxFetchedFileSize
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't build the request: %w", err)
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, UnreachableSourceError{
+			URL: url,
+			Err: err,
+		}
+	}
+	defer resp.Body.Close()
+
+	doc := &fetchedDocument{
+		url:         url,
+		statusCode:  resp.StatusCode,
+		header:      resp.Header,
+		contentType: resp.Header.Get("Content-Type"),
+	}
+
+	if resp.StatusCode == http.StatusNotModified && header != nil {
+		return doc, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, HTTPStatusError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	if resp.ContentLength > maxSize {
+		return nil, ResponseTooLargeError{
+			URL:     url,
+			MaxSize: maxSize,
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read HTTP response body: %w", err)
+	}
+
+	if int64(len(body)) > maxSize {
+		return nil, ResponseTooLargeError{
+			URL:     url,
+			MaxSize: maxSize,
+		}
+	}
+
+	if len(body) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	if err := verifyBody(url, body, options); err != nil {
+		return nil, err
+	}
+
+	doc.body = body
+
+	return doc, nil
+}
+
+func verifyBody(url string, body []byte, options FetchOptions) error {
+	if options.ExpectedSHA256 != "" {
+		sum := sha256.Sum256(body)
+		actual := hex.EncodeToString(sum[:])
+		if !strings.EqualFold(actual, options.ExpectedSHA256) {
+			return ChecksumMismatchError{
+				URL:      url,
+				Expected: options.ExpectedSHA256,
+				Actual:   actual,
+			}
+		}
+	}
+
+	if options.Verify != nil {
+		if err := options.Verify(body); err != nil {
+			return VerificationError{
+				URL: url,
+				Err: err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// codecForResponse picks the codec from the content type of the response,
+// falling back on the extension of the URL, as servers commonly serve
+// structured files as plain text.
+func codecForResponse(rawURL string, contentType string) Codec {
+	if codec, ok := codecForContentType(contentType); ok {
+		return codec
+	}
+
+	if u, err := url.Parse(rawURL); err == nil {
+		return CodecForPath(u.Path)
+	}
+
+	return DefaultCodec
+}
@@ -0,0 +1,212 @@
+package paths
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+type CachedFetchOptions struct {
+	FetchOptions
+
+	// TTL is the duration during which a cached copy is used without
+	// contacting the source. When it's 0, the cached copy is revalidated
+	// against the source on every fetch.
+	TTL time.Duration
+}
+
+// CachedFetchResult describes where the fetched content comes from.
+type CachedFetchResult struct {
+	// FromCache tells if the content has been read from the cached copy.
+	FromCache bool `json:"fromCache"`
+	// FetchedAt is the last time the content has been fetched, or revalidated,
+	// from the source.
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Age is the time elapsed since FetchedAt.
+	Age time.Duration `json:"age"`
+	// SourceErr is the error that prevented the content from being fetched
+	// from the source, when the cached copy is used as a fallback.
+	SourceErr error `json:"-"`
+}
+
+type cachedFileMetadata struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+	// BodyHash is the hex-encoded SHA-256 of the cached body, so metadata left
+	// over from a previous body is never trusted.
+	BodyHash string `json:"bodyHash"`
+}
+
+// FetchStructuredFileWithCache behaves like FetchStructuredFileWithContext,
+// but keeps a copy of every fetched document under the FetchedFilesCacheHome.
+//
+// The cached copy is used as is during the TTL. After that, it is revalidated
+// against the source with its ETag and Last-Modified headers. When the source
+// is unreachable, or fails on its side, the cached copy is used as a fallback,
+// and the result reports its age and the error from the source.
+func FetchStructuredFileWithCache(ctx context.Context, vegaPaths Paths, url string, v interface{}, options CachedFetchOptions) (*CachedFetchResult, error) {
+	fsys := FileSystemOf(vegaPaths)
+	metadataPath, bodyPath, err := cachedFilePathsFor(vegaPaths, url)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, body, hasCache := loadCachedFile(fsys, metadataPath, bodyPath)
+
+	if hasCache && options.TTL > 0 && time.Since(metadata.FetchedAt) < options.TTL {
+		return decodeCachedFile(metadata, body, v, nil)
+	}
+
+	header := http.Header{}
+	if hasCache {
+		if metadata.ETag != "" {
+			header.Set("If-None-Match", metadata.ETag)
+		}
+		if metadata.LastModified != "" {
+			header.Set("If-Modified-Since", metadata.LastModified)
+		}
+	}
+
+	doc, err := fetchDocument(ctx, url, options.FetchOptions, header)
+	if err != nil {
+		if hasCache && isSourceFailure(ctx, err) {
+			return decodeCachedFile(metadata, body, v, err)
+		}
+		return nil, err
+	}
+
+	if doc.statusCode == http.StatusNotModified {
+		if !hasCache {
+			return nil, HTTPStatusError{URL: url, StatusCode: doc.statusCode}
+		}
+		metadata.FetchedAt = time.Now()
+		if err := saveCachedFileMetadata(fsys, metadataPath, metadata); err != nil {
+			return nil, err
+		}
+		return decodeCachedFile(metadata, body, v, nil)
+	}
+
+	if err := doc.decode(v); err != nil {
+		return nil, err
+	}
+
+	metadata = &cachedFileMetadata{
+		URL:          url,
+		ETag:         doc.header.Get("ETag"),
+		LastModified: doc.header.Get("Last-Modified"),
+		ContentType:  doc.contentType,
+		FetchedAt:    time.Now(),
+		BodyHash:     bodyHashOf(doc.body),
+	}
+
+	// The body is written first, so the metadata never describes a body that
+	// has not been written.
+	if err := vgfs.WriteFileAtomicallyIn(fsys, bodyPath, doc.body); err != nil {
+		return nil, fmt.Errorf("couldn't cache the fetched file: %w", err)
+	}
+	if err := saveCachedFileMetadata(fsys, metadataPath, metadata); err != nil {
+		return nil, err
+	}
+
+	return &CachedFetchResult{
+		FromCache: false,
+		FetchedAt: metadata.FetchedAt,
+	}, nil
+}
+
+// cachedFilePathsFor returns the paths of the metadata and the body of the
+// cached copy of the document at the given URL.
+func cachedFilePathsFor(vegaPaths Paths, url string) (string, string, error) {
+	sum := sha256.Sum256([]byte(url))
+	key := hex.EncodeToString(sum[:])
+
+	metadataPath, err := vegaPaths.CreateCachePathFor(JoinCachePath(FetchedFilesCacheHome, key+".json"))
+	if err != nil {
+		return "", "", fmt.Errorf("couldn't create the cache directory: %w", err)
+	}
+
+	return metadataPath, vegaPaths.CachePathFor(JoinCachePath(FetchedFilesCacheHome, key+".body")), nil
+}
+
+func loadCachedFile(fsys vgfs.FileSystem, metadataPath, bodyPath string) (*cachedFileMetadata, []byte, bool) {
+	rawMetadata, err := vgfs.ReadFileIn(fsys, metadataPath)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	metadata := &cachedFileMetadata{}
+	if err := json.Unmarshal(rawMetadata, metadata); err != nil {
+		return nil, nil, false
+	}
+
+	body, err := vgfs.ReadFileIn(fsys, bodyPath)
+	if err != nil || len(body) == 0 || bodyHashOf(body) != metadata.BodyHash {
+		return nil, nil, false
+	}
+
+	return metadata, body, true
+}
+
+func saveCachedFileMetadata(fsys vgfs.FileSystem, metadataPath string, metadata *cachedFileMetadata) error {
+	buf, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal the cache metadata: %w", err)
+	}
+
+	if err := vgfs.WriteFileAtomicallyIn(fsys, metadataPath, buf); err != nil {
+		return fmt.Errorf("couldn't write the cache metadata: %w", err)
+	}
+
+	return nil
+}
+
+func bodyHashOf(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func decodeCachedFile(metadata *cachedFileMetadata, body []byte, v interface{}, sourceErr error) (*CachedFetchResult, error) {
+	doc := &fetchedDocument{
+		url:         metadata.URL,
+		contentType: metadata.ContentType,
+		body:        body,
+	}
+
+	if err := doc.decode(v); err != nil {
+		return nil, fmt.Errorf("couldn't decode the cached file: %w", err)
+	}
+
+	return &CachedFetchResult{
+		FromCache: true,
+		FetchedAt: metadata.FetchedAt,
+		Age:       time.Since(metadata.FetchedAt),
+		SourceErr: sourceErr,
+	}, nil
+}
+
+// isSourceFailure tells if the error comes from the source being unreachable or
+// failing on its side, as opposed to a cancellation from the caller, or an
+// invalid document.
+func isSourceFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var unreachableErr UnreachableSourceError
+	if errors.As(err, &unreachableErr) {
+		return true
+	}
+
+	var statusErr HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusInternalServerError
+}
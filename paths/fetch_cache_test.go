@@ -0,0 +1,184 @@
+package paths_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchingStructuredFileWithCache(t *testing.T) {
+	t.Run("Fetching document for the first time hits the source", testCachedFetchingForFirstTimeHitsSource)
+	t.Run("Fetching document within TTL uses the cache", testCachedFetchingWithinTTLUsesCache)
+	t.Run("Fetching unmodified document revalidates the cache", testCachedFetchingUnmodifiedDocumentRevalidatesCache)
+	t.Run("Fetching document from unreachable source falls back on cache", testCachedFetchingFromUnreachableSourceFallsBackOnCache)
+	t.Run("Fetching document from unreachable source without cache fails", testCachedFetchingFromUnreachableSourceWithoutCacheFails)
+	t.Run("Failing to cache the document keeps the previous copy", testCachedFetchingFailingToCacheKeepsThePreviousCopy)
+	t.Run("Cached body not matching its metadata is ignored", testCachedFetchingBodyNotMatchingItsMetadataIsIgnored)
+}
+
+func testCachedFetchingForFirstTimeHitsSource(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	server, hits := serveCacheableDocument(t)
+
+	readData := &DummyData{}
+	result, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), server.URL, readData, paths.CachedFetchOptions{})
+	require.NoError(t, err)
+	assert.False(t, result.FromCache)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+	assert.Equal(t, int32(1), atomic.LoadInt32(hits))
+}
+
+func testCachedFetchingWithinTTLUsesCache(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	server, hits := serveCacheableDocument(t)
+	options := paths.CachedFetchOptions{TTL: time.Hour}
+
+	_, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), server.URL, &DummyData{}, options)
+	require.NoError(t, err)
+
+	readData := &DummyData{}
+	result, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), server.URL, readData, options)
+	require.NoError(t, err)
+	assert.True(t, result.FromCache)
+	assert.NoError(t, result.SourceErr)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+	assert.Equal(t, int32(1), atomic.LoadInt32(hits))
+}
+
+func testCachedFetchingUnmodifiedDocumentRevalidatesCache(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	server, hits := serveCacheableDocument(t)
+
+	_, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), server.URL, &DummyData{}, paths.CachedFetchOptions{})
+	require.NoError(t, err)
+
+	readData := &DummyData{}
+	result, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), server.URL, readData, paths.CachedFetchOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.FromCache)
+	assert.NoError(t, result.SourceErr)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+	assert.Equal(t, int32(2), atomic.LoadInt32(hits))
+}
+
+func testCachedFetchingFromUnreachableSourceFallsBackOnCache(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	server, _ := serveCacheableDocument(t)
+	url := server.URL
+
+	_, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), url, &DummyData{}, paths.CachedFetchOptions{})
+	require.NoError(t, err)
+
+	server.Close()
+
+	readData := &DummyData{}
+	result, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), url, readData, paths.CachedFetchOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.FromCache)
+	assert.Error(t, result.SourceErr)
+	assert.Greater(t, result.Age, time.Duration(0))
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
+
+func testCachedFetchingFromUnreachableSourceWithoutCacheFails(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	server, _ := serveCacheableDocument(t)
+	server.Close()
+
+	readData := &DummyData{}
+	result, err := paths.FetchStructuredFileWithCache(context.Background(), paths.New(home), server.URL, readData, paths.CachedFetchOptions{})
+
+	var unreachableErr paths.UnreachableSourceError
+	require.ErrorAs(t, err, &unreachableErr)
+	assert.Nil(t, result)
+	assert.Empty(t, readData)
+}
+
+func testCachedFetchingFailingToCacheKeepsThePreviousCopy(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	vegaPaths := &paths.CustomPaths{CustomHome: "/vega", FileSystem: fsys}
+	server, _ := serveCacheableDocument(t)
+	url := server.URL
+
+	_, err := paths.FetchStructuredFileWithCache(context.Background(), vegaPaths, url, &DummyData{}, paths.CachedFetchOptions{})
+	require.NoError(t, err)
+	cacheHome := vegaPaths.CachePathFor(paths.FetchedFilesCacheHome)
+	fsys.InjectFault(vgfs.OpRename, cacheHome, errors.New("disk full"))
+
+	_, err = paths.FetchStructuredFileWithCache(context.Background(), vegaPaths, url, &DummyData{}, paths.CachedFetchOptions{})
+	require.Error(t, err)
+
+	fsys.ClearFaults()
+	server.Close()
+	entries, err := fsys.ReadDir(cacheHome)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	readData := &DummyData{}
+	result, err := paths.FetchStructuredFileWithCache(context.Background(), vegaPaths, url, readData, paths.CachedFetchOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.FromCache)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
+
+func testCachedFetchingBodyNotMatchingItsMetadataIsIgnored(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	server, hits := serveCacheableDocument(t)
+	options := paths.CachedFetchOptions{TTL: time.Hour}
+
+	_, err := paths.FetchStructuredFileWithCache(context.Background(), vegaPaths, server.URL, &DummyData{}, options)
+	require.NoError(t, err)
+
+	entries, err := vegaPaths.FileSystem.ReadDir(vegaPaths.CachePathFor(paths.FetchedFilesCacheHome))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".body") {
+			bodyPath := filepath.Join(vegaPaths.CachePathFor(paths.FetchedFilesCacheHome), entry.Name())
+			require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, bodyPath, []byte("Name = \"John\"\nAge = 30\n")))
+		}
+	}
+
+	readData := &DummyData{}
+	result, err := paths.FetchStructuredFileWithCache(context.Background(), vegaPaths, server.URL, readData, options)
+	require.NoError(t, err)
+	assert.False(t, result.FromCache)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+	assert.Equal(t, int32(2), atomic.LoadInt32(hits))
+}
+
+func serveCacheableDocument(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+
+	hits := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(dummyDocument))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, hits
+}
@@ -0,0 +1,140 @@
+package paths_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dummyDocument = "Name = \"Jane\"\nAge = 40\n"
+
+func TestFetchingStructuredFile(t *testing.T) {
+	t.Run("Fetching document succeeds", testFetchingDocumentSucceeds)
+	t.Run("Fetching document with unexpected status fails", testFetchingDocumentWithUnexpectedStatusFails)
+	t.Run("Fetching empty document fails", testFetchingEmptyDocumentFails)
+	t.Run("Fetching too large document fails", testFetchingTooLargeDocumentFails)
+	t.Run("Fetching document with matching checksum succeeds", testFetchingDocumentWithMatchingChecksumSucceeds)
+	t.Run("Fetching document with mismatching checksum fails", testFetchingDocumentWithMismatchingChecksumFails)
+	t.Run("Fetching document failing verification fails", testFetchingDocumentFailingVerificationFails)
+	t.Run("Fetching document with cancelled context fails", testFetchingDocumentWithCancelledContextFails)
+}
+
+func testFetchingDocumentSucceeds(t *testing.T) {
+	server := serveDocument(t, http.StatusOK, dummyDocument)
+
+	readData := &DummyData{}
+	err := paths.FetchStructuredFileWithContext(context.Background(), server.URL, readData, paths.FetchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
+
+func testFetchingDocumentWithUnexpectedStatusFails(t *testing.T) {
+	server := serveDocument(t, http.StatusNotFound, "")
+
+	err := paths.FetchStructuredFileWithContext(context.Background(), server.URL, &DummyData{}, paths.FetchOptions{})
+
+	var statusErr paths.HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
+
+func testFetchingEmptyDocumentFails(t *testing.T) {
+	server := serveDocument(t, http.StatusOK, "")
+
+	err := paths.FetchStructuredFileWithContext(context.Background(), server.URL, &DummyData{}, paths.FetchOptions{})
+	require.ErrorIs(t, err, paths.ErrEmptyResponse)
+}
+
+func testFetchingTooLargeDocumentFails(t *testing.T) {
+	server := serveDocument(t, http.StatusOK, strings.Repeat("a", 100))
+
+	err := paths.FetchStructuredFileWithContext(context.Background(), server.URL, &DummyData{}, paths.FetchOptions{
+		MaxSize: 10,
+	})
+
+	var tooLargeErr paths.ResponseTooLargeError
+	require.ErrorAs(t, err, &tooLargeErr)
+	assert.Equal(t, int64(10), tooLargeErr.MaxSize)
+}
+
+func testFetchingDocumentWithMatchingChecksumSucceeds(t *testing.T) {
+	server := serveDocument(t, http.StatusOK, dummyDocument)
+	sum := sha256.Sum256([]byte(dummyDocument))
+
+	readData := &DummyData{}
+	err := paths.FetchStructuredFileWithContext(context.Background(), server.URL, readData, paths.FetchOptions{
+		ExpectedSHA256: strings.ToUpper(hex.EncodeToString(sum[:])),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
+
+func testFetchingDocumentWithMismatchingChecksumFails(t *testing.T) {
+	server := serveDocument(t, http.StatusOK, dummyDocument)
+	sum := sha256.Sum256([]byte("something else"))
+
+	readData := &DummyData{}
+	err := paths.FetchStructuredFileWithContext(context.Background(), server.URL, readData, paths.FetchOptions{
+		ExpectedSHA256: hex.EncodeToString(sum[:]),
+	})
+
+	var checksumErr paths.ChecksumMismatchError
+	require.ErrorAs(t, err, &checksumErr)
+	assert.Equal(t, hex.EncodeToString(sum[:]), checksumErr.Expected)
+	assert.Empty(t, readData)
+}
+
+func testFetchingDocumentFailingVerificationFails(t *testing.T) {
+	server := serveDocument(t, http.StatusOK, dummyDocument)
+	verificationErr := errors.New("bad signature")
+
+	err := paths.FetchStructuredFileWithContext(context.Background(), server.URL, &DummyData{}, paths.FetchOptions{
+		Verify: func(body []byte) error {
+			assert.Equal(t, dummyDocument, string(body))
+			return verificationErr
+		},
+	})
+
+	var vErr paths.VerificationError
+	require.ErrorAs(t, err, &vErr)
+	assert.ErrorIs(t, err, verificationErr)
+}
+
+func testFetchingDocumentWithCancelledContextFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := paths.FetchStructuredFileWithContext(ctx, server.URL, &DummyData{}, paths.FetchOptions{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func serveDocument(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
@@ -1,81 +1,87 @@
 package paths
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"io/fs"
+	"path/filepath"
+	"time"
 
 	vgcrypto "code.vegaprotocol.io/shared/libs/crypto"
 	vgfs "code.vegaprotocol.io/shared/libs/fs"
-
-	"github.com/BurntSushi/toml"
 )
 
-var (
-	ErrEmptyResponse = errors.New("empty response")
-	ErrEmptyFile     = errors.New("empty file")
-)
+var ErrEmptyFile = errors.New("empty file")
 
-func FetchStructuredFile(url string, v interface{}) error {
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("couldn't load file: %w", err)
-	}
-	defer resp.Body.Close()
+// DefaultLockTimeout is the time the locked file helpers wait for another
+// process to release a file before giving up.
+var DefaultLockTimeout = 10 * time.Second
 
-	if resp.StatusCode != http.StatusOK {
-		return errors.New(http.StatusText(resp.StatusCode))
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+func ReadStructuredFile(path string, v interface{}) error {
+	buf, err := vgfs.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("couldn't read HTTP response body: %w", err)
+		return fmt.Errorf("couldn't read file: %w", err)
 	}
 
-	if len(body) == 0 {
-		return ErrEmptyResponse
+	return decodeStructuredFile(path, buf, v)
+}
+
+// decodeStructuredFile decodes the content of the file at the given path with
+// the codec matching its extension.
+func decodeStructuredFile(path string, buf []byte, v interface{}) error {
+	if len(buf) == 0 {
+		return ErrEmptyFile
 	}
 
-	if _, err := toml.Decode(string(body), v); err != nil {
-		return fmt.Errorf("invalid TOML document: %w", err)
+	codec := CodecForPath(path)
+	if err := codec.Decode(buf, v); err != nil {
+		return fmt.Errorf("invalid %s file: %w", codec.Name(), err)
 	}
 
 	return nil
 }
 
-func ReadStructuredFile(path string, v interface{}) error {
-	buf, err := vgfs.ReadFile(path)
+func WriteStructuredFile(path string, v interface{}) error {
+	buf, err := encodeStructuredFile(path, v)
 	if err != nil {
-		return fmt.Errorf("couldn't read file: %w", err)
+		return err
 	}
 
-	if len(buf) == 0 {
-		return ErrEmptyFile
-	}
-
-	if _, err := toml.Decode(string(buf), v); err != nil {
-		return fmt.Errorf("invalid TOML file: %w", err)
+	if err := vgfs.WriteFile(path, buf); err != nil {
+		return fmt.Errorf("couldn't write file: %w", err)
 	}
 
 	return nil
 }
 
-func WriteStructuredFile(path string, v interface{}) error {
-	buf := new(bytes.Buffer)
-	if err := toml.NewEncoder(buf).Encode(v); err != nil {
-		return fmt.Errorf("couldn't encode to TOML: %w", err)
+// WriteStructuredFileWithMode behaves like WriteStructuredFile, but sets the
+// given mode on the file. The mode usually comes from the permission policy,
+// through PermissionsFor.
+func WriteStructuredFileWithMode(path string, v interface{}, mode fs.FileMode) error {
+	buf, err := encodeStructuredFile(path, v)
+	if err != nil {
+		return err
 	}
 
-	if err := vgfs.WriteFile(path, buf.Bytes()); err != nil {
+	if err := vgfs.WriteFileWithMode(path, buf, mode); err != nil {
 		return fmt.Errorf("couldn't write file: %w", err)
 	}
 
 	return nil
 }
 
+// encodeStructuredFile encodes v with the codec matching the extension of the
+// file at the given path.
+func encodeStructuredFile(path string, v interface{}) ([]byte, error) {
+	codec := CodecForPath(path)
+	buf, err := codec.Encode(v)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode to %s: %w", codec.Name(), err)
+	}
+	return buf, nil
+}
+
 func ReadEncryptedFile(path string, passphrase string, v interface{}) error {
 	encryptedBuf, err := vgfs.ReadFile(path)
 	if err != nil {
@@ -96,19 +102,267 @@ func ReadEncryptedFile(path string, passphrase string, v interface{}) error {
 }
 
 func WriteEncryptedFile(path string, passphrase string, v interface{}) error {
-	buf, err := json.Marshal(v)
+	encryptedBuf, err := encryptFileContent(passphrase, v)
 	if err != nil {
-		return fmt.Errorf("couldn't marshal content: %w", err)
+		return err
 	}
 
-	encryptedBuf, err := vgcrypto.Encrypt(buf, passphrase)
+	if err := vgfs.WriteFile(path, encryptedBuf); err != nil {
+		return fmt.Errorf("couldn't write secure file: %w", err)
+	}
+
+	return nil
+}
+
+// WriteEncryptedFileWithMode behaves like WriteEncryptedFile, but sets the
+// given mode on the file.
+func WriteEncryptedFileWithMode(path string, passphrase string, v interface{}, mode fs.FileMode) error {
+	encryptedBuf, err := encryptFileContent(passphrase, v)
 	if err != nil {
-		return fmt.Errorf("couldn't encrypt content: %w", err)
+		return err
 	}
 
-	if err := vgfs.WriteFile(path, encryptedBuf); err != nil {
+	if err := vgfs.WriteFileWithMode(path, encryptedBuf, mode); err != nil {
 		return fmt.Errorf("couldn't write secure file: %w", err)
 	}
 
 	return nil
 }
+
+// encryptFileContent marshals v to JSON, and encrypts it with the passphrase.
+func encryptFileContent(passphrase string, v interface{}) ([]byte, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal content: %w", err)
+	}
+
+	encryptedBuf, err := vgcrypto.Encrypt(buf, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encrypt content: %w", err)
+	}
+
+	return encryptedBuf, nil
+}
+
+// ReadStructuredFileLocked behaves like ReadStructuredFile, but holds a shared
+// lock on the file during the read, so it doesn't observe a concurrent write.
+func ReadStructuredFileLocked(path string, v interface{}) error {
+	lock, err := vgfs.LockShared(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock file: %w", err)
+	}
+	defer lock.Unlock()
+
+	return ReadStructuredFile(path, v)
+}
+
+// WriteStructuredFileLocked behaves like WriteStructuredFile, but holds an
+// exclusive lock on the file during the write.
+func WriteStructuredFileLocked(path string, v interface{}) error {
+	lock, err := vgfs.LockExclusive(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock file: %w", err)
+	}
+	defer lock.Unlock()
+
+	return WriteStructuredFile(path, v)
+}
+
+// WriteStructuredFileLockedWithMode behaves like WriteStructuredFileLocked,
+// but sets the given mode on the file.
+func WriteStructuredFileLockedWithMode(path string, v interface{}, mode fs.FileMode) error {
+	lock, err := vgfs.LockExclusive(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock file: %w", err)
+	}
+	defer lock.Unlock()
+
+	return WriteStructuredFileWithMode(path, v, mode)
+}
+
+// UpdateStructuredFile reads the file into v, calls update, and writes v back,
+// while holding an exclusive lock on the file for the whole sequence. If the
+// file doesn't exist yet, v is left untouched before calling update. If
+// update returns an error, the file is not written.
+func UpdateStructuredFile(path string, v interface{}, update func() error) error {
+	return UpdateStructuredFileWithMode(path, v, update, vgfs.DefaultFileMode)
+}
+
+// UpdateStructuredFileWithMode behaves like UpdateStructuredFile, but sets the
+// given mode on the file.
+func UpdateStructuredFileWithMode(path string, v interface{}, update func() error, mode fs.FileMode) error {
+	lock, err := vgfs.LockExclusive(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock file: %w", err)
+	}
+	defer lock.Unlock()
+
+	exists, err := vgfs.FileExists(path)
+	if err != nil {
+		return fmt.Errorf("couldn't verify file presence: %w", err)
+	}
+
+	if exists {
+		if err := ReadStructuredFile(path, v); err != nil {
+			return err
+		}
+	}
+
+	if err := update(); err != nil {
+		return err
+	}
+
+	return WriteStructuredFileWithMode(path, v, mode)
+}
+
+// ReadEncryptedFileLocked behaves like ReadEncryptedFile, but holds a shared
+// lock on the file during the read, so it doesn't observe a concurrent write.
+func ReadEncryptedFileLocked(path string, passphrase string, v interface{}) error {
+	lock, err := vgfs.LockShared(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock secure file: %w", err)
+	}
+	defer lock.Unlock()
+
+	return ReadEncryptedFile(path, passphrase, v)
+}
+
+// WriteEncryptedFileLocked behaves like WriteEncryptedFile, but holds an
+// exclusive lock on the file during the write.
+func WriteEncryptedFileLocked(path string, passphrase string, v interface{}) error {
+	lock, err := vgfs.LockExclusive(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock secure file: %w", err)
+	}
+	defer lock.Unlock()
+
+	return WriteEncryptedFile(path, passphrase, v)
+}
+
+// WriteEncryptedFileLockedWithMode behaves like WriteEncryptedFileLocked, but
+// sets the given mode on the file.
+func WriteEncryptedFileLockedWithMode(path string, passphrase string, v interface{}, mode fs.FileMode) error {
+	lock, err := vgfs.LockExclusive(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock secure file: %w", err)
+	}
+	defer lock.Unlock()
+
+	return WriteEncryptedFileWithMode(path, passphrase, v, mode)
+}
+
+// UpdateEncryptedFile is the encrypted counterpart of UpdateStructuredFile.
+func UpdateEncryptedFile(path string, passphrase string, v interface{}, update func() error) error {
+	return UpdateEncryptedFileWithMode(path, passphrase, v, update, vgfs.DefaultFileMode)
+}
+
+// UpdateEncryptedFileWithMode behaves like UpdateEncryptedFile, but sets the
+// given mode on the file.
+func UpdateEncryptedFileWithMode(path string, passphrase string, v interface{}, update func() error, mode fs.FileMode) error {
+	lock, err := vgfs.LockExclusive(LockFilePathFor(path), DefaultLockTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't lock secure file: %w", err)
+	}
+	defer lock.Unlock()
+
+	exists, err := vgfs.FileExists(path)
+	if err != nil {
+		return fmt.Errorf("couldn't verify secure file presence: %w", err)
+	}
+
+	if exists {
+		if err := ReadEncryptedFile(path, passphrase, v); err != nil {
+			return err
+		}
+	}
+
+	if err := update(); err != nil {
+		return err
+	}
+
+	return WriteEncryptedFileWithMode(path, passphrase, v, mode)
+}
+
+// The helpers below resolve the file from the Paths, and apply its permission
+// policy: the missing directories are created with the mode of their path,
+// and the file is written with the mode of its own. Like the other helpers,
+// they work on the operating system file system.
+
+// WriteStructuredFileFor behaves like WriteStructuredFile, for the path
+// relative to the root of the given category.
+func WriteStructuredFileFor(vegaPaths Paths, category PathCategory, relPath string, v interface{}) error {
+	path, mode, err := createFileFor(vegaPaths, category, relPath)
+	if err != nil {
+		return err
+	}
+	return WriteStructuredFileWithMode(path, v, mode)
+}
+
+// WriteStructuredFileLockedFor behaves like WriteStructuredFileLocked, for the
+// path relative to the root of the given category.
+func WriteStructuredFileLockedFor(vegaPaths Paths, category PathCategory, relPath string, v interface{}) error {
+	path, mode, err := createFileFor(vegaPaths, category, relPath)
+	if err != nil {
+		return err
+	}
+	return WriteStructuredFileLockedWithMode(path, v, mode)
+}
+
+// UpdateStructuredFileFor behaves like UpdateStructuredFile, for the path
+// relative to the root of the given category.
+func UpdateStructuredFileFor(vegaPaths Paths, category PathCategory, relPath string, v interface{}, update func() error) error {
+	path, mode, err := createFileFor(vegaPaths, category, relPath)
+	if err != nil {
+		return err
+	}
+	return UpdateStructuredFileWithMode(path, v, update, mode)
+}
+
+// WriteEncryptedFileFor behaves like WriteEncryptedFile, for the path relative
+// to the root of the given category.
+func WriteEncryptedFileFor(vegaPaths Paths, category PathCategory, relPath string, passphrase string, v interface{}) error {
+	path, mode, err := createFileFor(vegaPaths, category, relPath)
+	if err != nil {
+		return err
+	}
+	return WriteEncryptedFileWithMode(path, passphrase, v, mode)
+}
+
+// WriteEncryptedFileLockedFor behaves like WriteEncryptedFileLocked, for the
+// path relative to the root of the given category.
+func WriteEncryptedFileLockedFor(vegaPaths Paths, category PathCategory, relPath string, passphrase string, v interface{}) error {
+	path, mode, err := createFileFor(vegaPaths, category, relPath)
+	if err != nil {
+		return err
+	}
+	return WriteEncryptedFileLockedWithMode(path, passphrase, v, mode)
+}
+
+// UpdateEncryptedFileFor behaves like UpdateEncryptedFile, for the path
+// relative to the root of the given category.
+func UpdateEncryptedFileFor(vegaPaths Paths, category PathCategory, relPath string, passphrase string, v interface{}, update func() error) error {
+	path, mode, err := createFileFor(vegaPaths, category, relPath)
+	if err != nil {
+		return err
+	}
+	return UpdateEncryptedFileWithMode(path, passphrase, v, update, mode)
+}
+
+// createFileFor creates the directory of the file at the path relative to the
+// root of the given category, and returns its full path and the mode the
+// policy grants it.
+func createFileFor(vegaPaths Paths, category PathCategory, relPath string) (string, fs.FileMode, error) {
+	path, err := CreatePathFor(vegaPaths, category, relPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("couldn't create the directory of %s: %w", relPath, err)
+	}
+	return path, PermissionsFor(vegaPaths, category, relPath).FileMode, nil
+}
+
+// LockFilePathFor returns the path of the lock file protecting the file at the
+// given path. The lock file is hidden, and lives next to the protected file, so
+// every process resolves the same one.
+func LockFilePathFor(path string) string {
+	dir, fileName := filepath.Split(path)
+	return filepath.Join(dir, "."+fileName+".lock")
+}
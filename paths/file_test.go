@@ -1,7 +1,9 @@
 package paths_test
 
 import (
+	"errors"
 	"os"
+	"sync"
 	"testing"
 
 	vgtest "code.vegaprotocol.io/shared/libs/test"
@@ -21,6 +23,11 @@ func TestFileHelpers(t *testing.T) {
 	t.Run("Reading encrypted file succeeds", testReadingEncryptedFileSucceeds)
 	t.Run("Reading non-existing encrypted file fails", testReadingNonExistingEncryptedFileFails)
 	t.Run("Reading encrypted file with wrong passphrase fails", testReadingEncryptedFileWithWrongPassphraseFails)
+	t.Run("Writing and reading locked structured file succeeds", testWritingAndReadingLockedStructuredFileSucceeds)
+	t.Run("Updating non-existing structured file succeeds", testUpdatingNonExistingStructuredFileSucceeds)
+	t.Run("Updating structured file concurrently succeeds", testUpdatingStructuredFileConcurrentlySucceeds)
+	t.Run("Failing update does not write structured file", testFailingUpdateDoesNotWriteStructuredFile)
+	t.Run("Updating encrypted file succeeds", testUpdatingEncryptedFileSucceeds)
 }
 
 func testWritingStructuredFileSucceeds(t *testing.T) {
@@ -201,6 +208,115 @@ func testReadingEncryptedFileWithWrongPassphraseFails(t *testing.T) {
 	assert.Empty(t, readData)
 }
 
+func testWritingAndReadingLockedStructuredFileSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	defer os.RemoveAll(paths.LockFilePathFor(path))
+	data := &DummyData{
+		Name: "Jane",
+		Age:  40,
+	}
+
+	err := paths.WriteStructuredFileLocked(path, data)
+	require.NoError(t, err)
+	vgtest.AssertFileAccess(t, path)
+
+	readData := &DummyData{}
+	err = paths.ReadStructuredFileLocked(path, readData)
+	require.NoError(t, err)
+	assert.Equal(t, data, readData)
+}
+
+func testUpdatingNonExistingStructuredFileSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	defer os.RemoveAll(paths.LockFilePathFor(path))
+
+	data := &DummyData{}
+	err := paths.UpdateStructuredFile(path, data, func() error {
+		data.Name = "Jane"
+		data.Age = 40
+		return nil
+	})
+	require.NoError(t, err)
+
+	readData := &DummyData{}
+	err = paths.ReadStructuredFile(path, readData)
+	require.NoError(t, err)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
+
+func testUpdatingStructuredFileConcurrentlySucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	defer os.RemoveAll(paths.LockFilePathFor(path))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := &DummyData{}
+			err := paths.UpdateStructuredFile(path, data, func() error {
+				data.Age++
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	readData := &DummyData{}
+	err := paths.ReadStructuredFile(path, readData)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(20), readData.Age)
+}
+
+func testFailingUpdateDoesNotWriteStructuredFile(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	defer os.RemoveAll(paths.LockFilePathFor(path))
+	updateErr := errors.New("update failed")
+
+	err := paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 40})
+	require.NoError(t, err)
+
+	data := &DummyData{}
+	err = paths.UpdateStructuredFile(path, data, func() error {
+		data.Name = "John"
+		return updateErr
+	})
+	require.ErrorIs(t, err, updateErr)
+
+	readData := &DummyData{}
+	err = paths.ReadStructuredFile(path, readData)
+	require.NoError(t, err)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, readData)
+}
+
+func testUpdatingEncryptedFileSucceeds(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	defer os.RemoveAll(paths.LockFilePathFor(path))
+	passphrase := "pa$$w0rd"
+
+	err := paths.WriteEncryptedFileLocked(path, passphrase, &DummyData{Name: "Jane", Age: 40})
+	require.NoError(t, err)
+
+	data := &DummyData{}
+	err = paths.UpdateEncryptedFile(path, passphrase, data, func() error {
+		data.Age++
+		return nil
+	})
+	require.NoError(t, err)
+	vgtest.AssertFileAccess(t, path)
+
+	readData := &DummyData{}
+	err = paths.ReadEncryptedFileLocked(path, passphrase, readData)
+	require.NoError(t, err)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 41}, readData)
+}
+
 type DummyData struct {
 	Name string
 	Age  uint8
@@ -0,0 +1,111 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+const (
+	// instanceLockFileName is the name of the file locked by the running
+	// instance, under its state home.
+	instanceLockFileName = "instance.lock"
+
+	// instancePIDFileName is the name of the file holding the PID of the
+	// running instance, under its state home.
+	instancePIDFileName = "instance.pid"
+)
+
+// InstanceAlreadyRunningError is returned when acquiring an instance lock
+// that is held by another process.
+type InstanceAlreadyRunningError struct {
+	// PID is the process ID of the running instance. It is 0 if it couldn't
+	// be determined.
+	PID int
+	// StateHome is the directory the instance lock lives in.
+	StateHome string
+}
+
+func (e InstanceAlreadyRunningError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("an instance is already running with the state home %s", e.StateHome)
+	}
+	return fmt.Sprintf("an instance is already running with the state home %s (PID %d)", e.StateHome, e.PID)
+}
+
+// InstanceLock guarantees a single instance of an application runs against a
+// given Vega home.
+type InstanceLock struct {
+	lock        *vgfs.Lock
+	pidFilePath string
+}
+
+// AcquireInstanceLock locks the given state home for the current process, and
+// records its PID in it. It fails with an InstanceAlreadyRunningError if
+// another process holds the lock.
+//
+// The lock is released by the OS when the process dies. So, a PID file left
+// behind by a process that crashed is considered stale, and is taken over.
+func AcquireInstanceLock(vegaPaths Paths, stateHome StatePath) (*InstanceLock, error) {
+	dir, err := vegaPaths.CreateStateDirFor(stateHome)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create the state directory: %w", err)
+	}
+
+	pidFilePath := filepath.Join(dir, instancePIDFileName)
+
+	lock, err := vgfs.LockExclusive(filepath.Join(dir, instanceLockFileName), 0)
+	if err != nil {
+		if errors.Is(err, vgfs.ErrLockTimeout) {
+			pid, _ := readInstancePID(pidFilePath)
+			return nil, InstanceAlreadyRunningError{
+				PID:       pid,
+				StateHome: dir,
+			}
+		}
+		return nil, fmt.Errorf("couldn't lock the state directory: %w", err)
+	}
+
+	if err := vgfs.WriteFile(pidFilePath, []byte(strconv.Itoa(os.Getpid()))); err != nil {
+		_ = lock.Unlock()
+		return nil, fmt.Errorf("couldn't write the PID file: %w", err)
+	}
+
+	return &InstanceLock{
+		lock:        lock,
+		pidFilePath: pidFilePath,
+	}, nil
+}
+
+// Release removes the PID file and releases the lock, so another instance can
+// start.
+func (l *InstanceLock) Release() error {
+	if err := os.Remove(l.pidFilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("couldn't remove the PID file: %w", err)
+	}
+
+	if err := l.lock.Unlock(); err != nil {
+		return fmt.Errorf("couldn't release the instance lock: %w", err)
+	}
+
+	return nil
+}
+
+func readInstancePID(pidFilePath string) (int, error) {
+	buf, err := vgfs.ReadFile(pidFilePath)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil {
+		return 0, fmt.Errorf("couldn't parse the PID file: %w", err)
+	}
+
+	return pid, nil
+}
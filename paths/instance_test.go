@@ -0,0 +1,93 @@
+package paths_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceLock(t *testing.T) {
+	t.Run("Acquiring instance lock records the PID", testAcquiringInstanceLockRecordsPID)
+	t.Run("Acquiring instance lock twice fails with owner PID", testAcquiringInstanceLockTwiceFailsWithOwnerPID)
+	t.Run("Acquiring instance lock after release succeeds", testAcquiringInstanceLockAfterReleaseSucceeds)
+	t.Run("Acquiring instance lock with stale PID file succeeds", testAcquiringInstanceLockWithStalePIDFileSucceeds)
+}
+
+func testAcquiringInstanceLockRecordsPID(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+
+	lock, err := paths.AcquireInstanceLock(vegaPaths, paths.WalletServiceStateHome)
+	require.NoError(t, err)
+	defer lock.Release()
+
+	buf, err := vgfs.ReadFile(vegaPaths.StatePathFor(paths.JoinStatePath(paths.WalletServiceStateHome, "instance.pid")))
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), string(buf))
+}
+
+func testAcquiringInstanceLockTwiceFailsWithOwnerPID(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+
+	lock, err := paths.AcquireInstanceLock(vegaPaths, paths.WalletServiceStateHome)
+	require.NoError(t, err)
+	defer lock.Release()
+
+	secondLock, err := paths.AcquireInstanceLock(vegaPaths, paths.WalletServiceStateHome)
+	require.Error(t, err)
+	assert.Nil(t, secondLock)
+
+	var runningErr paths.InstanceAlreadyRunningError
+	require.ErrorAs(t, err, &runningErr)
+	assert.Equal(t, os.Getpid(), runningErr.PID)
+	assert.Equal(t, vegaPaths.StatePathFor(paths.WalletServiceStateHome), runningErr.StateHome)
+}
+
+func testAcquiringInstanceLockAfterReleaseSucceeds(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+
+	lock, err := paths.AcquireInstanceLock(vegaPaths, paths.WalletServiceStateHome)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+
+	pidFileExists, err := vgfs.FileExists(vegaPaths.StatePathFor(paths.JoinStatePath(paths.WalletServiceStateHome, "instance.pid")))
+	require.NoError(t, err)
+	assert.False(t, pidFileExists)
+
+	secondLock, err := paths.AcquireInstanceLock(vegaPaths, paths.WalletServiceStateHome)
+	require.NoError(t, err)
+	require.NoError(t, secondLock.Release())
+}
+
+func testAcquiringInstanceLockWithStalePIDFileSucceeds(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+
+	// Simulate a crashed instance that left its PID file behind.
+	stateHome, err := vegaPaths.CreateStateDirFor(paths.WalletServiceStateHome)
+	require.NoError(t, err)
+	pidFilePath := filepath.Join(stateHome, "instance.pid")
+	require.NoError(t, vgfs.WriteFile(pidFilePath, []byte("999999999")))
+
+	lock, err := paths.AcquireInstanceLock(vegaPaths, paths.WalletServiceStateHome)
+	require.NoError(t, err)
+	defer lock.Release()
+
+	buf, err := vgfs.ReadFile(pidFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), string(buf))
+}
@@ -1,5 +1,7 @@
 package paths
 
+import vgfs "code.vegaprotocol.io/shared/libs/fs"
+
 type Paths interface {
 	CreateCachePathFor(CachePath) (string, error)
 	CreateCacheDirFor(CachePath) (string, error)
@@ -27,3 +29,17 @@ func New(customHome string) Paths {
 
 	return &DefaultPaths{}
 }
+
+// FileSystemOf returns the file system the given Paths implementation creates
+// its directories on. It defaults to the OS file system for implementations
+// that are not backed by a configurable file system.
+func FileSystemOf(vegaPaths Paths) vgfs.FileSystem {
+	if p, ok := vegaPaths.(fileSystemHolder); ok {
+		return p.fileSystem()
+	}
+	return vgfs.OSFileSystem{}
+}
+
+type fileSystemHolder interface {
+	fileSystem() vgfs.FileSystem
+}
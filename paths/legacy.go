@@ -0,0 +1,333 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+// LegacyMoveStatus describes the state of a move from a legacy directory.
+type LegacyMoveStatus string
+
+const (
+	// LegacyMovePlanned means the file can be moved to its new location.
+	LegacyMovePlanned LegacyMoveStatus = "planned"
+	// LegacyMoveConflict means a file already exists at the new location. The
+	// file is left untouched.
+	LegacyMoveConflict LegacyMoveStatus = "conflict"
+	// LegacyMoveDone means the file has been moved to its new location.
+	LegacyMoveDone LegacyMoveStatus = "moved"
+	// LegacyMoveFailed means an error occurred during the move. The reason is
+	// detailed on the move.
+	LegacyMoveFailed LegacyMoveStatus = "failed"
+)
+
+// LegacyMove describes the move of a single file from a legacy directory to
+// the XDG file structure.
+type LegacyMove struct {
+	Description string           `json:"description"`
+	Source      string           `json:"source"`
+	Destination string           `json:"destination"`
+	Status      LegacyMoveStatus `json:"status"`
+	Reason      string           `json:"reason,omitempty"`
+}
+
+// LegacyMigrationPlan lists the moves required to migrate a legacy directory
+// to the XDG file structure.
+type LegacyMigrationPlan struct {
+	LegacyHome string        `json:"legacyHome"`
+	Moves      []*LegacyMove `json:"moves"`
+	// Notes are the manual steps left to the user once the plan is applied.
+	Notes []string `json:"notes,omitempty"`
+	// BackupHome is the folder the original files are copied to, before
+	// being moved. It is set once the plan is applied.
+	BackupHome string `json:"backupHome,omitempty"`
+}
+
+// HasConflicts tells if some files can't be moved because their new location
+// is already taken.
+func (p *LegacyMigrationPlan) HasConflicts() bool {
+	for _, move := range p.Moves {
+		if move.Status == LegacyMoveConflict {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyEntry maps a file, or a folder, of the legacy directories to its
+// location in the XDG file structure.
+type legacyEntry struct {
+	name        string
+	description string
+	destination func(Paths) string
+}
+
+var legacyEntries = []legacyEntry{
+	{
+		name:        "config.toml",
+		description: "node configuration",
+		destination: func(p Paths) string { return p.ConfigPathFor(NodeDefaultConfigFile) },
+	}, {
+		name:        filepath.Join("nodewallet", "ethereum"),
+		description: "Ethereum node wallet",
+		destination: func(p Paths) string { return p.DataPathFor(EthereumNodeWalletsDataHome) },
+	}, {
+		name:        filepath.Join("nodewallet", "vega"),
+		description: "Vega node wallet",
+		destination: func(p Paths) string { return p.DataPathFor(VegaNodeWalletsDataHome) },
+	}, {
+		name:        "wallet-service-config.toml",
+		description: "wallet service configuration",
+		destination: func(p Paths) string { return p.ConfigPathFor(WalletServiceDefaultConfigFile) },
+	}, {
+		name:        "wallet_rsa",
+		description: "wallet service RSA key",
+		destination: func(p Paths) string { return p.DataPathFor(WalletServiceRSAKeysDataHome) },
+	},
+}
+
+// LegacyHomeCandidates returns the directories the Vega files used to be
+// located in, before the adoption of the XDG file structure. The root path,
+// specified with the former `--root-path` flag, comes first if set.
+func LegacyHomeCandidates(rootPath string) []string {
+	candidates := []string{}
+	if rootPath != "" {
+		candidates = append(candidates, rootPath)
+	}
+
+	candidates = append(candidates,
+		filepath.Join("/etc", "vega"),
+		filepath.Join("/usr", "local", "vega", "etc"),
+		filepath.Join("/usr", "local", "etc", "vega"),
+	)
+
+	if userHome, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(userHome, ".vega"),
+			filepath.Join(userHome, ".local", "share", "vega"),
+		)
+	}
+
+	return candidates
+}
+
+// DetectLegacyHomes returns the legacy directories, among the candidates,
+// that contain at least one file to migrate.
+func DetectLegacyHomes(vegaPaths Paths, rootPath string) ([]string, error) {
+	fsys := FileSystemOf(vegaPaths)
+
+	homes := []string{}
+	for _, candidate := range LegacyHomeCandidates(rootPath) {
+		for _, entry := range legacyEntries {
+			exists, err := vgfs.PathExistsIn(fsys, filepath.Join(candidate, entry.name))
+			if err != nil {
+				return nil, fmt.Errorf("couldn't verify the presence of legacy files in %s: %w", candidate, err)
+			}
+			if exists {
+				homes = append(homes, candidate)
+				break
+			}
+		}
+	}
+
+	return homes, nil
+}
+
+// PlanLegacyMigration lists the moves required to migrate the given legacy
+// directory to the XDG file structure. It doesn't modify anything, so it can
+// be used as a dry-run.
+func PlanLegacyMigration(vegaPaths Paths, legacyHome string) (*LegacyMigrationPlan, error) {
+	fsys := FileSystemOf(vegaPaths)
+
+	plan := &LegacyMigrationPlan{
+		LegacyHome: legacyHome,
+		Moves:      []*LegacyMove{},
+	}
+
+	hasNodeWallets := false
+	for _, entry := range legacyEntries {
+		source := filepath.Join(legacyHome, entry.name)
+		info, err := fsys.Stat(source)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("couldn't inspect %s: %w", source, err)
+		}
+
+		destination := entry.destination(vegaPaths)
+
+		var moves []*LegacyMove
+		if info.IsDir() {
+			moves, err = planLegacyDirMoves(fsys, entry.description, source, destination)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			move, err := planLegacyMove(fsys, entry.description, source, destination)
+			if err != nil {
+				return nil, err
+			}
+			moves = []*LegacyMove{move}
+		}
+
+		if len(moves) > 0 && filepath.Dir(entry.name) == "nodewallet" {
+			hasNodeWallets = true
+		}
+		plan.Moves = append(plan.Moves, moves...)
+	}
+
+	if hasNodeWallets {
+		plan.Notes = append(plan.Notes, fmt.Sprintf(
+			"The node wallets have to be imported with `vega nodewallet import` to be registered in %s.",
+			vegaPaths.ConfigPathFor(NodeWalletsConfigFile),
+		))
+	}
+
+	return plan, nil
+}
+
+// ApplyLegacyMigration moves the files listed in the plan to their new
+// location. Every file is copied to a backup folder, under the
+// LegacyBackupsStateHome, before being moved. Existing files are never
+// overwritten: a move whose destination has been taken since the plan was
+// built is marked as a conflict, and skipped.
+//
+// The status of every move is updated in the plan. An error is returned only
+// if the backup folder can't be created.
+func ApplyLegacyMigration(vegaPaths Paths, plan *LegacyMigrationPlan) error {
+	fsys := FileSystemOf(vegaPaths)
+
+	backupHome, err := vegaPaths.CreateStateDirFor(JoinStatePath(LegacyBackupsStateHome, time.Now().UTC().Format("20060102T150405")))
+	if err != nil {
+		return fmt.Errorf("couldn't create the backup directory: %w", err)
+	}
+	plan.BackupHome = backupHome
+
+	for _, move := range plan.Moves {
+		if move.Status != LegacyMovePlanned {
+			continue
+		}
+
+		exists, err := vgfs.PathExistsIn(fsys, move.Destination)
+		if err != nil {
+			markLegacyMoveAsFailed(move, fmt.Errorf("couldn't verify the destination: %w", err))
+			continue
+		}
+		if exists {
+			move.Status = LegacyMoveConflict
+			move.Reason = "the destination already exists"
+			continue
+		}
+
+		relSource, err := filepath.Rel(plan.LegacyHome, move.Source)
+		if err != nil {
+			markLegacyMoveAsFailed(move, fmt.Errorf("couldn't resolve the source: %w", err))
+			continue
+		}
+
+		if err := moveLegacyFile(fsys, move.Source, filepath.Join(backupHome, relSource), move.Destination); err != nil {
+			markLegacyMoveAsFailed(move, err)
+			continue
+		}
+
+		move.Status = LegacyMoveDone
+	}
+
+	return nil
+}
+
+func planLegacyDirMoves(fsys vgfs.FileSystem, description, sourceDir, destinationDir string) ([]*LegacyMove, error) {
+	entries, err := fsys.ReadDir(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read directory %s: %w", sourceDir, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	moves := []*LegacyMove{}
+	for _, entry := range entries {
+		source := filepath.Join(sourceDir, entry.Name())
+		destination := filepath.Join(destinationDir, entry.Name())
+
+		if entry.IsDir() {
+			subMoves, err := planLegacyDirMoves(fsys, description, source, destination)
+			if err != nil {
+				return nil, err
+			}
+			moves = append(moves, subMoves...)
+			continue
+		}
+
+		move, err := planLegacyMove(fsys, description, source, destination)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, move)
+	}
+
+	return moves, nil
+}
+
+func planLegacyMove(fsys vgfs.FileSystem, description, source, destination string) (*LegacyMove, error) {
+	move := &LegacyMove{
+		Description: description,
+		Source:      source,
+		Destination: destination,
+		Status:      LegacyMovePlanned,
+	}
+
+	exists, err := vgfs.PathExistsIn(fsys, destination)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't verify the presence of %s: %w", destination, err)
+	}
+	if exists {
+		move.Status = LegacyMoveConflict
+		move.Reason = "the destination already exists"
+	}
+
+	return move, nil
+}
+
+// moveLegacyFile copies the source to the backup and the destination, and
+// then removes it. Copying is used instead of renaming, as the legacy
+// directories are usually on a different device than the XDG ones.
+func moveLegacyFile(fsys vgfs.FileSystem, source, backup, destination string) error {
+	content, err := vgfs.ReadFileIn(fsys, source)
+	if err != nil {
+		return err
+	}
+
+	if err := vgfs.EnsureDirIn(fsys, filepath.Dir(backup)); err != nil {
+		return fmt.Errorf("couldn't create the backup directory: %w", err)
+	}
+	if err := vgfs.WriteFileIn(fsys, backup, content); err != nil {
+		return fmt.Errorf("couldn't back up the file: %w", err)
+	}
+
+	if err := vgfs.EnsureDirIn(fsys, filepath.Dir(destination)); err != nil {
+		return fmt.Errorf("couldn't create the destination directory: %w", err)
+	}
+	if err := vgfs.WriteFileIn(fsys, destination, content); err != nil {
+		return fmt.Errorf("couldn't copy the file to its destination: %w", err)
+	}
+
+	if err := fsys.Remove(source); err != nil {
+		return fmt.Errorf("couldn't remove the original file: %w", err)
+	}
+
+	return nil
+}
+
+func markLegacyMoveAsFailed(move *LegacyMove, err error) {
+	move.Status = LegacyMoveFailed
+	move.Reason = err.Error()
+}
@@ -0,0 +1,141 @@
+package paths_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegacyMigration(t *testing.T) {
+	t.Run("Detecting legacy homes finds the root path", testDetectingLegacyHomesFindsTheRootPath)
+	t.Run("Planning migration does not modify anything", testPlanningMigrationDoesNotModifyAnything)
+	t.Run("Applying migration moves the files with backup", testApplyingMigrationMovesTheFilesWithBackup)
+	t.Run("Applying migration does not overwrite existing files", testApplyingMigrationDoesNotOverwriteExistingFiles)
+}
+
+func setUpLegacyHome(t *testing.T) string {
+	t.Helper()
+
+	legacyHome := vgtest.RandomPath()
+	files := map[string]string{
+		"config.toml":                "node-config",
+		"wallet-service-config.toml": "service-config",
+		filepath.Join("nodewallet", "ethereum", "eth-key"): "eth-wallet",
+		filepath.Join("nodewallet", "vega", "vega-wallet"): "vega-wallet",
+		filepath.Join("wallet_rsa", "public.pem"):          "public-key",
+		filepath.Join("wallet_rsa", "private.pem"):         "private-key",
+	}
+	for name, content := range files {
+		path := filepath.Join(legacyHome, name)
+		require.NoError(t, vgfs.EnsureDir(filepath.Dir(path)))
+		require.NoError(t, vgfs.WriteFile(path, []byte(content)))
+	}
+
+	return legacyHome
+}
+
+func testDetectingLegacyHomesFindsTheRootPath(t *testing.T) {
+	legacyHome := setUpLegacyHome(t)
+	defer os.RemoveAll(legacyHome)
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+
+	homes, err := paths.DetectLegacyHomes(paths.New(home), legacyHome)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, homes)
+	assert.Equal(t, legacyHome, homes[0])
+}
+
+func testPlanningMigrationDoesNotModifyAnything(t *testing.T) {
+	legacyHome := setUpLegacyHome(t)
+	defer os.RemoveAll(legacyHome)
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+
+	plan, err := paths.PlanLegacyMigration(vegaPaths, legacyHome)
+
+	require.NoError(t, err)
+	require.Len(t, plan.Moves, 6)
+	for _, move := range plan.Moves {
+		assert.Equal(t, paths.LegacyMovePlanned, move.Status)
+	}
+	assert.Len(t, plan.Notes, 1)
+	assert.NoDirExists(t, home)
+	assert.FileExists(t, filepath.Join(legacyHome, "config.toml"))
+}
+
+func testApplyingMigrationMovesTheFilesWithBackup(t *testing.T) {
+	legacyHome := setUpLegacyHome(t)
+	defer os.RemoveAll(legacyHome)
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+	plan, err := paths.PlanLegacyMigration(vegaPaths, legacyHome)
+	require.NoError(t, err)
+
+	require.NoError(t, paths.ApplyLegacyMigration(vegaPaths, plan))
+
+	for _, move := range plan.Moves {
+		assert.Equal(t, paths.LegacyMoveDone, move.Status, move.Reason)
+		assert.NoFileExists(t, move.Source)
+		assert.FileExists(t, move.Destination)
+	}
+
+	expectedContents := map[string]string{
+		vegaPaths.ConfigPathFor(paths.NodeDefaultConfigFile):                                    "node-config",
+		vegaPaths.ConfigPathFor(paths.WalletServiceDefaultConfigFile):                           "service-config",
+		vegaPaths.DataPathFor(paths.JoinDataPath(paths.EthereumNodeWalletsDataHome, "eth-key")): "eth-wallet",
+		vegaPaths.DataPathFor(paths.JoinDataPath(paths.VegaNodeWalletsDataHome, "vega-wallet")): "vega-wallet",
+		vegaPaths.DataPathFor(paths.WalletServicePublicRSAKeyDataFile):                          "public-key",
+		vegaPaths.DataPathFor(paths.WalletServicePrivateRSAKeyDataFile):                         "private-key",
+	}
+	for path, expectedContent := range expectedContents {
+		content, err := vgfs.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, expectedContent, string(content))
+	}
+
+	backup, err := vgfs.ReadFile(filepath.Join(plan.BackupHome, "nodewallet", "vega", "vega-wallet"))
+	require.NoError(t, err)
+	assert.Equal(t, "vega-wallet", string(backup))
+}
+
+func testApplyingMigrationDoesNotOverwriteExistingFiles(t *testing.T) {
+	legacyHome := setUpLegacyHome(t)
+	defer os.RemoveAll(legacyHome)
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+	plan, err := paths.PlanLegacyMigration(vegaPaths, legacyHome)
+	require.NoError(t, err)
+
+	// The configuration is created between the planning and the migration.
+	configPath, err := vegaPaths.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+	require.NoError(t, vgfs.WriteFile(configPath, []byte("new-config")))
+
+	require.NoError(t, paths.ApplyLegacyMigration(vegaPaths, plan))
+
+	assert.True(t, plan.HasConflicts())
+	for _, move := range plan.Moves {
+		if move.Destination == configPath {
+			assert.Equal(t, paths.LegacyMoveConflict, move.Status)
+			assert.FileExists(t, move.Source)
+		} else {
+			assert.Equal(t, paths.LegacyMoveDone, move.Status)
+		}
+	}
+
+	content, err := vgfs.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "new-config", string(content))
+}
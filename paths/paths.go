@@ -10,7 +10,8 @@ var (
 // File structure for cache
 //
 // CACHE_PATH
-// 	└── data-node/
+// 	├── data-node/
+// 	└── fetched-files/
 
 type CachePath string
 
@@ -36,6 +37,10 @@ var (
 	// DataNodeCacheHome is the folder containing the cache used by the
 	// data-node.
 	DataNodeCacheHome = CachePath("data-node")
+
+	// FetchedFilesCacheHome is the folder containing the copies of the
+	// structured files fetched from remote sources.
+	FetchedFilesCacheHome = CachePath("fetched-files")
 )
 
 // File structure for configuration
@@ -54,6 +59,7 @@ var (
 // 	│	└── config.toml
 // 	└── wallet-service/
 // 		├── networks/
+// 		├── config.toml
 //		└── permissions.toml
 
 type ConfigPath string
@@ -127,6 +133,10 @@ var (
 	// configuration files used by the networks.
 	WalletServiceNetworksConfigHome = JoinConfigPath(WalletServiceConfigHome, "networks")
 
+	// WalletServiceDefaultConfigFile is the default configuration file for
+	// the wallet service application.
+	WalletServiceDefaultConfigFile = JoinConfigPath(WalletServiceConfigHome, "config.toml")
+
 	// WalletServicePermissionsConfigFile is the file containing the permissions that
 	// control the access to the wallets.
 	WalletServicePermissionsConfigFile = ConfigPath(filepath.Join(WalletServiceConfigHome.String(), "permissions.toml"))
@@ -221,6 +231,8 @@ var (
 // 	├── data-node/
 // 	│	├── logs/
 // 	│	└── storage/
+// 	├── legacy-backups/
+// 	├── profiles.toml
 // 	├── node/
 // 	│	├── logs/
 // 	│	├── checkpoints/
@@ -265,6 +277,14 @@ var (
 	// data-node.
 	DataNodeStorageHome = StatePath(filepath.Join(DataNodeStateHome.String(), "storage"))
 
+	// LegacyBackupsStateHome is the folder containing the copies of the
+	// files moved out of the legacy Vega directories.
+	LegacyBackupsStateHome = StatePath("legacy-backups")
+
+	// ProfilesStateFile is the file containing the state of the profiles,
+	// like the default one.
+	ProfilesStateFile = StatePath("profiles.toml")
+
 	// NodeStateHome is the folder containing the state of the node.
 	NodeStateHome = StatePath("node")
 
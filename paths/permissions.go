@@ -0,0 +1,175 @@
+package paths
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+// Permissions are the modes the directories and the files are created with.
+type Permissions struct {
+	DirMode  fs.FileMode `json:"dirMode"`
+	FileMode fs.FileMode `json:"fileMode"`
+}
+
+// DefaultPermissions grants access to the owner only.
+var DefaultPermissions = Permissions{
+	DirMode:  vgfs.DefaultDirMode,
+	FileMode: vgfs.DefaultFileMode,
+}
+
+// PermissionOverride sets the permissions of a path, and of everything under
+// it.
+type PermissionOverride struct {
+	Category PathCategory
+	// Path is the path relative to the root of its category, like the value
+	// of a ConfigPath.
+	Path        string
+	Permissions Permissions
+}
+
+// PermissionPolicy decides the permissions of the directories created by a
+// Paths implementation, and of the files written in them. A nil policy
+// applies the DefaultPermissions everywhere.
+type PermissionPolicy struct {
+	// Defaults are the permissions applied to the paths without override.
+	Defaults Permissions
+	// Overrides are the permissions of specific paths. When several of them
+	// apply, the one with the deepest path wins.
+	Overrides []PermissionOverride
+}
+
+// DefaultPermissionPolicy returns a policy applying the DefaultPermissions to
+// all the paths.
+func DefaultPermissionPolicy() *PermissionPolicy {
+	return &PermissionPolicy{
+		Defaults: DefaultPermissions,
+	}
+}
+
+// OverrideCachePath sets the permissions of the given cache path.
+func (p *PermissionPolicy) OverrideCachePath(relPath CachePath, permissions Permissions) {
+	p.override(CachePathCategory, relPath.String(), permissions)
+}
+
+// OverrideConfigPath sets the permissions of the given config path.
+func (p *PermissionPolicy) OverrideConfigPath(relPath ConfigPath, permissions Permissions) {
+	p.override(ConfigPathCategory, relPath.String(), permissions)
+}
+
+// OverrideDataPath sets the permissions of the given data path.
+func (p *PermissionPolicy) OverrideDataPath(relPath DataPath, permissions Permissions) {
+	p.override(DataPathCategory, relPath.String(), permissions)
+}
+
+// OverrideStatePath sets the permissions of the given state path.
+func (p *PermissionPolicy) OverrideStatePath(relPath StatePath, permissions Permissions) {
+	p.override(StatePathCategory, relPath.String(), permissions)
+}
+
+// For returns the permissions of the path relative to the root of the given
+// category.
+//
+// The paths namespaced under a profile are matched against the overrides
+// both as is, and without their profile prefix, so an override applies to
+// all the profiles.
+func (p *PermissionPolicy) For(category PathCategory, relPath string) Permissions {
+	if p == nil {
+		return DefaultPermissions
+	}
+
+	defaults := p.Defaults
+	if defaults.DirMode == 0 {
+		defaults.DirMode = DefaultPermissions.DirMode
+	}
+	if defaults.FileMode == 0 {
+		defaults.FileMode = DefaultPermissions.FileMode
+	}
+
+	permissions := defaults
+	candidates := []string{filepath.Clean(relPath)}
+	if unprefixed, ok := trimProfilePrefix(candidates[0]); ok {
+		candidates = append(candidates, unprefixed)
+	}
+
+	bestDepth := -1
+	for _, override := range p.Overrides {
+		if override.Category != category {
+			continue
+		}
+
+		overridePath := filepath.Clean(override.Path)
+		for _, candidate := range candidates {
+			if candidate != overridePath && !strings.HasPrefix(candidate, overridePath+string(filepath.Separator)) {
+				continue
+			}
+
+			depth := strings.Count(overridePath, string(filepath.Separator))
+			if depth > bestDepth {
+				bestDepth = depth
+				permissions = override.Permissions
+			}
+		}
+	}
+
+	// An override may only set one of the modes, the other one is left to the
+	// defaults.
+	if permissions.DirMode == 0 {
+		permissions.DirMode = defaults.DirMode
+	}
+	if permissions.FileMode == 0 {
+		permissions.FileMode = defaults.FileMode
+	}
+
+	return permissions
+}
+
+// dirModeFor returns the mode of the directory at the given relative path.
+func (p *PermissionPolicy) dirModeFor(category PathCategory, relDirPath string) fs.FileMode {
+	return p.For(category, relDirPath).DirMode
+}
+
+// parentDirModeFor returns the mode of the directory containing the file at
+// the given relative path.
+func (p *PermissionPolicy) parentDirModeFor(category PathCategory, relFilePath string) fs.FileMode {
+	return p.For(category, filepath.Dir(relFilePath)).DirMode
+}
+
+func (p *PermissionPolicy) override(category PathCategory, relPath string, permissions Permissions) {
+	p.Overrides = append(p.Overrides, PermissionOverride{
+		Category:    category,
+		Path:        relPath,
+		Permissions: permissions,
+	})
+}
+
+// PermissionPolicyOf returns the permission policy attached to the given Paths
+// implementation. It returns nil, meaning the DefaultPermissions, for the
+// implementations without policy.
+func PermissionPolicyOf(vegaPaths Paths) *PermissionPolicy {
+	if p, ok := vegaPaths.(permissionPolicyHolder); ok {
+		return p.permissionPolicy()
+	}
+	return nil
+}
+
+// PermissionsFor returns the permissions of the path relative to the root of
+// the given category, according to the policy attached to the Paths.
+func PermissionsFor(vegaPaths Paths, category PathCategory, relPath string) Permissions {
+	return PermissionPolicyOf(vegaPaths).For(category, relPath)
+}
+
+type permissionPolicyHolder interface {
+	permissionPolicy() *PermissionPolicy
+}
+
+// trimProfilePrefix removes the "profiles/<name>/" prefix from the path.
+func trimProfilePrefix(relPath string) (string, bool) {
+	segments := strings.SplitN(relPath, string(filepath.Separator), 3)
+	if len(segments) != 3 || segments[0] != profilesDirName {
+		return "", false
+	}
+	return segments[2], true
+}
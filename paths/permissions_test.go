@@ -0,0 +1,212 @@
+package paths_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionPolicy(t *testing.T) {
+	t.Run("Nil policy applies the default permissions", testNilPolicyAppliesTheDefaultPermissions)
+	t.Run("Deepest override wins", testDeepestOverrideWins)
+	t.Run("Overrides apply to the profiles", testOverridesApplyToTheProfiles)
+	t.Run("Creating directories honours the policy", testCreatingDirectoriesHonoursThePolicy)
+	t.Run("Wrappers expose the policy of their base", testWrappersExposeThePolicyOfTheirBase)
+	t.Run("Writing files honours the policy", testWritingFilesHonoursThePolicy)
+	t.Run("Partial overrides keep the default modes", testPartialOverridesKeepTheDefaultModes)
+	t.Run("Writing files through the Paths honours the policy", testWritingFilesThroughThePathsHonoursThePolicy)
+}
+
+func testNilPolicyAppliesTheDefaultPermissions(t *testing.T) {
+	var policy *paths.PermissionPolicy
+
+	assert.Equal(t, paths.DefaultPermissions, policy.For(paths.DataPathCategory, paths.WalletsDataHome.String()))
+	assert.Equal(t, paths.DefaultPermissions, paths.PermissionsFor(&paths.CustomPaths{}, paths.DataPathCategory, paths.WalletsDataHome.String()))
+}
+
+func testDeepestOverrideWins(t *testing.T) {
+	policy := newStoragePermissionPolicy()
+	policy.OverrideStatePath(paths.DataNodeStateHome, paths.Permissions{DirMode: 0755, FileMode: 0644})
+
+	assert.Equal(t, fs.FileMode(0750), policy.For(paths.StatePathCategory, paths.DataNodeStorageHome.String()).DirMode)
+	assert.Equal(t, fs.FileMode(0750), policy.For(paths.StatePathCategory, filepath.Join(paths.DataNodeStorageHome.String(), "db")).DirMode)
+	assert.Equal(t, fs.FileMode(0755), policy.For(paths.StatePathCategory, paths.DataNodeLogsHome.String()).DirMode)
+	assert.Equal(t, paths.DefaultPermissions, policy.For(paths.StatePathCategory, paths.NodeStateHome.String()))
+	assert.Equal(t, paths.DefaultPermissions, policy.For(paths.DataPathCategory, paths.DataNodeStorageHome.String()))
+}
+
+func testOverridesApplyToTheProfiles(t *testing.T) {
+	base := newMemoryCustomPaths()
+	base.Permissions = newStoragePermissionPolicy()
+	vegaPaths, err := paths.NewProfilePaths(base, "mainnet")
+	require.NoError(t, err)
+
+	storagePath, err := vegaPaths.CreateStateDirFor(paths.DataNodeStorageHome)
+	require.NoError(t, err)
+	assertMemoryDirMode(t, base.FileSystem, storagePath, 0750)
+
+	logsPath, err := vegaPaths.CreateStateDirFor(paths.DataNodeLogsHome)
+	require.NoError(t, err)
+	assertMemoryDirMode(t, base.FileSystem, logsPath, 0700)
+}
+
+func testCreatingDirectoriesHonoursThePolicy(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	vegaPaths.Permissions = newStoragePermissionPolicy()
+
+	storagePath, err := vegaPaths.CreateStateDirFor(paths.DataNodeStorageHome)
+	require.NoError(t, err)
+	assertMemoryDirMode(t, vegaPaths.FileSystem, storagePath, 0750)
+
+	filePath, err := vegaPaths.CreateStatePathFor(paths.StatePath(filepath.Join(paths.DataNodeStorageHome.String(), "db", "file")))
+	require.NoError(t, err)
+	assertMemoryDirMode(t, vegaPaths.FileSystem, filepath.Dir(filePath), 0750)
+
+	nodePath, err := vegaPaths.CreateStateDirFor(paths.NodeStateHome)
+	require.NoError(t, err)
+	assertMemoryDirMode(t, vegaPaths.FileSystem, nodePath, 0700)
+}
+
+func testWrappersExposeThePolicyOfTheirBase(t *testing.T) {
+	base := newMemoryCustomPaths()
+	base.Permissions = newStoragePermissionPolicy()
+
+	profilePaths, err := paths.NewProfilePaths(base, "mainnet")
+	require.NoError(t, err)
+	readOnlyPaths := paths.NewReadOnlyPaths(profilePaths)
+
+	assert.Same(t, base.Permissions, paths.PermissionPolicyOf(profilePaths))
+	assert.Same(t, base.Permissions, paths.PermissionPolicyOf(readOnlyPaths))
+
+	rootPaths := paths.NewRootPaths(paths.RootPathsOptions{
+		FileSystem:  vgfs.NewMemoryFileSystem(),
+		Permissions: base.Permissions,
+	})
+	assert.Same(t, base.Permissions, paths.PermissionPolicyOf(rootPaths))
+	assert.Nil(t, paths.PermissionPolicyOf(&paths.DefaultPaths{}))
+}
+
+func testWritingFilesHonoursThePolicy(t *testing.T) {
+	vegaHome := vgtest.RandomPath()
+	defer os.RemoveAll(vegaHome)
+
+	policy := paths.DefaultPermissionPolicy()
+	policy.OverrideDataPath(paths.WalletServicePublicRSAKeyDataFile, paths.Permissions{
+		DirMode:  0700,
+		FileMode: 0644,
+	})
+	vegaPaths := &paths.CustomPaths{
+		CustomHome:  vegaHome,
+		Permissions: policy,
+	}
+
+	publicKeyPath, err := vegaPaths.CreateDataPathFor(paths.WalletServicePublicRSAKeyDataFile)
+	require.NoError(t, err)
+	vgtest.AssertDirAccess(t, vegaPaths.DataPathFor(paths.WalletServiceRSAKeysDataHome))
+
+	permissions := paths.PermissionsFor(vegaPaths, paths.DataPathCategory, paths.WalletServicePublicRSAKeyDataFile.String())
+	require.NoError(t, paths.WriteStructuredFileWithMode(publicKeyPath+".json", map[string]string{"key": "public"}, permissions.FileMode))
+	vgtest.AssertFileAccessWithMode(t, publicKeyPath+".json", 0644)
+
+	privateKeyPath := vegaPaths.DataPathFor(paths.WalletServicePrivateRSAKeyDataFile)
+	permissions = paths.PermissionsFor(vegaPaths, paths.DataPathCategory, paths.WalletServicePrivateRSAKeyDataFile.String())
+	require.NoError(t, paths.WriteEncryptedFileWithMode(privateKeyPath, "passphrase", map[string]string{"key": "private"}, permissions.FileMode))
+	vgtest.AssertFileAccess(t, privateKeyPath)
+}
+
+func testPartialOverridesKeepTheDefaultModes(t *testing.T) {
+	policy := &paths.PermissionPolicy{
+		Defaults: paths.Permissions{DirMode: 0750, FileMode: 0640},
+	}
+	policy.OverrideStatePath(paths.DataNodeLogsHome, paths.Permissions{FileMode: 0644})
+	policy.OverrideStatePath(paths.DataNodeStorageHome, paths.Permissions{DirMode: 0755})
+
+	assert.Equal(t, paths.Permissions{DirMode: 0750, FileMode: 0644}, policy.For(paths.StatePathCategory, paths.DataNodeLogsHome.String()))
+	assert.Equal(t, paths.Permissions{DirMode: 0755, FileMode: 0640}, policy.For(paths.StatePathCategory, paths.DataNodeStorageHome.String()))
+
+	policy.Defaults = paths.Permissions{}
+	assert.Equal(t, paths.Permissions{DirMode: paths.DefaultPermissions.DirMode, FileMode: 0644}, policy.For(paths.StatePathCategory, paths.DataNodeLogsHome.String()))
+}
+
+func testWritingFilesThroughThePathsHonoursThePolicy(t *testing.T) {
+	vegaHome := vgtest.RandomPath()
+	defer os.RemoveAll(vegaHome)
+
+	policy := paths.DefaultPermissionPolicy()
+	policy.OverrideConfigPath(paths.WalletServiceConfigHome, paths.Permissions{
+		DirMode:  0750,
+		FileMode: 0640,
+	})
+	vegaPaths := &paths.CustomPaths{
+		CustomHome:  vegaHome,
+		Permissions: policy,
+	}
+
+	configFile := filepath.Join(paths.WalletServiceConfigHome.String(), "config.toml")
+	require.NoError(t, paths.WriteStructuredFileFor(vegaPaths, paths.ConfigPathCategory, configFile, map[string]string{"level": "info"}))
+	vgtest.AssertDirAccessFor(t, vegaPaths, paths.ConfigPathCategory, paths.WalletServiceConfigHome.String())
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, configFile)
+	vgtest.AssertFileAccessWithMode(t, paths.PathFor(vegaPaths, paths.ConfigPathCategory, configFile), 0640)
+
+	lockedFile := filepath.Join(paths.WalletServiceConfigHome.String(), "locked.json")
+	require.NoError(t, paths.WriteStructuredFileLockedFor(vegaPaths, paths.ConfigPathCategory, lockedFile, map[string]string{"level": "info"}))
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, lockedFile)
+
+	updatedFile := filepath.Join(paths.WalletServiceConfigHome.String(), "updated.json")
+	counter := map[string]int{}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, paths.UpdateStructuredFileFor(vegaPaths, paths.ConfigPathCategory, updatedFile, &counter, func() error {
+			counter["count"]++
+			return nil
+		}))
+	}
+	assert.Equal(t, 2, counter["count"])
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, updatedFile)
+
+	secretFile := filepath.Join(paths.WalletServiceConfigHome.String(), "secret")
+	require.NoError(t, paths.WriteEncryptedFileFor(vegaPaths, paths.ConfigPathCategory, secretFile, "passphrase", map[string]string{"key": "secret"}))
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, secretFile)
+
+	lockedSecretFile := filepath.Join(paths.WalletServiceConfigHome.String(), "locked-secret")
+	require.NoError(t, paths.WriteEncryptedFileLockedFor(vegaPaths, paths.ConfigPathCategory, lockedSecretFile, "passphrase", map[string]string{"key": "secret"}))
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, lockedSecretFile)
+
+	updatedSecretFile := filepath.Join(paths.WalletServiceConfigHome.String(), "updated-secret")
+	secret := map[string]string{}
+	require.NoError(t, paths.UpdateEncryptedFileFor(vegaPaths, paths.ConfigPathCategory, updatedSecretFile, "passphrase", &secret, func() error {
+		secret["key"] = "secret"
+		return nil
+	}))
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, updatedSecretFile)
+
+	nodeFile := filepath.Join(paths.NodeConfigHome.String(), "config.toml")
+	require.NoError(t, paths.WriteStructuredFileFor(vegaPaths, paths.ConfigPathCategory, nodeFile, map[string]string{"level": "info"}))
+	vgtest.AssertDirAccess(t, vegaPaths.ConfigPathFor(paths.NodeConfigHome))
+	vgtest.AssertFileAccess(t, paths.PathFor(vegaPaths, paths.ConfigPathCategory, nodeFile))
+}
+
+func newStoragePermissionPolicy() *paths.PermissionPolicy {
+	policy := paths.DefaultPermissionPolicy()
+	policy.OverrideStatePath(paths.DataNodeStorageHome, paths.Permissions{
+		DirMode:  0750,
+		FileMode: 0640,
+	})
+	return policy
+}
+
+func assertMemoryDirMode(t *testing.T, fsys vgfs.FileSystem, dirPath string, mode fs.FileMode) {
+	t.Helper()
+
+	info, err := fsys.Stat(dirPath)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, mode, info.Mode().Perm())
+}
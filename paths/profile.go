@@ -0,0 +1,287 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+// A profile isolates the files of a network, so several networks can be run
+// side by side from a single Vega home. Each category gets a sub-folder per
+// profile, that contains the usual structure described in paths.go.
+//
+// File structure for profiles:
+//
+// CACHE_PATH | CONFIG_PATH | DATA_PATH | STATE_PATH
+// 	└── profiles/
+// 		├── mainnet/
+// 		└── fairground/
+
+// profilesDirName is the folder containing the profiles, in every category.
+const profilesDirName = "profiles"
+
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ProfilePaths namespaces the paths of every category under a profile. It
+// can wrap any Paths implementation, like DefaultPaths or CustomPaths.
+type ProfilePaths struct {
+	base    Paths
+	profile string
+}
+
+type profilesState struct {
+	DefaultProfile string `json:"defaultProfile" toml:"default_profile" yaml:"default_profile"`
+}
+
+// NewProfilePaths returns the paths of the given profile, built on top of
+// the given paths.
+func NewProfilePaths(base Paths, profile string) (*ProfilePaths, error) {
+	if err := validateProfileName(profile); err != nil {
+		return nil, err
+	}
+
+	return &ProfilePaths{
+		base:    base,
+		profile: profile,
+	}, nil
+}
+
+// ResolveProfile returns the paths of the given profile. If no profile is
+// specified, the default one is used. If there is no default profile, the
+// base paths are returned as is.
+func ResolveProfile(base Paths, profile string) (Paths, error) {
+	if profile == "" {
+		defaultProfile, err := DefaultProfile(base)
+		if err != nil {
+			return nil, err
+		}
+		if defaultProfile == "" {
+			return base, nil
+		}
+		profile = defaultProfile
+	}
+
+	return NewProfilePaths(base, profile)
+}
+
+// Profile returns the name of the profile.
+func (p *ProfilePaths) Profile() string {
+	return p.profile
+}
+
+// CreateCachePathFor builds the path for cache files of the profile and
+// creates intermediate directories.
+func (p *ProfilePaths) CreateCachePathFor(relFilePath CachePath) (string, error) {
+	return p.base.CreateCachePathFor(p.cachePath(relFilePath))
+}
+
+// CreateCacheDirFor builds the path for cache directories of the profile and
+// creates intermediate directories.
+func (p *ProfilePaths) CreateCacheDirFor(relDirPath CachePath) (string, error) {
+	return p.base.CreateCacheDirFor(p.cachePath(relDirPath))
+}
+
+// CreateConfigPathFor builds the path for configuration files of the profile
+// and creates intermediate directories.
+func (p *ProfilePaths) CreateConfigPathFor(relFilePath ConfigPath) (string, error) {
+	return p.base.CreateConfigPathFor(p.configPath(relFilePath))
+}
+
+// CreateConfigDirFor builds the path for configuration directories of the
+// profile and creates intermediate directories.
+func (p *ProfilePaths) CreateConfigDirFor(relDirPath ConfigPath) (string, error) {
+	return p.base.CreateConfigDirFor(p.configPath(relDirPath))
+}
+
+// CreateDataPathFor builds the path for data files of the profile and creates
+// intermediate directories.
+func (p *ProfilePaths) CreateDataPathFor(relFilePath DataPath) (string, error) {
+	return p.base.CreateDataPathFor(p.dataPath(relFilePath))
+}
+
+// CreateDataDirFor builds the path for data directories of the profile and
+// creates intermediate directories.
+func (p *ProfilePaths) CreateDataDirFor(relDirPath DataPath) (string, error) {
+	return p.base.CreateDataDirFor(p.dataPath(relDirPath))
+}
+
+// CreateStatePathFor builds the path for state files of the profile and
+// creates intermediate directories.
+func (p *ProfilePaths) CreateStatePathFor(relFilePath StatePath) (string, error) {
+	return p.base.CreateStatePathFor(p.statePath(relFilePath))
+}
+
+// CreateStateDirFor builds the path for state directories of the profile and
+// creates intermediate directories.
+func (p *ProfilePaths) CreateStateDirFor(relDirPath StatePath) (string, error) {
+	return p.base.CreateStateDirFor(p.statePath(relDirPath))
+}
+
+// CachePathFor builds the path for a cache file or directory of the profile.
+func (p *ProfilePaths) CachePathFor(relPath CachePath) string {
+	return p.base.CachePathFor(p.cachePath(relPath))
+}
+
+// ConfigPathFor builds the path for a configuration file or directory of the
+// profile.
+func (p *ProfilePaths) ConfigPathFor(relPath ConfigPath) string {
+	return p.base.ConfigPathFor(p.configPath(relPath))
+}
+
+// DataPathFor builds the path for a data file or directory of the profile.
+func (p *ProfilePaths) DataPathFor(relPath DataPath) string {
+	return p.base.DataPathFor(p.dataPath(relPath))
+}
+
+// StatePathFor builds the path for a state file or directory of the profile.
+func (p *ProfilePaths) StatePathFor(relPath StatePath) string {
+	return p.base.StatePathFor(p.statePath(relPath))
+}
+
+// Roots returns the root of every category of the profile, if the base paths
+// expose them. The origin is the one of the base roots.
+func (p *ProfilePaths) Roots() map[PathCategory]Root {
+	baseRoots := RootsOf(p.base)
+	if baseRoots == nil {
+		return nil
+	}
+
+	roots := make(map[PathCategory]Root, len(baseRoots))
+	for category, root := range baseRoots {
+		roots[category] = Root{
+			Path:   filepath.Join(root.Path, profilesDirName, p.profile),
+			Origin: root.Origin,
+		}
+	}
+	return roots
+}
+
+func (p *ProfilePaths) fileSystem() vgfs.FileSystem {
+	return FileSystemOf(p.base)
+}
+
+func (p *ProfilePaths) permissionPolicy() *PermissionPolicy {
+	return PermissionPolicyOf(p.base)
+}
+
+func (p *ProfilePaths) cachePath(relPath CachePath) CachePath {
+	return CachePath(filepath.Join(profilesDirName, p.profile, relPath.String()))
+}
+
+func (p *ProfilePaths) configPath(relPath ConfigPath) ConfigPath {
+	return ConfigPath(filepath.Join(profilesDirName, p.profile, relPath.String()))
+}
+
+func (p *ProfilePaths) dataPath(relPath DataPath) DataPath {
+	return DataPath(filepath.Join(profilesDirName, p.profile, relPath.String()))
+}
+
+func (p *ProfilePaths) statePath(relPath StatePath) StatePath {
+	return StatePath(filepath.Join(profilesDirName, p.profile, relPath.String()))
+}
+
+// ListProfiles returns the names of the profiles existing in any category,
+// sorted alphabetically.
+func ListProfiles(base Paths) ([]string, error) {
+	fsys := FileSystemOf(base)
+
+	profilesHomes := []string{
+		base.CachePathFor(CachePath(profilesDirName)),
+		base.ConfigPathFor(ConfigPath(profilesDirName)),
+		base.DataPathFor(DataPath(profilesDirName)),
+		base.StatePathFor(StatePath(profilesDirName)),
+	}
+
+	found := map[string]struct{}{}
+	for _, profilesHome := range profilesHomes {
+		entries, err := fsys.ReadDir(profilesHome)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("couldn't read directory %s: %w", profilesHome, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() && profileNameRegex.MatchString(entry.Name()) {
+				found[entry.Name()] = struct{}{}
+			}
+		}
+	}
+
+	profiles := make([]string, 0, len(found))
+	for profile := range found {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+// DefaultProfile returns the default profile. It returns an empty string if
+// none is set.
+func DefaultProfile(base Paths) (string, error) {
+	fsys := FileSystemOf(base)
+	statePath := base.StatePathFor(ProfilesStateFile)
+
+	exists, err := vgfs.FileExistsIn(fsys, statePath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't verify the profiles state file presence: %w", err)
+	}
+	if !exists {
+		return "", nil
+	}
+
+	buf, err := vgfs.ReadFileIn(fsys, statePath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read the profiles state file: %w", err)
+	}
+
+	state := &profilesState{}
+	if err := decodeStructuredFile(statePath, buf, state); err != nil {
+		return "", fmt.Errorf("couldn't decode the profiles state file: %w", err)
+	}
+
+	return state.DefaultProfile, nil
+}
+
+// SetDefaultProfile sets the profile used when none is specified. An empty
+// profile unsets the default one.
+func SetDefaultProfile(base Paths, profile string) error {
+	if profile != "" {
+		if err := validateProfileName(profile); err != nil {
+			return err
+		}
+	}
+
+	statePath, err := base.CreateStatePathFor(ProfilesStateFile)
+	if err != nil {
+		return fmt.Errorf("couldn't create the state directory: %w", err)
+	}
+
+	codec := CodecForPath(statePath)
+	buf, err := codec.Encode(&profilesState{
+		DefaultProfile: profile,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't encode to %s: %w", codec.Name(), err)
+	}
+
+	if err := vgfs.WriteFileIn(FileSystemOf(base), statePath, buf); err != nil {
+		return fmt.Errorf("couldn't write the profiles state file: %w", err)
+	}
+
+	return nil
+}
+
+func validateProfileName(profile string) error {
+	if !profileNameRegex.MatchString(profile) {
+		return fmt.Errorf("invalid profile name %q: it must start with a letter or a digit, and contain only letters, digits, '.', '_' and '-'", profile)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package paths_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Run("Profile paths are namespaced in every category", testProfilePathsAreNamespacedInEveryCategory)
+	t.Run("Profile paths are isolated from each other", testProfilePathsAreIsolatedFromEachOther)
+	t.Run("Invalid profile names are rejected", testInvalidProfileNamesAreRejected)
+	t.Run("Listing profiles returns the existing ones", testListingProfilesReturnsTheExistingOnes)
+	t.Run("Default profile is used when none is specified", testDefaultProfileIsUsedWhenNoneIsSpecified)
+}
+
+func newMemoryCustomPaths() *paths.CustomPaths {
+	return &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: vgfs.NewMemoryFileSystem(),
+	}
+}
+
+func testProfilePathsAreNamespacedInEveryCategory(t *testing.T) {
+	base := newMemoryCustomPaths()
+
+	profilePaths, err := paths.NewProfilePaths(base, "fairground")
+	require.NoError(t, err)
+
+	assert.Equal(t, "fairground", profilePaths.Profile())
+	assert.Equal(t, filepath.Join("/vega", "cache", "profiles", "fairground", "data-node"), profilePaths.CachePathFor(paths.DataNodeCacheHome))
+	assert.Equal(t, filepath.Join("/vega", "config", "profiles", "fairground", "node", "config.toml"), profilePaths.ConfigPathFor(paths.NodeDefaultConfigFile))
+	assert.Equal(t, filepath.Join("/vega", "data", "profiles", "fairground", "wallets"), profilePaths.DataPathFor(paths.WalletsDataHome))
+	assert.Equal(t, filepath.Join("/vega", "state", "profiles", "fairground", "node", "logs"), profilePaths.StatePathFor(paths.NodeLogsHome))
+
+	dir, err := profilePaths.CreateStateDirFor(paths.NodeLogsHome)
+	require.NoError(t, err)
+	exists, err := vgfs.PathExistsIn(base.FileSystem, dir)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, base.FileSystem, paths.FileSystemOf(profilePaths))
+	assert.Equal(t, paths.Root{
+		Path:   filepath.Join("/vega", "config", "profiles", "fairground"),
+		Origin: paths.CustomHomeRootOrigin,
+	}, paths.RootsOf(profilePaths)[paths.ConfigPathCategory])
+}
+
+func testProfilePathsAreIsolatedFromEachOther(t *testing.T) {
+	base := newMemoryCustomPaths()
+	mainnet, err := paths.NewProfilePaths(base, "mainnet")
+	require.NoError(t, err)
+	fairground, err := paths.NewProfilePaths(base, "fairground")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, mainnet.ConfigPathFor(paths.NodeDefaultConfigFile), fairground.ConfigPathFor(paths.NodeDefaultConfigFile))
+	assert.NotEqual(t, base.ConfigPathFor(paths.NodeDefaultConfigFile), fairground.ConfigPathFor(paths.NodeDefaultConfigFile))
+}
+
+func testInvalidProfileNamesAreRejected(t *testing.T) {
+	for _, profile := range []string{"", ".", "..", "../mainnet", "main/net", "-mainnet"} {
+		_, err := paths.NewProfilePaths(newMemoryCustomPaths(), profile)
+		assert.Error(t, err, profile)
+	}
+}
+
+func testListingProfilesReturnsTheExistingOnes(t *testing.T) {
+	base := newMemoryCustomPaths()
+
+	profiles, err := paths.ListProfiles(base)
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	mainnet, err := paths.NewProfilePaths(base, "mainnet")
+	require.NoError(t, err)
+	_, err = mainnet.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+
+	devnet, err := paths.NewProfilePaths(base, "devnet")
+	require.NoError(t, err)
+	_, err = devnet.CreateStateDirFor(paths.NodeLogsHome)
+	require.NoError(t, err)
+
+	profiles, err = paths.ListProfiles(base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"devnet", "mainnet"}, profiles)
+}
+
+func testDefaultProfileIsUsedWhenNoneIsSpecified(t *testing.T) {
+	base := newMemoryCustomPaths()
+
+	// Without default profile.
+	defaultProfile, err := paths.DefaultProfile(base)
+	require.NoError(t, err)
+	assert.Empty(t, defaultProfile)
+	resolvedPaths, err := paths.ResolveProfile(base, "")
+	require.NoError(t, err)
+	assert.Equal(t, base, resolvedPaths)
+
+	// With default profile.
+	require.NoError(t, paths.SetDefaultProfile(base, "fairground"))
+	defaultProfile, err = paths.DefaultProfile(base)
+	require.NoError(t, err)
+	assert.Equal(t, "fairground", defaultProfile)
+	resolvedPaths, err = paths.ResolveProfile(base, "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/vega", "config", "profiles", "fairground", "node"), resolvedPaths.ConfigPathFor(paths.NodeConfigHome))
+
+	// With explicit profile.
+	resolvedPaths, err = paths.ResolveProfile(base, "mainnet")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/vega", "config", "profiles", "mainnet", "node"), resolvedPaths.ConfigPathFor(paths.NodeConfigHome))
+
+	// Unset default profile.
+	require.NoError(t, paths.SetDefaultProfile(base, ""))
+	defaultProfile, err = paths.DefaultProfile(base)
+	require.NoError(t, err)
+	assert.Empty(t, defaultProfile)
+
+	require.Error(t, paths.SetDefaultProfile(base, "../mainnet"))
+}
@@ -0,0 +1,249 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgrand "code.vegaprotocol.io/shared/libs/rand"
+)
+
+// ReadOnlyPathError is returned by the ReadOnlyPaths when a directory would
+// have to be created.
+type ReadOnlyPathError struct {
+	Path string
+}
+
+func (e ReadOnlyPathError) Error() string {
+	return fmt.Sprintf("couldn't create %s: the paths are read-only", e.Path)
+}
+
+// ReadOnlyPaths resolves the paths like the wrapped Paths implementation, but
+// never creates anything. It is meant for immutable deployments, like
+// containers with a read-only root file system, where the writable
+// directories are mounted beforehand.
+//
+// The Create* methods return the path if the directories it requires already
+// exist, and a ReadOnlyPathError naming the missing directory otherwise.
+type ReadOnlyPaths struct {
+	base Paths
+}
+
+func NewReadOnlyPaths(base Paths) *ReadOnlyPaths {
+	return &ReadOnlyPaths{
+		base: base,
+	}
+}
+
+// CreateCachePathFor returns the path for a cache file if its directory
+// exists.
+func (p *ReadOnlyPaths) CreateCachePathFor(relFilePath CachePath) (string, error) {
+	return p.requireParentDir(p.base.CachePathFor(relFilePath))
+}
+
+// CreateCacheDirFor returns the path for a cache directory if it exists.
+func (p *ReadOnlyPaths) CreateCacheDirFor(relDirPath CachePath) (string, error) {
+	return p.requireDir(p.base.CachePathFor(relDirPath))
+}
+
+// CreateConfigPathFor returns the path for a configuration file if its
+// directory exists.
+func (p *ReadOnlyPaths) CreateConfigPathFor(relFilePath ConfigPath) (string, error) {
+	return p.requireParentDir(p.base.ConfigPathFor(relFilePath))
+}
+
+// CreateConfigDirFor returns the path for a config directory if it exists.
+func (p *ReadOnlyPaths) CreateConfigDirFor(relDirPath ConfigPath) (string, error) {
+	return p.requireDir(p.base.ConfigPathFor(relDirPath))
+}
+
+// CreateDataPathFor returns the path for a data file if its directory exists.
+func (p *ReadOnlyPaths) CreateDataPathFor(relFilePath DataPath) (string, error) {
+	return p.requireParentDir(p.base.DataPathFor(relFilePath))
+}
+
+// CreateDataDirFor returns the path for a data directory if it exists.
+func (p *ReadOnlyPaths) CreateDataDirFor(relDirPath DataPath) (string, error) {
+	return p.requireDir(p.base.DataPathFor(relDirPath))
+}
+
+// CreateStatePathFor returns the path for a state file if its directory
+// exists.
+func (p *ReadOnlyPaths) CreateStatePathFor(relFilePath StatePath) (string, error) {
+	return p.requireParentDir(p.base.StatePathFor(relFilePath))
+}
+
+// CreateStateDirFor returns the path for a state directory if it exists.
+func (p *ReadOnlyPaths) CreateStateDirFor(relDirPath StatePath) (string, error) {
+	return p.requireDir(p.base.StatePathFor(relDirPath))
+}
+
+// CachePathFor builds the path for a cache file or directory. It doesn't
+// create any resources.
+func (p *ReadOnlyPaths) CachePathFor(relPath CachePath) string {
+	return p.base.CachePathFor(relPath)
+}
+
+// ConfigPathFor builds the path for a config file or directory. It doesn't
+// create any resources.
+func (p *ReadOnlyPaths) ConfigPathFor(relPath ConfigPath) string {
+	return p.base.ConfigPathFor(relPath)
+}
+
+// DataPathFor builds the path for a data file or directory. It doesn't create
+// any resources.
+func (p *ReadOnlyPaths) DataPathFor(relPath DataPath) string {
+	return p.base.DataPathFor(relPath)
+}
+
+// StatePathFor builds the path for a state file or directory. It doesn't
+// create any resources.
+func (p *ReadOnlyPaths) StatePathFor(relPath StatePath) string {
+	return p.base.StatePathFor(relPath)
+}
+
+// Roots returns the roots of the wrapped Paths, if it exposes them.
+func (p *ReadOnlyPaths) Roots() map[PathCategory]Root {
+	return RootsOf(p.base)
+}
+
+func (p *ReadOnlyPaths) fileSystem() vgfs.FileSystem {
+	return FileSystemOf(p.base)
+}
+
+func (p *ReadOnlyPaths) permissionPolicy() *PermissionPolicy {
+	return PermissionPolicyOf(p.base)
+}
+
+func (p *ReadOnlyPaths) requireParentDir(filePath string) (string, error) {
+	if _, err := p.requireDir(filepath.Dir(filePath)); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+func (p *ReadOnlyPaths) requireDir(dirPath string) (string, error) {
+	info, err := p.fileSystem().Stat(dirPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ReadOnlyPathError{Path: dirPath}
+		}
+		return "", fmt.Errorf("couldn't verify the presence of %s: %w", dirPath, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dirPath)
+	}
+	return dirPath, nil
+}
+
+// PreflightEntry tells if a directory an application writes to is writable.
+type PreflightEntry struct {
+	Name     string       `json:"name"`
+	Category PathCategory `json:"category"`
+	// Path is the directory that has to be writable. For a file, it is the
+	// directory containing it.
+	Path     string `json:"path"`
+	Exists   bool   `json:"exists"`
+	Writable bool   `json:"writable"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type PreflightReport struct {
+	Application string           `json:"application"`
+	Entries     []PreflightEntry `json:"entries"`
+}
+
+// Ready tells if all the directories are writable.
+func (r *PreflightReport) Ready() bool {
+	for _, entry := range r.Entries {
+		if !entry.Writable {
+			return false
+		}
+	}
+	return true
+}
+
+// Preflight lists the directories the given application needs to be writable,
+// which are its registered paths flagged as writable, and verifies they are. A
+// directory that doesn't exist yet is writable if it can be created under its
+// closest existing parent.
+//
+// The verification writes, and removes, a probe file in every directory.
+func Preflight(vegaPaths Paths, application string) *PreflightReport {
+	fsys := FileSystemOf(vegaPaths)
+
+	report := &PreflightReport{
+		Application: application,
+		Entries:     []PreflightEntry{},
+	}
+
+	for _, definition := range RegisteredPaths() {
+		if definition.Application != application {
+			continue
+		}
+		if !definition.Writable {
+			continue
+		}
+
+		dirPath := definition.Resolve(vegaPaths)
+		if definition.Kind == FilePathKind {
+			dirPath = filepath.Dir(dirPath)
+		}
+
+		entry := PreflightEntry{
+			Name:     definition.Name,
+			Category: definition.Category,
+			Path:     dirPath,
+		}
+
+		exists, err := vgfs.PathExistsIn(fsys, dirPath)
+		if err != nil {
+			entry.Reason = fmt.Sprintf("couldn't verify the presence of the directory: %v", err)
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+		entry.Exists = exists
+
+		if err := probeWritableDir(fsys, dirPath); err != nil {
+			entry.Reason = err.Error()
+		} else {
+			entry.Writable = true
+		}
+
+		report.Entries = append(report.Entries, entry)
+	}
+
+	return report
+}
+
+// probeWritableDir verifies a file can be written in the directory, or in its
+// closest existing parent if it doesn't exist.
+func probeWritableDir(fsys vgfs.FileSystem, dirPath string) error {
+	existingDir := dirPath
+	for {
+		exists, err := vgfs.PathExistsIn(fsys, existingDir)
+		if err != nil {
+			return fmt.Errorf("couldn't verify the presence of %s: %w", existingDir, err)
+		}
+		if exists {
+			break
+		}
+		parent := filepath.Dir(existingDir)
+		if parent == existingDir {
+			return fmt.Errorf("none of the parents of %s exists", dirPath)
+		}
+		existingDir = parent
+	}
+
+	probePath := filepath.Join(existingDir, ".vega-preflight-"+vgrand.RandomStr(8))
+	if err := fsys.WriteFile(probePath, []byte{}, 0600); err != nil {
+		return fmt.Errorf("%s is not writable: %w", existingDir, err)
+	}
+	if err := fsys.Remove(probePath); err != nil {
+		return fmt.Errorf("couldn't remove the probe file %s: %w", probePath, err)
+	}
+
+	return nil
+}
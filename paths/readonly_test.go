@@ -0,0 +1,128 @@
+package paths_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyPaths(t *testing.T) {
+	t.Run("Resolving paths does not create anything", testResolvingPathsDoesNotCreateAnything)
+	t.Run("Creating missing paths fails with the path", testCreatingMissingPathsFailsWithThePath)
+	t.Run("Creating existing paths succeeds", testCreatingExistingPathsSucceeds)
+	t.Run("Preflight reports the writable directories", testPreflightReportsTheWritableDirectories)
+	t.Run("Preflight reports the read-only directories", testPreflightReportsTheReadOnlyDirectories)
+	t.Run("Preflight verifies the writable data and config paths", testPreflightVerifiesTheWritableDataAndConfigPaths)
+}
+
+func testResolvingPathsDoesNotCreateAnything(t *testing.T) {
+	base := newMemoryCustomPaths()
+	vegaPaths := paths.NewReadOnlyPaths(base)
+
+	assert.Equal(t, base.ConfigPathFor(paths.NodeDefaultConfigFile), vegaPaths.ConfigPathFor(paths.NodeDefaultConfigFile))
+	assert.Equal(t, base.StatePathFor(paths.NodeLogsHome), vegaPaths.StatePathFor(paths.NodeLogsHome))
+	assert.Equal(t, paths.RootsOf(base), paths.RootsOf(vegaPaths))
+}
+
+func testCreatingMissingPathsFailsWithThePath(t *testing.T) {
+	base := newMemoryCustomPaths()
+	vegaPaths := paths.NewReadOnlyPaths(base)
+
+	_, err := vegaPaths.CreateStateDirFor(paths.NodeLogsHome)
+	var readOnlyErr paths.ReadOnlyPathError
+	require.ErrorAs(t, err, &readOnlyErr)
+	assert.Equal(t, base.StatePathFor(paths.NodeLogsHome), readOnlyErr.Path)
+
+	_, err = vegaPaths.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.ErrorAs(t, err, &readOnlyErr)
+	assert.Equal(t, base.ConfigPathFor(paths.NodeConfigHome), readOnlyErr.Path)
+
+	exists, err := vgfs.PathExistsIn(base.FileSystem, base.ConfigPathFor(paths.NodeConfigHome))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testCreatingExistingPathsSucceeds(t *testing.T) {
+	base := newMemoryCustomPaths()
+	_, err := base.CreateConfigDirFor(paths.NodeConfigHome)
+	require.NoError(t, err)
+	vegaPaths := paths.NewReadOnlyPaths(base)
+
+	dirPath, err := vegaPaths.CreateConfigDirFor(paths.NodeConfigHome)
+	require.NoError(t, err)
+	assert.Equal(t, base.ConfigPathFor(paths.NodeConfigHome), dirPath)
+
+	filePath, err := vegaPaths.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, base.ConfigPathFor(paths.NodeDefaultConfigFile), filePath)
+}
+
+func testPreflightReportsTheWritableDirectories(t *testing.T) {
+	base := newMemoryCustomPaths()
+	_, err := base.CreateStateDirFor(paths.NodeLogsHome)
+	require.NoError(t, err)
+
+	report := paths.Preflight(paths.NewReadOnlyPaths(base), "node")
+
+	assert.True(t, report.Ready())
+	names := []string{}
+	for _, entry := range report.Entries {
+		names = append(names, entry.Name)
+		assert.Contains(t, []paths.PathCategory{paths.CachePathCategory, paths.StatePathCategory}, entry.Category)
+	}
+	assert.Contains(t, names, "NodeLogsHome")
+	assert.Contains(t, names, "CheckpointStateHome")
+	assert.NotContains(t, names, "NodeConfigHome")
+
+	// The probe files are removed.
+	entries, err := base.FileSystem.ReadDir(base.StatePathFor(paths.NodeLogsHome))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func testPreflightReportsTheReadOnlyDirectories(t *testing.T) {
+	fsys := vgfs.NewFaultyFileSystem(vgfs.NewMemoryFileSystem())
+	base := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: fsys,
+	}
+	_, err := base.CreateStateDirFor(paths.NodeStateHome)
+	require.NoError(t, err)
+	fsys.InjectFault(vgfs.OpWriteFile, filepath.Join("/vega", "state"), errors.New("read-only file system"))
+
+	report := paths.Preflight(base, "node")
+
+	assert.False(t, report.Ready())
+	for _, entry := range report.Entries {
+		if entry.Name == "NodeLogsHome" {
+			assert.False(t, entry.Exists)
+			assert.False(t, entry.Writable)
+			assert.Contains(t, entry.Reason, "read-only file system")
+		}
+	}
+}
+
+func testPreflightVerifiesTheWritableDataAndConfigPaths(t *testing.T) {
+	report := paths.Preflight(newMemoryCustomPaths(), "wallet-service")
+
+	assert.True(t, report.Ready())
+	categories := map[string]paths.PathCategory{}
+	for _, entry := range report.Entries {
+		categories[entry.Name] = entry.Category
+	}
+	assert.Equal(t, paths.ConfigPathCategory, categories["WalletServiceNetworksConfigHome"])
+	assert.Equal(t, paths.DataPathCategory, categories["WalletServiceRSAKeysDataHome"])
+	assert.Equal(t, paths.StatePathCategory, categories["WalletServiceLogsHome"])
+
+	for _, definition := range paths.RegisteredPaths() {
+		if definition.Category == paths.CachePathCategory || definition.Category == paths.StatePathCategory {
+			assert.True(t, definition.Writable, definition.Name)
+		}
+	}
+}
@@ -0,0 +1,587 @@
+package paths
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PathCategory is the category of a path in the Vega file structure.
+type PathCategory string
+
+const (
+	CachePathCategory  PathCategory = "cache"
+	ConfigPathCategory PathCategory = "config"
+	DataPathCategory   PathCategory = "data"
+	StatePathCategory  PathCategory = "state"
+)
+
+// PathKind tells if a path is expected to be a file or a directory.
+type PathKind string
+
+const (
+	FilePathKind PathKind = "file"
+	DirPathKind  PathKind = "directory"
+)
+
+// SharedApplication is the application owning the paths used by several
+// applications.
+const SharedApplication = "shared"
+
+// PathDefinition declares a path of the Vega file structure.
+type PathDefinition struct {
+	// Name identifies the path, like "NodeDefaultConfigFile". It is unique
+	// across all categories.
+	Name     string       `json:"name"`
+	Category PathCategory `json:"category"`
+	Kind     PathKind     `json:"kind"`
+	// Application is the application owning the path, like "node" or
+	// "wallet-service".
+	Application string `json:"application"`
+	// Path is the path relative to the home of its category, like the value
+	// of a ConfigPath.
+	Path        string `json:"path"`
+	Description string `json:"description"`
+	// Writable tells if the application writes in the path while running,
+	// so it must be writable for the application to work. Preflight
+	// verifies these paths.
+	Writable bool `json:"writable"`
+}
+
+// Resolve returns the full path of the definition for the given Paths.
+func (d PathDefinition) Resolve(vegaPaths Paths) string {
+	return PathFor(vegaPaths, d.Category, d.Path)
+}
+
+// PathFor returns the full path of the path relative to the root of the given
+// category. It returns an empty string for an unknown category.
+func PathFor(vegaPaths Paths, category PathCategory, relPath string) string {
+	switch category {
+	case CachePathCategory:
+		return vegaPaths.CachePathFor(CachePath(relPath))
+	case ConfigPathCategory:
+		return vegaPaths.ConfigPathFor(ConfigPath(relPath))
+	case DataPathCategory:
+		return vegaPaths.DataPathFor(DataPath(relPath))
+	case StatePathCategory:
+		return vegaPaths.StatePathFor(StatePath(relPath))
+	default:
+		return ""
+	}
+}
+
+// CreatePathFor behaves like PathFor, but creates the directory containing the
+// file, with the mode the permission policy of the Paths grants it.
+func CreatePathFor(vegaPaths Paths, category PathCategory, relPath string) (string, error) {
+	switch category {
+	case CachePathCategory:
+		return vegaPaths.CreateCachePathFor(CachePath(relPath))
+	case ConfigPathCategory:
+		return vegaPaths.CreateConfigPathFor(ConfigPath(relPath))
+	case DataPathCategory:
+		return vegaPaths.CreateDataPathFor(DataPath(relPath))
+	case StatePathCategory:
+		return vegaPaths.CreateStatePathFor(StatePath(relPath))
+	default:
+		return "", fmt.Errorf("unknown path category %q", category)
+	}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]PathDefinition{}
+)
+
+func init() {
+	for _, definition := range builtinPathDefinitions() {
+		MustRegisterPath(definition)
+	}
+}
+
+// RegisterPath declares a path, so it is listed, explained and verified
+// alongside the built-in ones. Applications register their own paths at
+// initialisation.
+func RegisterPath(definition PathDefinition) error {
+	if definition.Name == "" {
+		return fmt.Errorf("the path name is required")
+	}
+	if definition.Path == "" {
+		return fmt.Errorf("the path of %q is required", definition.Name)
+	}
+
+	switch definition.Category {
+	case CachePathCategory, ConfigPathCategory, DataPathCategory, StatePathCategory:
+	default:
+		return fmt.Errorf("path %q has an unsupported category %q", definition.Name, definition.Category)
+	}
+
+	switch definition.Kind {
+	case FilePathKind, DirPathKind:
+	default:
+		return fmt.Errorf("path %q has an unsupported kind %q", definition.Name, definition.Kind)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[definition.Name]; ok {
+		return fmt.Errorf("path %q is already registered", definition.Name)
+	}
+
+	registry[definition.Name] = definition
+	return nil
+}
+
+// MustRegisterPath behaves like RegisterPath, but panics on error.
+func MustRegisterPath(definition PathDefinition) {
+	if err := RegisterPath(definition); err != nil {
+		panic(err)
+	}
+}
+
+// RegisteredPaths returns the registered paths sorted by category, and then
+// by name.
+func RegisteredPaths() []PathDefinition {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	definitions := make([]PathDefinition, 0, len(registry))
+	for _, definition := range registry {
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].Category != definitions[j].Category {
+			return categoryRank(definitions[i].Category) < categoryRank(definitions[j].Category)
+		}
+		return definitions[i].Name < definitions[j].Name
+	})
+
+	return definitions
+}
+
+// LookupRegisteredPath returns the definition of the path registered under the
+// given name.
+func LookupRegisteredPath(name string) (PathDefinition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	definition, ok := registry[name]
+	return definition, ok
+}
+
+// LongestRegisteredPathNameLen returns the length of the longest registered
+// path name. It is used for text formatting.
+func LongestRegisteredPathNameLen() int {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	longest := 0
+	for name := range registry {
+		if len(name) > longest {
+			longest = len(name)
+		}
+	}
+	return longest
+}
+
+func categoryRank(category PathCategory) int {
+	switch category {
+	case CachePathCategory:
+		return 0
+	case ConfigPathCategory:
+		return 1
+	case DataPathCategory:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func builtinPathDefinitions() []PathDefinition {
+	return []PathDefinition{
+		{
+			Name:        "DataNodeCacheHome",
+			Category:    CachePathCategory,
+			Kind:        DirPathKind,
+			Application: "data-node",
+			Path:        DataNodeCacheHome.String(),
+			Description: `This folder contains the cache used by the data-node.`,
+			Writable:    true,
+		},
+		{
+			Name:        "FetchedFilesCacheHome",
+			Category:    CachePathCategory,
+			Kind:        DirPathKind,
+			Application: SharedApplication,
+			Path:        FetchedFilesCacheHome.String(),
+			Description: `This folder contains the copies of the files fetched from remote sources, used when these sources are unreachable.`,
+			Writable:    true,
+		},
+		{
+			Name:        "DataNodeConfigHome",
+			Category:    ConfigPathCategory,
+			Kind:        DirPathKind,
+			Application: "data-node",
+			Path:        DataNodeConfigHome.String(),
+			Description: `This folder contains the configuration files used by the data-node.`,
+		},
+		{
+			Name:        "DataNodeDefaultConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "data-node",
+			Path:        DataNodeDefaultConfigFile.String(),
+			Description: `This file contains the configuration used by the data-node.`,
+		},
+		{
+			Name:        "FaucetConfigHome",
+			Category:    ConfigPathCategory,
+			Kind:        DirPathKind,
+			Application: "faucet",
+			Path:        FaucetConfigHome.String(),
+			Description: `This folder contains the configuration files used by the faucet.`,
+		},
+		{
+			Name:        "FaucetDefaultConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "faucet",
+			Path:        FaucetDefaultConfigFile.String(),
+			Description: `This file contains the configuration used by the faucet.`,
+		},
+		{
+			Name:        "NodeConfigHome",
+			Category:    ConfigPathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        NodeConfigHome.String(),
+			Description: `This folder contains the configuration files used by the node.`,
+		},
+		{
+			Name:        "NodeDefaultConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "node",
+			Path:        NodeDefaultConfigFile.String(),
+			Description: `This file contains the configuration used by the node.`,
+		},
+		{
+			Name:        "NodeWalletsConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "node",
+			Path:        NodeWalletsConfigFile.String(),
+			Description: `This file contains information related to the registered node's wallets used by the node.`,
+		},
+		{
+			Name:        "WalletCLIConfigHome",
+			Category:    ConfigPathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-cli",
+			Path:        WalletCLIConfigHome.String(),
+			Description: `This folder contains the configuration files used by the wallet-cli.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletCLIDefaultConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "wallet-cli",
+			Path:        WalletCLIDefaultConfigFile.String(),
+			Description: `This file contains the configuration used by the wallet-cli.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletAppConfigHome",
+			Category:    ConfigPathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-app",
+			Path:        WalletAppConfigHome.String(),
+			Description: `This folder contains the configuration files used by the wallet-app.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletAppDefaultConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "wallet-app",
+			Path:        WalletAppDefaultConfigFile.String(),
+			Description: `This file contains the configuration used by the wallet-app.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServiceConfigHome",
+			Category:    ConfigPathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-service",
+			Path:        WalletServiceConfigHome.String(),
+			Description: `This folder contains the configuration files used by the wallet's service.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServiceNetworksConfigHome",
+			Category:    ConfigPathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-service",
+			Path:        WalletServiceNetworksConfigHome.String(),
+			Description: `This folder contains the network configuration files used by the wallet's service.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServiceDefaultConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "wallet-service",
+			Path:        WalletServiceDefaultConfigFile.String(),
+			Description: `This file contains the configuration used by the wallet's service.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServicePermissionsConfigFile",
+			Category:    ConfigPathCategory,
+			Kind:        FilePathKind,
+			Application: "wallet-service",
+			Path:        WalletServicePermissionsConfigFile.String(),
+			Description: `This file contains the permissions that control the access to the wallets.`,
+			Writable:    true,
+		},
+		{
+			Name:        "NodeDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        NodeDataHome.String(),
+			Description: `This folder contains the data managed by the node.`,
+		},
+		{
+			Name:        "NodeWalletsDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        NodeWalletsDataHome.String(),
+			Description: `This folder contains the data managed by the node's wallets.`,
+		},
+		{
+			Name:        "VegaNodeWalletsDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        VegaNodeWalletsDataHome.String(),
+			Description: `This folder contains the Vega wallet registered as node's wallet, used by the node to sign Vega commands.`,
+		},
+		{
+			Name:        "EthereumNodeWalletsDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        EthereumNodeWalletsDataHome.String(),
+			Description: `This folder contains the Ethereum wallet registered as node's wallet, used by the node to interact with the Ethereum blockchain.`,
+		},
+		{
+			Name:        "FaucetDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "faucet",
+			Path:        FaucetDataHome.String(),
+			Description: `This folder contains the data used by the faucet.`,
+		},
+		{
+			Name:        "FaucetWalletsDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "faucet",
+			Path:        FaucetWalletsDataHome.String(),
+			Description: `This folder contains the Vega wallet used by the faucet to sign its deposit commands.`,
+		},
+		{
+			Name:        "WalletsDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet",
+			Path:        WalletsDataHome.String(),
+			Description: `This folder contains the "user's" wallets. These wallets are used by the user to issue commands to a Vega network.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServiceDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-service",
+			Path:        WalletServiceDataHome.String(),
+			Description: `This folder contains the data used by the wallet's service.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServiceRSAKeysDataHome",
+			Category:    DataPathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-service",
+			Path:        WalletServiceRSAKeysDataHome.String(),
+			Description: `This folder contains the RSA keys used by the wallet's service for authentication.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServicePublicRSAKeyDataFile",
+			Category:    DataPathCategory,
+			Kind:        FilePathKind,
+			Application: "wallet-service",
+			Path:        WalletServicePublicRSAKeyDataFile.String(),
+			Description: `This file contains the public RSA key used by the wallet's service for authentication.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServicePrivateRSAKeyDataFile",
+			Category:    DataPathCategory,
+			Kind:        FilePathKind,
+			Application: "wallet-service",
+			Path:        WalletServicePrivateRSAKeyDataFile.String(),
+			Description: `This file contains the private RSA key used by the wallet's service for authentication.`,
+			Writable:    true,
+		},
+		{
+			Name:        "DataNodeStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "data-node",
+			Path:        DataNodeStateHome.String(),
+			Description: `This folder contains the state files used by the data-node.`,
+			Writable:    true,
+		},
+		{
+			Name:        "DataNodeLogsHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "data-node",
+			Path:        DataNodeLogsHome.String(),
+			Description: `This folder contains the log files generated by the data-node.`,
+			Writable:    true,
+		},
+		{
+			Name:        "DataNodeStorageHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "data-node",
+			Path:        DataNodeStorageHome.String(),
+			Description: `This folder contains the consolidated state, built out of the Vega network events, and served by the data-node's API.`,
+			Writable:    true,
+		},
+		{
+			Name:        "LegacyBackupsStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: SharedApplication,
+			Path:        LegacyBackupsStateHome.String(),
+			Description: `This folder contains the copies of the files moved out of the legacy Vega directories during their migration.`,
+			Writable:    true,
+		},
+		{
+			Name:        "ProfilesStateFile",
+			Category:    StatePathCategory,
+			Kind:        FilePathKind,
+			Application: SharedApplication,
+			Path:        ProfilesStateFile.String(),
+			Description: `This file contains the state of the network profiles, like the default one.`,
+			Writable:    true,
+		},
+		{
+			Name:        "NodeStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        NodeStateHome.String(),
+			Description: `This folder contains the state files used by the node.`,
+			Writable:    true,
+		},
+		{
+			Name:        "NodeLogsHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        NodeLogsHome.String(),
+			Description: `This folder contains the log files generated by the node.`,
+			Writable:    true,
+		},
+		{
+			Name:        "CheckpointStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        CheckpointStateHome.String(),
+			Description: `This folder contains the network checkpoints generated by the node.`,
+			Writable:    true,
+		},
+		{
+			Name:        "SnapshotStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        SnapshotStateHome.String(),
+			Description: `This folder contains the Tendermint snapshots of the application state generated by the node.`,
+			Writable:    true,
+		},
+		{
+			Name:        "SnapshotDBStateFile",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "node",
+			Path:        SnapshotDBStateFile.String(),
+			Description: `This file is a database containing the snapshots' data of the of the application state generated by the node`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletCLIStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-cli",
+			Path:        WalletCLIStateHome.String(),
+			Description: `This folder contains the state files used by the wallet-cli.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletCLILogsHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-cli",
+			Path:        WalletCLILogsHome.String(),
+			Description: `This folder contains the log files generated by the wallet-cli.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletAppStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-app",
+			Path:        WalletAppStateHome.String(),
+			Description: `This folder contains the state files used by the wallet-app.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletAppLogsHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-app",
+			Path:        WalletAppLogsHome.String(),
+			Description: `This folder contains the log files generated by the wallet-app.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServiceStateHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-service",
+			Path:        WalletServiceStateHome.String(),
+			Description: `This folder contains the state files used by the wallet's service.`,
+			Writable:    true,
+		},
+		{
+			Name:        "WalletServiceLogsHome",
+			Category:    StatePathCategory,
+			Kind:        DirPathKind,
+			Application: "wallet-service",
+			Path:        WalletServiceLogsHome.String(),
+			Description: `This folder contains the log files generated by the wallet's service'.`,
+			Writable:    true,
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package paths_test
+
+import (
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgrand "code.vegaprotocol.io/shared/libs/rand"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathsRegistry(t *testing.T) {
+	t.Run("Built-in paths are registered", testBuiltinPathsAreRegistered)
+	t.Run("Registered paths are listed, explained and diagnosed", testRegisteredPathsAreListedExplainedAndDiagnosed)
+	t.Run("Registering path twice fails", testRegisteringPathTwiceFails)
+	t.Run("Registering invalid path fails", testRegisteringInvalidPathFails)
+}
+
+func testBuiltinPathsAreRegistered(t *testing.T) {
+	definition, ok := paths.LookupRegisteredPath("NodeDefaultConfigFile")
+
+	require.True(t, ok)
+	assert.Equal(t, paths.ConfigPathCategory, definition.Category)
+	assert.Equal(t, paths.FilePathKind, definition.Kind)
+	assert.Equal(t, "node", definition.Application)
+	assert.Equal(t, paths.NodeDefaultConfigFile.String(), definition.Path)
+	assert.GreaterOrEqual(t, paths.LongestRegisteredPathNameLen(), len("WalletServicePermissionsConfigFile"))
+
+	for _, definition := range paths.RegisteredPaths() {
+		assert.NotEmpty(t, definition.Description, definition.Name)
+		assert.NotEmpty(t, definition.Application, definition.Name)
+	}
+}
+
+func testRegisteredPathsAreListedExplainedAndDiagnosed(t *testing.T) {
+	name := "TestApp" + vgrand.RandomStr(10) + "ConfigFile"
+	err := paths.RegisterPath(paths.PathDefinition{
+		Name:        name,
+		Category:    paths.ConfigPathCategory,
+		Kind:        paths.FilePathKind,
+		Application: "test-app",
+		Path:        paths.JoinConfigPathStr("test-app", "config.toml"),
+		Description: "This file contains the configuration used by the test app.",
+	})
+	require.NoError(t, err)
+
+	vegaPaths := &paths.CustomPaths{
+		CustomHome: "/vega",
+		FileSystem: vgfs.NewMemoryFileSystem(),
+	}
+
+	// List.
+	list := paths.List(vegaPaths)
+	assert.Equal(t, vegaPaths.ConfigPathFor(paths.JoinConfigPath("test-app", "config.toml")), list.ConfigPaths[name])
+
+	// Explain.
+	description, err := paths.Explain(name)
+	require.NoError(t, err)
+	assert.Equal(t, "This file contains the configuration used by the test app.", description)
+
+	// Doctor.
+	report := paths.Doctor(vegaPaths, paths.DoctorOptions{})
+	entry := findDoctorEntry(t, report, name)
+	assert.Equal(t, "test-app", entry.Application)
+	require.Len(t, entry.Issues, 1)
+	assert.Equal(t, paths.MissingPathIssue, entry.Issues[0].Type)
+}
+
+func testRegisteringPathTwiceFails(t *testing.T) {
+	definition, ok := paths.LookupRegisteredPath("NodeDefaultConfigFile")
+	require.True(t, ok)
+
+	require.Error(t, paths.RegisterPath(definition))
+}
+
+func testRegisteringInvalidPathFails(t *testing.T) {
+	tcs := []struct {
+		name       string
+		definition paths.PathDefinition
+	}{
+		{
+			name: "without name",
+			definition: paths.PathDefinition{
+				Category: paths.StatePathCategory,
+				Kind:     paths.DirPathKind,
+				Path:     "test-app",
+			},
+		}, {
+			name: "without path",
+			definition: paths.PathDefinition{
+				Name:     "TestAppStateHome" + vgrand.RandomStr(10),
+				Category: paths.StatePathCategory,
+				Kind:     paths.DirPathKind,
+			},
+		}, {
+			name: "with unknown category",
+			definition: paths.PathDefinition{
+				Name:     "TestAppStateHome" + vgrand.RandomStr(10),
+				Category: "temporary",
+				Kind:     paths.DirPathKind,
+				Path:     "test-app",
+			},
+		}, {
+			name: "with unknown kind",
+			definition: paths.PathDefinition{
+				Name:     "TestAppStateHome" + vgrand.RandomStr(10),
+				Category: paths.StatePathCategory,
+				Kind:     "symlink",
+				Path:     "test-app",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(tt *testing.T) {
+			require.Error(tt, paths.RegisterPath(tc.definition))
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+
+	"github.com/adrg/xdg"
+)
+
+// The roots of the file structure can be set per category, so the files of
+// each category can live on a different disk, or in a location imposed by the
+// platform, like `/etc` for the configuration in containers. A category that
+// is not set explicitly is looked up in the `VEGA_*_HOME` environment
+// variables, and falls back to the default XDG location.
+//
+// Unlike the XDG ones, these roots directly contain the structure described
+// in paths.go, without an additional `vega` folder.
+//
+// File structure for roots:
+//
+// $VEGA_CACHE_HOME
+// $VEGA_CONFIG_HOME
+// $VEGA_DATA_HOME
+// $VEGA_STATE_HOME
+
+const (
+	CacheHomeEnv  = "VEGA_CACHE_HOME"
+	ConfigHomeEnv = "VEGA_CONFIG_HOME"
+	DataHomeEnv   = "VEGA_DATA_HOME"
+	StateHomeEnv  = "VEGA_STATE_HOME"
+)
+
+// RootOrigin tells where the root of a category comes from.
+type RootOrigin string
+
+const (
+	ExplicitRootOrigin    RootOrigin = "explicit"
+	EnvironmentRootOrigin RootOrigin = "environment"
+	XDGRootOrigin         RootOrigin = "xdg"
+	CustomHomeRootOrigin  RootOrigin = "custom-home"
+)
+
+// Root is the folder a category of files lives in.
+type Root struct {
+	Path   string     `json:"path"`
+	Origin RootOrigin `json:"origin"`
+}
+
+type RootPathsOptions struct {
+	// CacheHome, ConfigHome, DataHome and StateHome are the explicit roots of
+	// each category. They take precedence over the environment variables.
+	CacheHome  string
+	ConfigHome string
+	DataHome   string
+	StateHome  string
+
+	// FileSystem is the file system the directories are created on. It
+	// defaults to the OS file system.
+	FileSystem vgfs.FileSystem
+
+	// Permissions is the policy deciding the mode of the created directories.
+	// It defaults to the DefaultPermissions.
+	Permissions *PermissionPolicy
+
+	// LookupEnv looks up the environment variables. It defaults to
+	// os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+}
+
+// RootPaths is the Paths implementation with a separate root per category.
+type RootPaths struct {
+	roots       map[PathCategory]Root
+	fsys        vgfs.FileSystem
+	permissions *PermissionPolicy
+}
+
+// NewRootPaths resolves the root of every category, in order, from the
+// explicit roots, the `VEGA_*_HOME` environment variables, and the default
+// XDG locations.
+func NewRootPaths(options RootPathsOptions) *RootPaths {
+	lookupEnv := options.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	resolve := func(explicitRoot, envName, xdgHome string) Root {
+		if explicitRoot != "" {
+			return Root{Path: explicitRoot, Origin: ExplicitRootOrigin}
+		}
+		if envRoot, ok := lookupEnv(envName); ok && envRoot != "" {
+			return Root{Path: envRoot, Origin: EnvironmentRootOrigin}
+		}
+		return Root{Path: filepath.Join(xdgHome, VegaHome), Origin: XDGRootOrigin}
+	}
+
+	return &RootPaths{
+		roots: map[PathCategory]Root{
+			CachePathCategory:  resolve(options.CacheHome, CacheHomeEnv, xdg.CacheHome),
+			ConfigPathCategory: resolve(options.ConfigHome, ConfigHomeEnv, xdg.ConfigHome),
+			DataPathCategory:   resolve(options.DataHome, DataHomeEnv, xdg.DataHome),
+			StatePathCategory:  resolve(options.StateHome, StateHomeEnv, xdg.StateHome),
+		},
+		fsys:        vgfs.OrDefault(options.FileSystem),
+		permissions: options.Permissions,
+	}
+}
+
+// Roots returns the root of every category, and where it comes from.
+func (p *RootPaths) Roots() map[PathCategory]Root {
+	roots := make(map[PathCategory]Root, len(p.roots))
+	for category, root := range p.roots {
+		roots[category] = root
+	}
+	return roots
+}
+
+// CreateCachePathFor builds the path for a cache file under the cache root and
+// creates intermediate directories, if needed.
+func (p *RootPaths) CreateCachePathFor(relFilePath CachePath) (string, error) {
+	return createCustomPathIn(p.fileSystem(), p.CachePathFor(relFilePath), p.permissions.parentDirModeFor(CachePathCategory, relFilePath.String()))
+}
+
+// CreateCacheDirFor builds the path for a cache directory under the cache root
+// and creates it, along with intermediate directories, if needed.
+func (p *RootPaths) CreateCacheDirFor(relDirPath CachePath) (string, error) {
+	return createCustomDirIn(p.fileSystem(), p.CachePathFor(relDirPath), p.permissions.dirModeFor(CachePathCategory, relDirPath.String()))
+}
+
+// CreateConfigPathFor builds the path for a configuration file under the
+// config root and creates intermediate directories, if needed.
+func (p *RootPaths) CreateConfigPathFor(relFilePath ConfigPath) (string, error) {
+	return createCustomPathIn(p.fileSystem(), p.ConfigPathFor(relFilePath), p.permissions.parentDirModeFor(ConfigPathCategory, relFilePath.String()))
+}
+
+// CreateConfigDirFor builds the path for a config directory under the config
+// root and creates it, along with intermediate directories, if needed.
+func (p *RootPaths) CreateConfigDirFor(relDirPath ConfigPath) (string, error) {
+	return createCustomDirIn(p.fileSystem(), p.ConfigPathFor(relDirPath), p.permissions.dirModeFor(ConfigPathCategory, relDirPath.String()))
+}
+
+// CreateDataPathFor builds the path for a data file under the data root and
+// creates intermediate directories, if needed.
+func (p *RootPaths) CreateDataPathFor(relFilePath DataPath) (string, error) {
+	return createCustomPathIn(p.fileSystem(), p.DataPathFor(relFilePath), p.permissions.parentDirModeFor(DataPathCategory, relFilePath.String()))
+}
+
+// CreateDataDirFor builds the path for a data directory under the data root
+// and creates it, along with intermediate directories, if needed.
+func (p *RootPaths) CreateDataDirFor(relDirPath DataPath) (string, error) {
+	return createCustomDirIn(p.fileSystem(), p.DataPathFor(relDirPath), p.permissions.dirModeFor(DataPathCategory, relDirPath.String()))
+}
+
+// CreateStatePathFor builds the path for a state file under the state root and
+// creates intermediate directories, if needed.
+func (p *RootPaths) CreateStatePathFor(relFilePath StatePath) (string, error) {
+	return createCustomPathIn(p.fileSystem(), p.StatePathFor(relFilePath), p.permissions.parentDirModeFor(StatePathCategory, relFilePath.String()))
+}
+
+// CreateStateDirFor builds the path for a state directory under the state root
+// and creates it, along with intermediate directories, if needed.
+func (p *RootPaths) CreateStateDirFor(relDirPath StatePath) (string, error) {
+	return createCustomDirIn(p.fileSystem(), p.StatePathFor(relDirPath), p.permissions.dirModeFor(StatePathCategory, relDirPath.String()))
+}
+
+// CachePathFor builds the path for a cache file or directory under the cache
+// root. It doesn't create any resources.
+func (p *RootPaths) CachePathFor(relPath CachePath) string {
+	return filepath.Join(p.roots[CachePathCategory].Path, relPath.String())
+}
+
+// ConfigPathFor builds the path for a config file or directory under the
+// config root. It doesn't create any resources.
+func (p *RootPaths) ConfigPathFor(relPath ConfigPath) string {
+	return filepath.Join(p.roots[ConfigPathCategory].Path, relPath.String())
+}
+
+// DataPathFor builds the path for a data file or directory under the data
+// root. It doesn't create any resources.
+func (p *RootPaths) DataPathFor(relPath DataPath) string {
+	return filepath.Join(p.roots[DataPathCategory].Path, relPath.String())
+}
+
+// StatePathFor builds the path for a state file or directory under the state
+// root. It doesn't create any resources.
+func (p *RootPaths) StatePathFor(relPath StatePath) string {
+	return filepath.Join(p.roots[StatePathCategory].Path, relPath.String())
+}
+
+func (p *RootPaths) fileSystem() vgfs.FileSystem {
+	return p.fsys
+}
+
+func (p *RootPaths) permissionPolicy() *PermissionPolicy {
+	return p.permissions
+}
+
+// RootsOf returns the root of every category of the given Paths, and where it
+// comes from. It returns nil for implementations that don't expose their
+// roots.
+func RootsOf(vegaPaths Paths) map[PathCategory]Root {
+	if p, ok := vegaPaths.(rootsHolder); ok {
+		return p.Roots()
+	}
+	return nil
+}
+
+type rootsHolder interface {
+	Roots() map[PathCategory]Root
+}
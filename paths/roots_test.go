@@ -0,0 +1,90 @@
+package paths_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/adrg/xdg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootPaths(t *testing.T) {
+	t.Run("Roots are resolved by precedence", testRootsAreResolvedByPrecedence)
+	t.Run("Paths are built under their category root", testPathsAreBuiltUnderTheirCategoryRoot)
+	t.Run("Listing paths shows the roots origin", testListingPathsShowsTheRootsOrigin)
+}
+
+func testRootsAreResolvedByPrecedence(t *testing.T) {
+	env := map[string]string{
+		paths.ConfigHomeEnv: "/env/config",
+		paths.StateHomeEnv:  "/env/state",
+	}
+
+	vegaPaths := paths.NewRootPaths(paths.RootPathsOptions{
+		StateHome: "/mnt/large-disk/vega",
+		LookupEnv: func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		},
+	})
+
+	assert.Equal(t, map[paths.PathCategory]paths.Root{
+		paths.CachePathCategory:  {Path: filepath.Join(xdg.CacheHome, "vega"), Origin: paths.XDGRootOrigin},
+		paths.ConfigPathCategory: {Path: "/env/config", Origin: paths.EnvironmentRootOrigin},
+		paths.DataPathCategory:   {Path: filepath.Join(xdg.DataHome, "vega"), Origin: paths.XDGRootOrigin},
+		paths.StatePathCategory:  {Path: "/mnt/large-disk/vega", Origin: paths.ExplicitRootOrigin},
+	}, vegaPaths.Roots())
+}
+
+func testPathsAreBuiltUnderTheirCategoryRoot(t *testing.T) {
+	fsys := vgfs.NewMemoryFileSystem()
+	vegaPaths := paths.NewRootPaths(paths.RootPathsOptions{
+		CacheHome:  "/var/cache/vega",
+		ConfigHome: "/etc/vega",
+		DataHome:   "/var/lib/vega",
+		StateHome:  "/mnt/large-disk/vega",
+		FileSystem: fsys,
+	})
+
+	assert.Equal(t, filepath.Join("/var/cache/vega", "data-node"), vegaPaths.CachePathFor(paths.DataNodeCacheHome))
+	assert.Equal(t, filepath.Join("/etc/vega", "node", "config.toml"), vegaPaths.ConfigPathFor(paths.NodeDefaultConfigFile))
+	assert.Equal(t, filepath.Join("/var/lib/vega", "wallets"), vegaPaths.DataPathFor(paths.WalletsDataHome))
+	assert.Equal(t, filepath.Join("/mnt/large-disk/vega", "node", "logs"), vegaPaths.StatePathFor(paths.NodeLogsHome))
+
+	dir, err := vegaPaths.CreateStateDirFor(paths.NodeLogsHome)
+	require.NoError(t, err)
+	exists, err := vgfs.PathExistsIn(fsys, dir)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	path, err := vegaPaths.CreateConfigPathFor(paths.NodeDefaultConfigFile)
+	require.NoError(t, err)
+	exists, err = vgfs.PathExistsIn(fsys, filepath.Dir(path))
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func testListingPathsShowsTheRootsOrigin(t *testing.T) {
+	vegaPaths := paths.NewRootPaths(paths.RootPathsOptions{
+		ConfigHome: "/etc/vega",
+		LookupEnv: func(string) (string, bool) {
+			return "", false
+		},
+	})
+
+	list := paths.List(vegaPaths)
+
+	assert.Equal(t, filepath.Join("/etc/vega", "node", "config.toml"), list.ConfigPaths["NodeDefaultConfigFile"])
+	assert.Equal(t, paths.ExplicitRootOrigin, list.Roots[paths.ConfigPathCategory].Origin)
+	assert.Equal(t, paths.XDGRootOrigin, list.Roots[paths.StatePathCategory].Origin)
+
+	list = paths.List(&paths.CustomPaths{CustomHome: "/vega"})
+	assert.Equal(t, paths.Root{Path: filepath.Join("/vega", "config"), Origin: paths.CustomHomeRootOrigin}, list.Roots[paths.ConfigPathCategory])
+
+	list = paths.List(&paths.DefaultPaths{})
+	assert.Equal(t, paths.Root{Path: filepath.Join(xdg.ConfigHome, "vega"), Origin: paths.XDGRootOrigin}, list.Roots[paths.ConfigPathCategory])
+}
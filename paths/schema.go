@@ -0,0 +1,292 @@
+package paths
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+// DefaultSchemaVersionKey is the top-level key holding the schema version in
+// the versioned structured files.
+const DefaultSchemaVersionKey = "schema_version"
+
+// MigrationFunc upgrades a raw decoded document by one version. It modifies
+// the document in place.
+type MigrationFunc func(doc map[string]interface{}) error
+
+// Schema describes the current version of a structured file, and the
+// migrations that upgrade the older versions to it. Files without a version
+// are considered at version 0.
+type Schema struct {
+	// VersionKey is the top-level key holding the schema version. It
+	// defaults to DefaultSchemaVersionKey.
+	VersionKey string
+
+	version    int
+	migrations map[int]MigrationFunc
+}
+
+// UnsupportedSchemaVersionError is returned when a file has been written with
+// a newer schema than the one known by the program.
+type UnsupportedSchemaVersionError struct {
+	Path             string
+	Version          int
+	SupportedVersion int
+}
+
+func (e UnsupportedSchemaVersionError) Error() string {
+	return fmt.Sprintf("file %s has schema version %d, but only versions up to %d are supported", e.Path, e.Version, e.SupportedVersion)
+}
+
+// MigrationResult describes the migration applied while reading a file.
+type MigrationResult struct {
+	FromVersion int `json:"fromVersion"`
+	ToVersion   int `json:"toVersion"`
+	// BackupPath is the path of the copy of the original file, when the
+	// migrated file has been written back.
+	BackupPath string `json:"backupPath,omitempty"`
+}
+
+// Migrated tells if the file has been upgraded.
+func (r *MigrationResult) Migrated() bool {
+	return r.FromVersion != r.ToVersion
+}
+
+// NewSchema returns a schema at the given version, without any migration.
+func NewSchema(version int) *Schema {
+	return &Schema{
+		VersionKey: DefaultSchemaVersionKey,
+		version:    version,
+		migrations: map[int]MigrationFunc{},
+	}
+}
+
+// Version returns the current version of the schema.
+func (s *Schema) Version() int {
+	return s.version
+}
+
+// RegisterMigration registers the migration that upgrades the documents from
+// the given version to the next one.
+func (s *Schema) RegisterMigration(fromVersion int, migrate MigrationFunc) error {
+	if fromVersion < 0 || fromVersion >= s.version {
+		return fmt.Errorf("migration from version %d is out of the schema range [0, %d)", fromVersion, s.version)
+	}
+
+	if _, ok := s.migrations[fromVersion]; ok {
+		return fmt.Errorf("a migration from version %d is already registered", fromVersion)
+	}
+
+	s.migrations[fromVersion] = migrate
+	return nil
+}
+
+type ReadVersionedOptions struct {
+	// WriteBack writes the migrated document back to the file, after saving
+	// a copy of the original next to it. The file is locked exclusively from
+	// the read to the write back.
+	WriteBack bool
+	// Mode is the mode the migrated file, and its backup, are written with.
+	// It defaults to vgfs.DefaultFileMode. It usually comes from the
+	// permission policy, through PermissionsFor.
+	Mode fs.FileMode
+}
+
+// ReadVersionedStructuredFile reads a versioned structured file into v,
+// applying the registered migrations in memory if the file uses an older
+// version of the schema.
+func ReadVersionedStructuredFile(path string, v interface{}, schema *Schema, options ReadVersionedOptions) (*MigrationResult, error) {
+	if options.WriteBack {
+		lock, err := vgfs.LockExclusive(LockFilePathFor(path), DefaultLockTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't lock file: %w", err)
+		}
+		defer lock.Unlock()
+	}
+
+	original, err := vgfs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read file: %w", err)
+	}
+
+	doc, err := decodeDocument(path, original)
+	if err != nil {
+		return nil, err
+	}
+
+	fromVersion, err := schemaVersionOf(doc, schema.versionKey())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the schema version of %s: %w", path, err)
+	}
+
+	if fromVersion > schema.version {
+		return nil, UnsupportedSchemaVersionError{
+			Path:             path,
+			Version:          fromVersion,
+			SupportedVersion: schema.version,
+		}
+	}
+
+	for version := fromVersion; version < schema.version; version++ {
+		migrate, ok := schema.migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered from version %d", version)
+		}
+		if err := migrate(doc); err != nil {
+			return nil, fmt.Errorf("couldn't migrate %s from version %d: %w", path, version, err)
+		}
+	}
+	doc[schema.versionKey()] = schema.version
+
+	codec := CodecForPath(path)
+	migrated, err := codec.Encode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode the migrated document to %s: %w", codec.Name(), err)
+	}
+
+	if err := decodeStructuredFile(path, migrated, v); err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{
+		FromVersion: fromVersion,
+		ToVersion:   schema.version,
+	}
+
+	if options.WriteBack && result.Migrated() {
+		mode := options.Mode
+		if mode == 0 {
+			mode = vgfs.DefaultFileMode
+		}
+
+		backupPath := fmt.Sprintf("%s.v%d.%s.bak", path, fromVersion, time.Now().UTC().Format("20060102T150405"))
+		if err := vgfs.WriteFileWithMode(backupPath, original, mode); err != nil {
+			return nil, fmt.Errorf("couldn't back up the original file: %w", err)
+		}
+		if err := vgfs.WriteFileWithMode(path, migrated, mode); err != nil {
+			return nil, fmt.Errorf("couldn't write the migrated file: %w", err)
+		}
+		result.BackupPath = backupPath
+	}
+
+	return result, nil
+}
+
+// ReadVersionedStructuredFileFor behaves like ReadVersionedStructuredFile,
+// for the path relative to the root of the given category. The migrated file
+// is written back with the mode the permission policy of the Paths grants it.
+func ReadVersionedStructuredFileFor(vegaPaths Paths, category PathCategory, relPath string, v interface{}, schema *Schema, options ReadVersionedOptions) (*MigrationResult, error) {
+	options.Mode = PermissionsFor(vegaPaths, category, relPath).FileMode
+	return ReadVersionedStructuredFile(PathFor(vegaPaths, category, relPath), v, schema, options)
+}
+
+// WriteVersionedStructuredFile writes v to a structured file, tagged with the
+// current version of the schema.
+func WriteVersionedStructuredFile(path string, v interface{}, schema *Schema) error {
+	return WriteVersionedStructuredFileWithMode(path, v, schema, vgfs.DefaultFileMode)
+}
+
+// WriteVersionedStructuredFileWithMode behaves like
+// WriteVersionedStructuredFile, but sets the given mode on the file.
+func WriteVersionedStructuredFileWithMode(path string, v interface{}, schema *Schema, mode fs.FileMode) error {
+	codec := CodecForPath(path)
+	buf, err := codec.Encode(v)
+	if err != nil {
+		return fmt.Errorf("couldn't encode to %s: %w", codec.Name(), err)
+	}
+
+	doc, err := decodeDocument(path, buf)
+	if err != nil {
+		return fmt.Errorf("couldn't decode the encoded %s: %w", codec.Name(), err)
+	}
+	doc[schema.versionKey()] = schema.version
+
+	versioned, err := codec.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("couldn't encode to %s: %w", codec.Name(), err)
+	}
+
+	if err := vgfs.WriteFileWithMode(path, versioned, mode); err != nil {
+		return fmt.Errorf("couldn't write file: %w", err)
+	}
+
+	return nil
+}
+
+// WriteVersionedStructuredFileFor behaves like WriteVersionedStructuredFile,
+// for the path relative to the root of the given category. The directory and
+// the file are created with the modes the permission policy of the Paths
+// grants them.
+func WriteVersionedStructuredFileFor(vegaPaths Paths, category PathCategory, relPath string, v interface{}, schema *Schema) error {
+	path, mode, err := createFileFor(vegaPaths, category, relPath)
+	if err != nil {
+		return err
+	}
+	return WriteVersionedStructuredFileWithMode(path, v, schema, mode)
+}
+
+// decodeDocument decodes the file at the given path into a raw document. The
+// JSON numbers are kept as json.Number, so the integers beyond the precision
+// of a float64 survive the round trip through the document.
+func decodeDocument(path string, buf []byte) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if CodecForPath(path) != JSONCodec {
+		if err := decodeStructuredFile(path, buf, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+
+	if len(buf) == 0 {
+		return nil, ErrEmptyFile
+	}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid %s file: %w", JSONCodec.Name(), err)
+	}
+	return doc, nil
+}
+
+func (s *Schema) versionKey() string {
+	if s.VersionKey == "" {
+		return DefaultSchemaVersionKey
+	}
+	return s.VersionKey
+}
+
+// schemaVersionOf reads the version from the document. Each codec decodes
+// numbers to a different type, so they are all handled.
+func schemaVersionOf(doc map[string]interface{}, key string) (int, error) {
+	raw, ok := doc[key]
+	if !ok {
+		return 0, nil
+	}
+
+	switch version := raw.(type) {
+	case int:
+		return version, nil
+	case int64:
+		return int(version), nil
+	case uint64:
+		return int(version), nil
+	case json.Number:
+		parsed, err := version.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("%v is not an integer", version)
+		}
+		return int(parsed), nil
+	case float64:
+		if version != math.Trunc(version) {
+			return 0, fmt.Errorf("%v is not an integer", version)
+		}
+		return int(version), nil
+	default:
+		return 0, fmt.Errorf("unsupported version type %T", raw)
+	}
+}
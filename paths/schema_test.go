@@ -0,0 +1,213 @@
+package paths_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dummyDataV1 struct {
+	FullName string
+	Age      uint8
+}
+
+func TestVersionedStructuredFiles(t *testing.T) {
+	t.Run("Registering out of range migration fails", testRegisteringOutOfRangeMigrationFails)
+	t.Run("Registering duplicated migration fails", testRegisteringDuplicatedMigrationFails)
+	t.Run("Writing versioned file tags the version", testWritingVersionedFileTagsTheVersion)
+	t.Run("Reading up-to-date file does not migrate", testReadingUpToDateFileDoesNotMigrate)
+	t.Run("Reading old file migrates it in memory", testReadingOldFileMigratesItInMemory)
+	t.Run("Reading old file with write back upgrades it", testReadingOldFileWithWriteBackUpgradesIt)
+	t.Run("Reading newer file fails", testReadingNewerFileFails)
+	t.Run("Failing migration fails the read", testFailingMigrationFailsTheRead)
+	t.Run("Migrating JSON file keeps large integers", testMigratingJSONFileKeepsLargeIntegers)
+	t.Run("Writing back honours the policy", testWritingBackHonoursThePolicy)
+}
+
+func dummySchema(t *testing.T) *paths.Schema {
+	t.Helper()
+
+	schema := paths.NewSchema(1)
+	require.NoError(t, schema.RegisterMigration(0, func(doc map[string]interface{}) error {
+		doc["FullName"] = doc["Name"]
+		delete(doc, "Name")
+		return nil
+	}))
+	return schema
+}
+
+func testRegisteringOutOfRangeMigrationFails(t *testing.T) {
+	schema := paths.NewSchema(1)
+
+	require.Error(t, schema.RegisterMigration(1, func(map[string]interface{}) error { return nil }))
+	require.Error(t, schema.RegisterMigration(-1, func(map[string]interface{}) error { return nil }))
+}
+
+func testRegisteringDuplicatedMigrationFails(t *testing.T) {
+	schema := dummySchema(t)
+
+	require.Error(t, schema.RegisterMigration(0, func(map[string]interface{}) error { return nil }))
+}
+
+func testWritingVersionedFileTagsTheVersion(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+
+	err := paths.WriteVersionedStructuredFile(path, &dummyDataV1{FullName: "Jane", Age: 40}, dummySchema(t))
+	require.NoError(t, err)
+	vgtest.AssertFileAccess(t, path)
+
+	doc := map[string]interface{}{}
+	require.NoError(t, paths.ReadStructuredFile(path, &doc))
+	assert.Equal(t, int64(1), doc[paths.DefaultSchemaVersionKey])
+}
+
+func testReadingUpToDateFileDoesNotMigrate(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	schema := dummySchema(t)
+	require.NoError(t, paths.WriteVersionedStructuredFile(path, &dummyDataV1{FullName: "Jane", Age: 40}, schema))
+
+	readData := &dummyDataV1{}
+	result, err := paths.ReadVersionedStructuredFile(path, readData, schema, paths.ReadVersionedOptions{WriteBack: true})
+	require.NoError(t, err)
+	assert.False(t, result.Migrated())
+	assert.Empty(t, result.BackupPath)
+	assert.Equal(t, &dummyDataV1{FullName: "Jane", Age: 40}, readData)
+}
+
+func testReadingOldFileMigratesItInMemory(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 40}))
+	original, err := vgfs.ReadFile(path)
+	require.NoError(t, err)
+
+	readData := &dummyDataV1{}
+	result, err := paths.ReadVersionedStructuredFile(path, readData, dummySchema(t), paths.ReadVersionedOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.Migrated())
+	assert.Equal(t, 0, result.FromVersion)
+	assert.Equal(t, 1, result.ToVersion)
+	assert.Equal(t, &dummyDataV1{FullName: "Jane", Age: 40}, readData)
+
+	// The file should be left untouched.
+	current, err := vgfs.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, current)
+}
+
+func testReadingOldFileWithWriteBackUpgradesIt(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	require.NoError(t, vgfs.EnsureDir(home))
+	path := filepath.Join(home, "config.toml")
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 40}))
+	original, err := vgfs.ReadFile(path)
+	require.NoError(t, err)
+	schema := dummySchema(t)
+
+	result, err := paths.ReadVersionedStructuredFile(path, &dummyDataV1{}, schema, paths.ReadVersionedOptions{WriteBack: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.BackupPath)
+
+	backup, err := vgfs.ReadFile(result.BackupPath)
+	require.NoError(t, err)
+	assert.Equal(t, original, backup)
+
+	readData := &dummyDataV1{}
+	result, err = paths.ReadVersionedStructuredFile(path, readData, schema, paths.ReadVersionedOptions{})
+	require.NoError(t, err)
+	assert.False(t, result.Migrated())
+	assert.Equal(t, &dummyDataV1{FullName: "Jane", Age: 40}, readData)
+}
+
+func testReadingNewerFileFails(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	require.NoError(t, paths.WriteVersionedStructuredFile(path, &dummyDataV1{FullName: "Jane"}, paths.NewSchema(2)))
+
+	_, err := paths.ReadVersionedStructuredFile(path, &dummyDataV1{}, dummySchema(t), paths.ReadVersionedOptions{})
+
+	var versionErr paths.UnsupportedSchemaVersionError
+	require.ErrorAs(t, err, &versionErr)
+	assert.Equal(t, 2, versionErr.Version)
+	assert.Equal(t, 1, versionErr.SupportedVersion)
+}
+
+func testFailingMigrationFailsTheRead(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 40}))
+	migrationErr := errors.New("migration failed")
+	schema := paths.NewSchema(1)
+	require.NoError(t, schema.RegisterMigration(0, func(map[string]interface{}) error {
+		return migrationErr
+	}))
+
+	_, err := paths.ReadVersionedStructuredFile(path, &dummyDataV1{}, schema, paths.ReadVersionedOptions{WriteBack: true})
+	require.ErrorIs(t, err, migrationErr)
+}
+
+func testMigratingJSONFileKeepsLargeIntegers(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	require.NoError(t, vgfs.EnsureDir(home))
+	path := filepath.Join(home, "state.json")
+	// 2^53 + 1 can't be represented by a float64.
+	require.NoError(t, vgfs.WriteFile(path, []byte(`{"Name":"Jane","Height":9007199254740993}`)))
+
+	type dummyState struct {
+		FullName string
+		Height   uint64
+	}
+
+	readData := &dummyState{}
+	result, err := paths.ReadVersionedStructuredFile(path, readData, dummySchema(t), paths.ReadVersionedOptions{WriteBack: true})
+	require.NoError(t, err)
+	assert.True(t, result.Migrated())
+	assert.Equal(t, &dummyState{FullName: "Jane", Height: 9007199254740993}, readData)
+
+	migrated, err := vgfs.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(migrated), "9007199254740993")
+
+	require.NoError(t, paths.WriteVersionedStructuredFile(path, &dummyState{FullName: "Jane", Height: 9007199254740995}, dummySchema(t)))
+	readData = &dummyState{}
+	_, err = paths.ReadVersionedStructuredFile(path, readData, dummySchema(t), paths.ReadVersionedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9007199254740995), readData.Height)
+}
+
+func testWritingBackHonoursThePolicy(t *testing.T) {
+	vegaHome := vgtest.RandomPath()
+	defer os.RemoveAll(vegaHome)
+
+	policy := paths.DefaultPermissionPolicy()
+	policy.OverrideConfigPath(paths.NodeConfigHome, paths.Permissions{FileMode: 0640})
+	vegaPaths := &paths.CustomPaths{
+		CustomHome:  vegaHome,
+		Permissions: policy,
+	}
+	configFile := filepath.Join(paths.NodeConfigHome.String(), "config.toml")
+	configPath, err := vegaPaths.CreateConfigPathFor(paths.ConfigPath(configFile))
+	require.NoError(t, err)
+	require.NoError(t, paths.WriteStructuredFile(configPath, &DummyData{Name: "Jane", Age: 40}))
+
+	result, err := paths.ReadVersionedStructuredFileFor(vegaPaths, paths.ConfigPathCategory, configFile, &dummyDataV1{}, dummySchema(t), paths.ReadVersionedOptions{WriteBack: true})
+	require.NoError(t, err)
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, configFile)
+	vgtest.AssertFileAccessWithMode(t, result.BackupPath, 0640)
+
+	versionedFile := filepath.Join(paths.NodeConfigHome.String(), "versioned.toml")
+	require.NoError(t, paths.WriteVersionedStructuredFileFor(vegaPaths, paths.ConfigPathCategory, versionedFile, &dummyDataV1{FullName: "Jane"}, dummySchema(t)))
+	vgtest.AssertFileAccessFor(t, vegaPaths, paths.ConfigPathCategory, versionedFile)
+}
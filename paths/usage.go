@@ -0,0 +1,268 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+// PathUsage is the disk space used by a registered path.
+type PathUsage struct {
+	Name     string       `json:"name"`
+	Category PathCategory `json:"category"`
+	Path     string       `json:"path"`
+	Exists   bool         `json:"exists"`
+	// Size is the total size of the files, in bytes. It includes the files
+	// of the registered paths nested in this one.
+	Size int64 `json:"size"`
+	// Files is the number of files.
+	Files int `json:"files"`
+}
+
+// RetentionPolicy decides which entries of a folder are purged. An entry is
+// purged as soon as one of the rules requires it. The zero value of a rule
+// disables it.
+type RetentionPolicy struct {
+	// KeepLast is the number of most recent entries to keep.
+	KeepLast int `json:"keepLast,omitempty"`
+	// MaxAge is the age above which the entries are purged.
+	MaxAge time.Duration `json:"maxAge,omitempty"`
+	// MaxTotalSize is the size, in bytes, the entries can't exceed. The
+	// oldest entries are purged first.
+	MaxTotalSize int64 `json:"maxTotalSize,omitempty"`
+}
+
+// requiresPurge tells if the entry at the given rank, starting from the most
+// recent one, has to be purged. The total size includes the size of the entry,
+// and of all the more recent entries kept.
+func (p RetentionPolicy) requiresPurge(rank int, age time.Duration, totalSize int64) bool {
+	return (p.KeepLast > 0 && rank >= p.KeepLast) ||
+		(p.MaxAge > 0 && age > p.MaxAge) ||
+		(p.MaxTotalSize > 0 && totalSize > p.MaxTotalSize)
+}
+
+type PurgeOptions struct {
+	// DryRun only reports the entries that would be purged.
+	DryRun bool
+}
+
+// PurgedEntry is a file, or a folder, purged from a registered path.
+type PurgedEntry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+type PurgeResult struct {
+	Name   string        `json:"name"`
+	Path   string        `json:"path"`
+	DryRun bool          `json:"dryRun"`
+	Purged []PurgedEntry `json:"purged"`
+	// FreedSize is the size, in bytes, of the purged entries.
+	FreedSize int64 `json:"freedSize"`
+	// Kept is the number of entries left in place.
+	Kept int `json:"kept"`
+}
+
+// PurgeRefusedError is returned when purging a path that must not be purged.
+type PurgeRefusedError struct {
+	Name   string
+	Reason string
+}
+
+func (e PurgeRefusedError) Error() string {
+	return fmt.Sprintf("couldn't purge path %q: %s", e.Name, e.Reason)
+}
+
+// DiskUsage reports the disk space used by every registered path. Missing
+// paths are reported with a zero size.
+func DiskUsage(vegaPaths Paths) ([]PathUsage, error) {
+	fsys := FileSystemOf(vegaPaths)
+
+	usages := []PathUsage{}
+	for _, definition := range RegisteredPaths() {
+		usage := PathUsage{
+			Name:     definition.Name,
+			Category: definition.Category,
+			Path:     definition.Resolve(vegaPaths),
+		}
+
+		size, files, err := sizeOf(fsys, usage.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("couldn't measure the size of %s: %w", usage.Path, err)
+		}
+		if err == nil {
+			usage.Exists = true
+			usage.Size = size
+			usage.Files = files
+		}
+
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
+
+// Purge removes the entries of the registered directory with the given name,
+// according to the retention policy. Only the cache and state directories can
+// be purged: the configuration and the data are never touched. The entries
+// that are registered paths themselves, like the logs folder in the node state
+// home, are never purged.
+func Purge(vegaPaths Paths, name string, policy RetentionPolicy, options PurgeOptions) (*PurgeResult, error) {
+	definition, ok := LookupRegisteredPath(name)
+	if !ok {
+		return nil, fmt.Errorf("path %q is not registered", name)
+	}
+
+	if definition.Category != CachePathCategory && definition.Category != StatePathCategory {
+		return nil, PurgeRefusedError{
+			Name:   name,
+			Reason: fmt.Sprintf("only cache and state paths can be purged, but it is a %s path", definition.Category),
+		}
+	}
+
+	if definition.Kind != DirPathKind {
+		return nil, PurgeRefusedError{
+			Name:   name,
+			Reason: "only directories can be purged",
+		}
+	}
+
+	fsys := FileSystemOf(vegaPaths)
+	dirPath := definition.Resolve(vegaPaths)
+
+	result := &PurgeResult{
+		Name:   name,
+		Path:   dirPath,
+		DryRun: options.DryRun,
+		Purged: []PurgedEntry{},
+	}
+
+	entries, err := fsys.ReadDir(dirPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return nil, fmt.Errorf("couldn't read directory %s: %w", dirPath, err)
+	}
+
+	protectedPaths := map[string]struct{}{}
+	for _, registered := range RegisteredPaths() {
+		protectedPaths[registered.Resolve(vegaPaths)] = struct{}{}
+	}
+
+	candidates := []PurgedEntry{}
+	for _, entry := range entries {
+		entryPath := filepath.Join(dirPath, entry.Name())
+		if _, protected := protectedPaths[entryPath]; protected {
+			continue
+		}
+
+		// The entry isn't followed if it is a symlink, as only the link is
+		// removed.
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't inspect %s: %w", entryPath, err)
+		}
+
+		size, _, err := sizeOfEntry(fsys, entryPath, entry)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't measure the size of %s: %w", entryPath, err)
+		}
+
+		candidates = append(candidates, PurgedEntry{
+			Path:    entryPath,
+			Size:    size,
+			ModTime: info.ModTime(),
+		})
+	}
+
+	// The most recent entries come first, so they are the ones kept.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].ModTime.After(candidates[j].ModTime)
+	})
+
+	now := time.Now()
+	var keptSize int64
+	for i, candidate := range candidates {
+		if !policy.requiresPurge(i, now.Sub(candidate.ModTime), keptSize+candidate.Size) {
+			keptSize += candidate.Size
+			result.Kept++
+			continue
+		}
+
+		if !options.DryRun {
+			if err := fsys.RemoveAll(candidate.Path); err != nil {
+				return nil, fmt.Errorf("couldn't remove %s: %w", candidate.Path, err)
+			}
+		}
+
+		result.Purged = append(result.Purged, candidate)
+		result.FreedSize += candidate.Size
+	}
+
+	return result, nil
+}
+
+// sizeOf returns the total size, and the number, of the files at the given
+// path. The path itself is followed if it is a symlink, as a whole directory
+// may be linked to another disk, but not the symlinks under it.
+func sizeOf(fsys vgfs.FileSystem, path string) (int64, int, error) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !info.IsDir() {
+		return info.Size(), 1, nil
+	}
+
+	return sizeOfDir(fsys, path)
+}
+
+func sizeOfDir(fsys vgfs.FileSystem, dirPath string) (int64, int, error) {
+	entries, err := fsys.ReadDir(dirPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		totalSize  int64
+		totalFiles int
+	)
+	for _, entry := range entries {
+		size, files, err := sizeOfEntry(fsys, filepath.Join(dirPath, entry.Name()), entry)
+		if err != nil {
+			return 0, 0, err
+		}
+		totalSize += size
+		totalFiles += files
+	}
+
+	return totalSize, totalFiles, nil
+}
+
+// sizeOfEntry returns the total size, and the number, of the files of the
+// directory entry. A symlink is counted as a file without size, and is not
+// followed, so a link to a parent doesn't loop, and a link to another tree
+// doesn't add up its size.
+func sizeOfEntry(fsys vgfs.FileSystem, path string, entry fs.DirEntry) (int64, int, error) {
+	switch {
+	case entry.Type()&fs.ModeSymlink != 0:
+		return 0, 1, nil
+	case entry.IsDir():
+		return sizeOfDir(fsys, path)
+	default:
+		info, err := entry.Info()
+		if err != nil {
+			return 0, 0, err
+		}
+		return info.Size(), 1, nil
+	}
+}
@@ -0,0 +1,215 @@
+package paths_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsage(t *testing.T) {
+	t.Run("Disk usage reports the size of every registered path", testDiskUsageReportsTheSizeOfEveryRegisteredPath)
+	t.Run("Disk usage does not follow the symlinks", testDiskUsageDoesNotFollowTheSymlinks)
+}
+
+func TestPurge(t *testing.T) {
+	t.Run("Purging config or data paths is refused", testPurgingConfigOrDataPathsIsRefused)
+	t.Run("Purging keeps the last entries", testPurgingKeepsTheLastEntries)
+	t.Run("Purging removes the entries above max age", testPurgingRemovesTheEntriesAboveMaxAge)
+	t.Run("Purging removes the oldest entries above max total size", testPurgingRemovesTheOldestEntriesAboveMaxTotalSize)
+	t.Run("Purging in dry-run does not remove anything", testPurgingInDryRunDoesNotRemoveAnything)
+	t.Run("Purging does not remove nested registered paths", testPurgingDoesNotRemoveNestedRegisteredPaths)
+}
+
+func testDiskUsageReportsTheSizeOfEveryRegisteredPath(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	logsHome, err := vegaPaths.CreateStateDirFor(paths.NodeLogsHome)
+	require.NoError(t, err)
+	require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, filepath.Join(logsHome, "1.log"), make([]byte, 100)))
+	require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, filepath.Join(logsHome, "2.log"), make([]byte, 50)))
+
+	usages, err := paths.DiskUsage(vegaPaths)
+	require.NoError(t, err)
+
+	usagesByName := map[string]paths.PathUsage{}
+	for _, usage := range usages {
+		usagesByName[usage.Name] = usage
+	}
+
+	assert.Equal(t, paths.PathUsage{
+		Name:     "NodeLogsHome",
+		Category: paths.StatePathCategory,
+		Path:     logsHome,
+		Exists:   true,
+		Size:     150,
+		Files:    2,
+	}, usagesByName["NodeLogsHome"])
+	assert.Equal(t, int64(150), usagesByName["NodeStateHome"].Size)
+	assert.False(t, usagesByName["WalletsDataHome"].Exists)
+}
+
+func testDiskUsageDoesNotFollowTheSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires privileges on Windows")
+	}
+
+	vegaHome := vgtest.RandomPath()
+	defer os.RemoveAll(vegaHome)
+	outsideHome := vgtest.RandomPath()
+	defer os.RemoveAll(outsideHome)
+
+	vegaPaths := &paths.CustomPaths{CustomHome: vegaHome}
+	logsHome, err := vegaPaths.CreateStateDirFor(paths.NodeLogsHome)
+	require.NoError(t, err)
+	require.NoError(t, vgfs.WriteFile(filepath.Join(logsHome, "1.log"), make([]byte, 100)))
+	require.NoError(t, vgfs.EnsureDir(outsideHome))
+	require.NoError(t, vgfs.WriteFile(filepath.Join(outsideHome, "big.log"), make([]byte, 1000)))
+	// A loop back to a parent, and a link to another tree.
+	require.NoError(t, os.Symlink(filepath.Dir(logsHome), filepath.Join(logsHome, "parent")))
+	require.NoError(t, os.Symlink(outsideHome, filepath.Join(logsHome, "outside")))
+
+	usages, err := paths.DiskUsage(vegaPaths)
+	require.NoError(t, err)
+
+	for _, usage := range usages {
+		if usage.Name == "NodeLogsHome" {
+			assert.Equal(t, int64(100), usage.Size)
+			assert.Equal(t, 3, usage.Files)
+		}
+	}
+
+	result, err := paths.Purge(vegaPaths, "NodeLogsHome", paths.RetentionPolicy{KeepLast: 1}, paths.PurgeOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, result.Purged, 2)
+	assert.LessOrEqual(t, result.FreedSize, int64(100))
+}
+
+func testPurgingConfigOrDataPathsIsRefused(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+
+	for _, name := range []string{"NodeConfigHome", "WalletsDataHome", "ProfilesStateFile"} {
+		_, err := paths.Purge(vegaPaths, name, paths.RetentionPolicy{KeepLast: 1}, paths.PurgeOptions{})
+		var refusedErr paths.PurgeRefusedError
+		assert.ErrorAs(t, err, &refusedErr, name)
+	}
+
+	_, err := paths.Purge(vegaPaths, "UnknownHome", paths.RetentionPolicy{}, paths.PurgeOptions{})
+	assert.Error(t, err)
+}
+
+// setUpCheckpoints creates checkpoints of 10 bytes each, the first one being
+// the oldest, a day apart.
+func setUpCheckpoints(t *testing.T, vegaPaths paths.Paths, count int) []string {
+	t.Helper()
+
+	checkpointsHome, err := vegaPaths.CreateStateDirFor(paths.CheckpointStateHome)
+	require.NoError(t, err)
+
+	now := time.Now()
+	checkpoints := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		checkpoint := filepath.Join(checkpointsHome, fmt.Sprintf("%d.cp", i))
+		require.NoError(t, vgfs.WriteFile(checkpoint, make([]byte, 10)))
+		modTime := now.Add(-time.Duration(count-i) * 24 * time.Hour)
+		require.NoError(t, os.Chtimes(checkpoint, modTime, modTime))
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints
+}
+
+func testPurgingKeepsTheLastEntries(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+	checkpoints := setUpCheckpoints(t, vegaPaths, 5)
+
+	result, err := paths.Purge(vegaPaths, "CheckpointStateHome", paths.RetentionPolicy{KeepLast: 2}, paths.PurgeOptions{})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Purged, 3)
+	assert.Equal(t, 2, result.Kept)
+	assert.Equal(t, int64(30), result.FreedSize)
+	for _, checkpoint := range checkpoints[:3] {
+		assert.NoFileExists(t, checkpoint)
+	}
+	for _, checkpoint := range checkpoints[3:] {
+		assert.FileExists(t, checkpoint)
+	}
+}
+
+func testPurgingRemovesTheEntriesAboveMaxAge(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+	checkpoints := setUpCheckpoints(t, vegaPaths, 5)
+
+	result, err := paths.Purge(vegaPaths, "CheckpointStateHome", paths.RetentionPolicy{MaxAge: 36 * time.Hour}, paths.PurgeOptions{})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Purged, 4)
+	for _, checkpoint := range checkpoints[:4] {
+		assert.NoFileExists(t, checkpoint)
+	}
+	assert.FileExists(t, checkpoints[4])
+}
+
+func testPurgingRemovesTheOldestEntriesAboveMaxTotalSize(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+	checkpoints := setUpCheckpoints(t, vegaPaths, 5)
+
+	result, err := paths.Purge(vegaPaths, "CheckpointStateHome", paths.RetentionPolicy{MaxTotalSize: 35}, paths.PurgeOptions{})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Purged, 2)
+	for _, checkpoint := range checkpoints[:2] {
+		assert.NoFileExists(t, checkpoint)
+	}
+	for _, checkpoint := range checkpoints[2:] {
+		assert.FileExists(t, checkpoint)
+	}
+}
+
+func testPurgingInDryRunDoesNotRemoveAnything(t *testing.T) {
+	home := vgtest.RandomPath()
+	defer os.RemoveAll(home)
+	vegaPaths := paths.New(home)
+	checkpoints := setUpCheckpoints(t, vegaPaths, 5)
+
+	result, err := paths.Purge(vegaPaths, "CheckpointStateHome", paths.RetentionPolicy{KeepLast: 1}, paths.PurgeOptions{DryRun: true})
+	require.NoError(t, err)
+
+	assert.True(t, result.DryRun)
+	assert.Len(t, result.Purged, 4)
+	for _, checkpoint := range checkpoints {
+		assert.FileExists(t, checkpoint)
+	}
+}
+
+func testPurgingDoesNotRemoveNestedRegisteredPaths(t *testing.T) {
+	vegaPaths := newMemoryCustomPaths()
+	ldbPath, err := vegaPaths.CreateStateDirFor(paths.SnapshotDBStateFile)
+	require.NoError(t, err)
+	snapshotsHome := vegaPaths.StatePathFor(paths.SnapshotStateHome)
+	require.NoError(t, vgfs.WriteFileIn(vegaPaths.FileSystem, filepath.Join(snapshotsHome, "old-snapshot"), []byte("snapshot")))
+
+	result, err := paths.Purge(vegaPaths, "SnapshotStateHome", paths.RetentionPolicy{MaxTotalSize: 1}, paths.PurgeOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, result.Purged, 1)
+	assert.Equal(t, filepath.Join(snapshotsHome, "old-snapshot"), result.Purged[0].Path)
+	exists, err := vgfs.PathExistsIn(vegaPaths.FileSystem, ldbPath)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
@@ -0,0 +1,204 @@
+package paths
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+)
+
+const (
+	defaultWatcherPollInterval = time.Second
+	defaultWatcherDebounce     = 500 * time.Millisecond
+)
+
+type WatcherOptions struct {
+	// PollInterval is the time between two checks of the file. It defaults to
+	// 1 second.
+	PollInterval time.Duration
+
+	// Debounce is the time the file content has to remain stable before it
+	// is reloaded. It prevents reading a file that is still being written. It
+	// defaults to 500 milliseconds. A negative value disables it.
+	Debounce time.Duration
+}
+
+// ConfigWatcher watches a structured file, and reloads it when its content
+// changes. Each reload is decoded into a fresh value, validated, and delivered
+// on the Updates channel. When the reload fails, the error is delivered on the
+// Errors channel, and the last good configuration is kept.
+//
+// Only the latest update and the latest error are kept for delivery. Older
+// ones are dropped if the consumer doesn't keep up.
+type ConfigWatcher[T any] struct {
+	path     string
+	validate func(*T) error
+	options  WatcherOptions
+
+	mu      sync.RWMutex
+	current *T
+
+	lastLoadedSum [sha256.Size]byte
+
+	updates chan *T
+	errors  chan error
+}
+
+// NewConfigWatcher loads the structured file at the given path, and returns a
+// watcher that reloads it on change. The validate function is optional. The
+// initial load has to succeed.
+func NewConfigWatcher[T any](path string, validate func(*T) error, options WatcherOptions) (*ConfigWatcher[T], error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultWatcherPollInterval
+	}
+	if options.Debounce < 0 {
+		options.Debounce = 0
+	} else if options.Debounce == 0 {
+		options.Debounce = defaultWatcherDebounce
+	}
+
+	w := &ConfigWatcher[T]{
+		path:     path,
+		validate: validate,
+		options:  options,
+		updates:  make(chan *T, 1),
+		errors:   make(chan error, 1),
+	}
+
+	buf, err := vgfs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read file: %w", err)
+	}
+
+	cfg, err := w.load(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	w.current = cfg
+	w.lastLoadedSum = sha256.Sum256(buf)
+
+	return w, nil
+}
+
+// WatchConfigFile creates a ConfigWatcher on the configuration file resolved
+// by the given Paths.
+func WatchConfigFile[T any](vegaPaths Paths, configPath ConfigPath, validate func(*T) error, options WatcherOptions) (*ConfigWatcher[T], error) {
+	return NewConfigWatcher(vegaPaths.ConfigPathFor(configPath), validate, options)
+}
+
+// Current returns the last good configuration.
+func (w *ConfigWatcher[T]) Current() *T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Updates delivers the new configurations as they are successfully reloaded.
+func (w *ConfigWatcher[T]) Updates() <-chan *T {
+	return w.updates
+}
+
+// Errors delivers the errors that prevented a reload.
+func (w *ConfigWatcher[T]) Errors() <-chan error {
+	return w.errors
+}
+
+// Run watches the file until the context is cancelled. It blocks.
+func (w *ConfigWatcher[T]) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.options.PollInterval)
+	defer ticker.Stop()
+
+	var (
+		pendingSum   [sha256.Size]byte
+		pendingSince time.Time
+		hasPending   bool
+		lastReadErr  string
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		buf, err := vgfs.ReadFile(w.path)
+		if err != nil {
+			// The same error is only reported once, to not flood the
+			// consumer while the file is missing.
+			if err.Error() != lastReadErr {
+				lastReadErr = err.Error()
+				w.reportError(fmt.Errorf("couldn't read file: %w", err))
+			}
+			hasPending = false
+			continue
+		}
+		lastReadErr = ""
+
+		sum := sha256.Sum256(buf)
+		if sum == w.lastLoadedSum {
+			hasPending = false
+			continue
+		}
+
+		if !hasPending || sum != pendingSum {
+			pendingSum = sum
+			pendingSince = time.Now()
+			hasPending = true
+		}
+
+		if time.Since(pendingSince) < w.options.Debounce {
+			continue
+		}
+
+		hasPending = false
+		w.lastLoadedSum = sum
+
+		cfg, err := w.load(buf)
+		if err != nil {
+			w.reportError(err)
+			continue
+		}
+
+		w.mu.Lock()
+		w.current = cfg
+		w.mu.Unlock()
+
+		w.reportUpdate(cfg)
+	}
+}
+
+func (w *ConfigWatcher[T]) load(buf []byte) (*T, error) {
+	cfg := new(T)
+	if err := decodeStructuredFile(w.path, buf, cfg); err != nil {
+		return nil, fmt.Errorf("couldn't decode %s: %w", w.path, err)
+	}
+
+	if w.validate != nil {
+		if err := w.validate(cfg); err != nil {
+			return nil, fmt.Errorf("invalid configuration in %s: %w", w.path, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func (w *ConfigWatcher[T]) reportUpdate(cfg *T) {
+	select {
+	case <-w.updates:
+	default:
+	}
+	w.updates <- cfg
+}
+
+func (w *ConfigWatcher[T]) reportError(err error) {
+	select {
+	case <-w.errors:
+	default:
+	}
+	w.errors <- err
+}
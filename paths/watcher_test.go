@@ -0,0 +1,115 @@
+package paths_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	vgfs "code.vegaprotocol.io/shared/libs/fs"
+	vgtest "code.vegaprotocol.io/shared/libs/test"
+	"code.vegaprotocol.io/shared/paths"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errAgeTooHigh = errors.New("age is too high")
+
+func TestConfigWatcher(t *testing.T) {
+	t.Run("Creating watcher on non-existing file fails", testCreatingWatcherOnNonExistingFileFails)
+	t.Run("Changing watched file delivers new config", testChangingWatchedFileDeliversNewConfig)
+	t.Run("Writing invalid file keeps last good config", testWritingInvalidFileKeepsLastGoodConfig)
+	t.Run("Writing file failing validation keeps last good config", testWritingFileFailingValidationKeepsLastGoodConfig)
+}
+
+func testCreatingWatcherOnNonExistingFileFails(t *testing.T) {
+	w, err := paths.NewConfigWatcher[DummyData](vgtest.RandomPath(), nil, paths.WatcherOptions{})
+	require.Error(t, err)
+	assert.Nil(t, w)
+}
+
+func testChangingWatchedFileDeliversNewConfig(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 40}))
+
+	w := startWatcher(t, path, nil)
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, w.Current())
+
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "John", Age: 30}))
+
+	select {
+	case cfg := <-w.Updates():
+		assert.Equal(t, &DummyData{Name: "John", Age: 30}, cfg)
+		assert.Equal(t, cfg, w.Current())
+	case err := <-w.Errors():
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+	}
+}
+
+func testWritingInvalidFileKeepsLastGoodConfig(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 40}))
+
+	w := startWatcher(t, path, nil)
+
+	require.NoError(t, vgfs.WriteFile(path, []byte("Name = ")))
+
+	select {
+	case cfg := <-w.Updates():
+		t.Fatalf("unexpected update: %v", cfg)
+	case err := <-w.Errors():
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no error received")
+	}
+
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, w.Current())
+}
+
+func testWritingFileFailingValidationKeepsLastGoodConfig(t *testing.T) {
+	path := vgtest.RandomPath()
+	defer os.RemoveAll(path)
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 40}))
+
+	w := startWatcher(t, path, func(d *DummyData) error {
+		if d.Age > 100 {
+			return errAgeTooHigh
+		}
+		return nil
+	})
+
+	require.NoError(t, paths.WriteStructuredFile(path, &DummyData{Name: "Jane", Age: 200}))
+
+	select {
+	case cfg := <-w.Updates():
+		t.Fatalf("unexpected update: %v", cfg)
+	case err := <-w.Errors():
+		assert.ErrorIs(t, err, errAgeTooHigh)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no error received")
+	}
+
+	assert.Equal(t, &DummyData{Name: "Jane", Age: 40}, w.Current())
+}
+
+func startWatcher(t *testing.T, path string, validate func(*DummyData) error) *paths.ConfigWatcher[DummyData] {
+	t.Helper()
+
+	w, err := paths.NewConfigWatcher(path, validate, paths.WatcherOptions{
+		PollInterval: 10 * time.Millisecond,
+		Debounce:     30 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go w.Run(ctx)
+
+	return w
+}